@@ -0,0 +1,47 @@
+// Entry point for 'pgcenter exporter' command.
+
+package exporter
+
+import (
+	"github.com/lesovsky/pgcenter/exporter"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var (
+	exporterConfig exporter.Config
+	connOptions    postgres.ConnectionOptions
+
+	// CommandDefinition defines 'exporter' sub-command.
+	CommandDefinition = &cobra.Command{
+		Use:   "exporter",
+		Short: "expose stats to Prometheus",
+		Long:  `'pgcenter exporter' connects to PostgreSQL and exposes its stats and system stats in Prometheus format.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			// Parse extra arguments.
+			if len(args) > 0 {
+				connOptions.ParseExtraArgs(args)
+			}
+
+			// Create connection config.
+			pgConfig, err := postgres.NewConfig(connOptions.Host, connOptions.Port, connOptions.User, connOptions.Dbname)
+			if err != nil {
+				return err
+			}
+
+			return exporter.RunMain(pgConfig, exporterConfig)
+		},
+	}
+)
+
+func init() {
+	CommandDefinition.Flags().StringVarP(&connOptions.Host, "host", "h", "", "database server host or socket directory")
+	CommandDefinition.Flags().IntVarP(&connOptions.Port, "port", "p", 5432, "database server port")
+	CommandDefinition.Flags().StringVarP(&connOptions.User, "username", "U", "", "database user name")
+	CommandDefinition.Flags().StringVarP(&connOptions.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&exporterConfig.Listen, "listen", "l", ":9187", "address to listen for scrape requests")
+	CommandDefinition.Flags().DurationVarP(&exporterConfig.CacheTTL, "cache-ttl", "", 5*time.Second, "time during which collected stats are served without collecting them again")
+	CommandDefinition.Flags().StringSliceVarP(&exporterConfig.Include, "include", "", nil, "export only views matching specified patterns")
+	CommandDefinition.Flags().StringSliceVarP(&exporterConfig.Exclude, "exclude", "", nil, "don't export views matching specified patterns")
+}
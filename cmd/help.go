@@ -5,10 +5,14 @@ package main
 import (
 	"fmt"
 	"github.com/lesovsky/pgcenter/cmd/config"
+	"github.com/lesovsky/pgcenter/cmd/exporter"
+	"github.com/lesovsky/pgcenter/cmd/importer"
 	"github.com/lesovsky/pgcenter/cmd/profile"
 	"github.com/lesovsky/pgcenter/cmd/record"
 	"github.com/lesovsky/pgcenter/cmd/report"
+	"github.com/lesovsky/pgcenter/cmd/serve"
 	top "github.com/lesovsky/pgcenter/cmd/top"
+	"github.com/lesovsky/pgcenter/cmd/web"
 )
 
 const programIssuesURL = "https://github.com/lesovsky/pgcenter/issues"
@@ -22,10 +26,14 @@ Usage:
 
 Available commands:
   config	%s
+  exporter	%s
+  import	%s
   profile	%s
   record	%s
   report	%s
+  serve		%s
   top		%s
+  web		%s
 
 Flags:
   -?, --help		show this help and exit
@@ -37,10 +45,14 @@ Report bugs to <%s>.
 `,
 		pgcenter.Long,
 		config.CommandDefinition.Short,
+		exporter.CommandDefinition.Short,
+		importer.CommandDefinition.Short,
 		profile.CommandDefinition.Short,
 		record.CommandDefinition.Short,
 		report.CommandDefinition.Short,
+		serve.CommandDefinition.Short,
 		top.CommandDefinition.Short,
+		web.CommandDefinition.Short,
 		programIssuesURL)
 }
 
@@ -67,6 +79,59 @@ Report bugs to <%s>.
 		programIssuesURL)
 }
 
+func printExporterHelp() string {
+	return fmt.Sprintf(`%s
+
+Usage:
+ pgcenter exporter [OPTIONS]... [DBNAME [USERNAME]]
+
+Options:
+ -d, --dbname DBNAME		database name to connect to
+ -h, --host HOSTNAME		database server host or socket directory
+ -p, --port PORT		database server port (default 5432)
+ -U, --username USERNAME	database user name
+
+ -l, --listen ADDRESS		address to listen for scrape requests (default: :9187)
+     --cache-ttl DURATION	time during which collected stats are served without collecting them again (default: 5s)
+     --include PATTERNS		export only views matching comma-separated patterns, e.g. 'databases_*,system_*'
+     --exclude PATTERNS		don't export views matching comma-separated patterns, e.g. 'statements_*'
+
+General options:
+ -?, --help		show this help and exit
+
+Report bugs to <%s>.
+`,
+		exporter.CommandDefinition.Long,
+		programIssuesURL)
+}
+
+func printImportHelp() string {
+	return fmt.Sprintf(`%s
+
+Usage:
+ pgcenter import [OPTIONS]... [DBNAME [USERNAME]]
+
+Options:
+ -d, --dbname DBNAME		database name to connect to
+ -h, --host HOSTNAME		database server host or socket directory
+ -p, --port PORT		database server port (default 5432)
+ -U, --username USERNAME	database user name
+
+ -f, --file FILE		read stats from file (default: pgcenter.stat.tar)
+ -S, --schema SCHEMA		schema where tables are created (default: public)
+     --host-column VALUE	add 'host' column with specified value, e.g. name of the recorded host
+     --key-file FILE		decrypt file using key from specified file
+     --passphrase		decrypt file using passphrase (taken from PGCENTER_PASSPHRASE or asked)
+
+General options:
+ -?, --help		show this help and exit
+
+Report bugs to <%s>.
+`,
+		importer.CommandDefinition.Long,
+		programIssuesURL)
+}
+
 func printProfileHelp() string {
 	return fmt.Sprintf(`%s
 
@@ -93,6 +158,38 @@ Report bugs to <%s>.
 		programIssuesURL)
 }
 
+func printServeHelp() string {
+	return fmt.Sprintf(`%s
+
+Usage:
+ pgcenter serve [OPTIONS]... [DBNAME [USERNAME]]
+
+Options:
+ -d, --dbname DBNAME		database name to connect to
+ -h, --host HOSTNAME		database server host or socket directory
+ -p, --port PORT		database server port (default 5432)
+ -U, --username USERNAME	database user name
+
+ -l, --listen ADDRESS		address to listen for API requests (default: 127.0.0.1:8080)
+ -i, --interval DURATION	default interval of updates sent to events streams (default: 1s)
+ -t, --strlimit INT		maximum query length (default: 0, no limit)
+     --redact			replace literals in query texts with placeholders
+
+Endpoints:
+ GET /views			list of available views
+ GET /views/NAME		stats of the view, parameters: order, desc, filter, limit, client
+ GET /system			system stats: load average, CPU, memory, disks, network, filesystems
+ GET /events			stream of stats (server-sent events), parameters: view, system, interval
+
+General options:
+ -?, --help		show this help and exit
+
+Report bugs to <%s>.
+`,
+		serve.CommandDefinition.Long,
+		programIssuesURL)
+}
+
 func printTopHelp() string {
 	return fmt.Sprintf(`%s
 
@@ -105,6 +202,12 @@ Options:
   -p, --port PORT		database server port (default 5432)
   -U, --username USERNAME	database user name
 
+  -a, --annotate FILENAME	stats file of an active recording where annotations are written
+      --key-file FILE		key file of encrypted file where annotations are written
+      --passphrase		use passphrase of encrypted file where annotations are written (taken from PGCENTER_PASSPHRASE or asked)
+      --redact			replace literals in query texts with placeholders
+      --config FILENAME		configuration file with settings of 'top' and custom views (default: ~/.config/pgcenter/config.toml)
+
 General options:
   -?, --help		show this help and exit
 
@@ -119,6 +222,7 @@ func printRecordHelp() string {
 
 Usage:
  pgcenter record [OPTIONS]... [DBNAME [USERNAME]]
+ pgcenter record annotate [OPTIONS]... TEXT
 
 Options:
  -d, --dbname DBNAME		database name to connect to
@@ -132,6 +236,13 @@ Options:
  -a, --append			append statistics to file (defailt: true)
  -s, --strlimit INT		maximum query length to record (default: 0, no limit)
  -1, --oneshot			append single statistics snapshot and exit (alias for --interval 0 --count 1)
+     --explain-interval DURATION	capture plans of top statements with specified interval, e.g. 10m (default: 0, disabled)
+     --explain-top INT		number of top statements by execution time which plans are captured (default: 5)
+     --redact			replace literals in query texts with placeholders before recording
+     --key-file FILE		encrypt file using key from specified file (32 bytes, raw or hex-encoded)
+     --passphrase		encrypt file using passphrase (taken from PGCENTER_PASSPHRASE or asked)
+     --config FILENAME		configuration file with definitions of custom views (default: ~/.config/pgcenter/config.toml)
+     --raw-procstat		record raw procpidstat values, required for reporting procpidstat with --rate-window
 
 General options:
  -?, --help		show this help and exit
@@ -142,6 +253,26 @@ Report bugs to <%s>.
 		programIssuesURL)
 }
 
+func printRecordAnnotateHelp() string {
+	return fmt.Sprintf(`%s
+
+Usage:
+ pgcenter record annotate [OPTIONS]... TEXT
+
+Options:
+ -f, --file FILENAME		file name where annotation is written (default: pgcenter.stat.tar)
+     --key-file FILE		key file of encrypted file
+     --passphrase		use passphrase of encrypted file (taken from PGCENTER_PASSPHRASE or asked)
+
+General options:
+ -?, --help		show this help and exit
+
+Report bugs to <%s>.
+`,
+		record.AnnotateCommandDefinition.Long,
+		programIssuesURL)
+}
+
 func printReportHelp() string {
 	return fmt.Sprintf(`%s
 
@@ -158,6 +289,14 @@ Options:
  -g, --grep COLNAME:PATTERN	filter values in specfied column (format: colname:filtertext)
  -l, --limit INT		print only limited number of rows per sample (default: unlimited)
  -t, --strlimit INT		maximum string size to print (default: 32, 0 disables)
+     --columns COLNAMES		print only specified columns in specified order (format: col1,col2,"col,3")
+     --derive NAME=EXPR		add column computed from numeric columns, e.g. ms_per_call="all,ms"/calls
+     --rate-window DURATION	calculate rates using samples taken at least specified interval apart, e.g. 1m
+     --export FORMAT		export recorded stats as time series (openmetrics, influx), all stats are exported if report type is not specified
+     --plans QUERYID		print plans captured for statement with specified queryid
+     --redact			replace literals in query texts with placeholders
+     --key-file FILE		decrypt file using key from specified file
+     --passphrase		decrypt file using passphrase (taken from PGCENTER_PASSPHRASE or asked)
 
 Report options:
  -A, --activity			show pg_stat_activity statistics
@@ -176,7 +315,10 @@ Report options:
  -P, --progress SELECTOR	show pg_stat_progress_* statistics, use additional selector to choose stats:
 				'v' - vacuum; 'c' - cluster; 'i' - create index; 'a' - analyze; 'b' - basebackup; 'y' - copy
 
+     --view NAME		show statistics of the view specified by name, including custom views
+
  -d, --describe			show statistics description, combined with one of the report options
+     --config FILENAME		configuration file with definitions of custom views (default: ~/.config/pgcenter/config.toml)
 
 General options:
  -?, --help		show this help and exit
@@ -186,3 +328,32 @@ Report bugs to <%s>.
 		report.CommandDefinition.Long,
 		programIssuesURL)
 }
+
+func printWebHelp() string {
+	return fmt.Sprintf(`%s
+
+Usage:
+ pgcenter web [OPTIONS]... [DBNAME [USERNAME]]
+
+Options:
+ -d, --dbname DBNAME		database name to connect to
+ -h, --host HOSTNAME		database server host or socket directory
+ -p, --port PORT		database server port (default 5432)
+ -U, --username USERNAME	database user name
+
+ -l, --listen ADDRESS		address to listen for dashboard requests (default: 127.0.0.1:8080)
+ -i, --interval DURATION	default interval of dashboard updates (default: 1s)
+ -t, --strlimit INT		maximum query length (default: 0, no limit)
+     --redact			replace literals in query texts with placeholders
+     --enable-actions		allow cancelling and terminating backends, and reloading configuration
+
+Dashboard is available at http://ADDRESS/, API endpoints of 'pgcenter serve' are also available.
+
+General options:
+ -?, --help		show this help and exit
+
+Report bugs to <%s>.
+`,
+		web.CommandDefinition.Long,
+		programIssuesURL)
+}
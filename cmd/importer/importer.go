@@ -0,0 +1,80 @@
+// Entry point for 'pgcenter import' command.
+
+package importer
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/report"
+	"github.com/spf13/cobra"
+)
+
+// options defines all user-requested startup options.
+type options struct {
+	inputFile  string          // Input file with statistics
+	schema     string          // Schema where tables are created
+	hostColumn string          // Value of 'host' column
+	key        stat.KeyOptions // Key file or passphrase of encrypted file
+}
+
+var (
+	opts        options
+	connOptions postgres.ConnectionOptions
+
+	// CommandDefinition defines 'import' sub-command.
+	CommandDefinition = &cobra.Command{
+		Use:   "import",
+		Short: "import previously saved statistics into Postgres",
+		Long:  `'pgcenter import' reads statistics from file and loads it into Postgres tables.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			// Parse extra arguments.
+			if len(args) > 0 {
+				connOptions.ParseExtraArgs(args)
+			}
+
+			// Create connection config.
+			pgConfig, err := postgres.NewConfig(connOptions.Host, connOptions.Port, connOptions.User, connOptions.Dbname)
+			if err != nil {
+				return err
+			}
+
+			importConfig, err := opts.validate()
+			if err != nil {
+				return err
+			}
+
+			importConfig.Key, err = opts.key.Key()
+			if err != nil {
+				return err
+			}
+
+			return report.RunImport(pgConfig, importConfig)
+		},
+	}
+)
+
+func init() {
+	CommandDefinition.Flags().StringVarP(&connOptions.Host, "host", "h", "", "database server host or socket directory")
+	CommandDefinition.Flags().IntVarP(&connOptions.Port, "port", "p", 5432, "database server port")
+	CommandDefinition.Flags().StringVarP(&connOptions.User, "username", "U", "", "database user name")
+	CommandDefinition.Flags().StringVarP(&connOptions.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&opts.inputFile, "file", "f", "pgcenter.stat.tar", "read stats from file")
+	CommandDefinition.Flags().StringVarP(&opts.schema, "schema", "S", "public", "schema where tables are created")
+	CommandDefinition.Flags().StringVarP(&opts.hostColumn, "host-column", "", "", "add 'host' column with specified value")
+	CommandDefinition.Flags().StringVarP(&opts.key.KeyFile, "key-file", "", "", "decrypt file using key from specified file")
+	CommandDefinition.Flags().BoolVarP(&opts.key.Passphrase, "passphrase", "", false, "decrypt file using passphrase (taken from "+stat.PassphraseEnv+" or asked)")
+}
+
+// validate parses and validates options passed by user and returns options ready for 'pgcenter import'.
+func (opts options) validate() (report.ImportConfig, error) {
+	if opts.schema == "" {
+		return report.ImportConfig{}, fmt.Errorf("schema is not specified")
+	}
+
+	return report.ImportConfig{
+		InputFile: opts.inputFile,
+		Schema:    opts.schema,
+		Host:      opts.hostColumn,
+	}, nil
+}
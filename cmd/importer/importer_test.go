@@ -0,0 +1,16 @@
+package importer
+
+import (
+	"github.com/lesovsky/pgcenter/report"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_options_validate(t *testing.T) {
+	got, err := options{inputFile: "stats.tar", schema: "incidents", hostColumn: "db1"}.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, report.ImportConfig{InputFile: "stats.tar", Schema: "incidents", Host: "db1"}, got)
+
+	_, err = options{inputFile: "stats.tar"}.validate()
+	assert.Error(t, err)
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"fmt"
 	"github.com/lesovsky/pgcenter/cmd/config"
+	"github.com/lesovsky/pgcenter/cmd/exporter"
+	"github.com/lesovsky/pgcenter/cmd/importer"
 	"github.com/lesovsky/pgcenter/cmd/profile"
 	"github.com/lesovsky/pgcenter/cmd/record"
 	"github.com/lesovsky/pgcenter/cmd/report"
+	"github.com/lesovsky/pgcenter/cmd/serve"
 	"github.com/lesovsky/pgcenter/cmd/top"
+	"github.com/lesovsky/pgcenter/cmd/web"
 	"github.com/lesovsky/pgcenter/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +42,18 @@ func init() {
 	config.CommandDefinition.SetHelpTemplate(printConfigHelp())
 	config.CommandDefinition.SetUsageTemplate(printConfigHelp())
 
+	// Setup 'exporter' sub-command
+	pgcenter.AddCommand(exporter.CommandDefinition)
+	exporter.CommandDefinition.SetVersionTemplate(versionStr)
+	exporter.CommandDefinition.SetHelpTemplate(printExporterHelp())
+	exporter.CommandDefinition.SetUsageTemplate(printExporterHelp())
+
+	// Setup 'import' sub-command
+	pgcenter.AddCommand(importer.CommandDefinition)
+	importer.CommandDefinition.SetVersionTemplate(versionStr)
+	importer.CommandDefinition.SetHelpTemplate(printImportHelp())
+	importer.CommandDefinition.SetUsageTemplate(printImportHelp())
+
 	// Setup 'profile' sub-command
 	pgcenter.AddCommand(profile.CommandDefinition)
 	profile.CommandDefinition.SetVersionTemplate(versionStr)
@@ -49,6 +65,8 @@ func init() {
 	record.CommandDefinition.SetVersionTemplate(versionStr)
 	record.CommandDefinition.SetHelpTemplate(printRecordHelp())
 	record.CommandDefinition.SetUsageTemplate(printRecordHelp())
+	record.AnnotateCommandDefinition.SetHelpTemplate(printRecordAnnotateHelp())
+	record.AnnotateCommandDefinition.SetUsageTemplate(printRecordAnnotateHelp())
 
 	// Setup 'report' sub-command
 	pgcenter.AddCommand(report.CommandDefinition)
@@ -56,11 +74,23 @@ func init() {
 	report.CommandDefinition.SetHelpTemplate(printReportHelp())
 	report.CommandDefinition.SetUsageTemplate(printReportHelp())
 
+	// Setup 'serve' sub-command
+	pgcenter.AddCommand(serve.CommandDefinition)
+	serve.CommandDefinition.SetVersionTemplate(versionStr)
+	serve.CommandDefinition.SetHelpTemplate(printServeHelp())
+	serve.CommandDefinition.SetUsageTemplate(printServeHelp())
+
 	// Setup 'top' sub-command
 	pgcenter.AddCommand(top.CommandDefinition)
 	top.CommandDefinition.SetVersionTemplate(versionStr)
 	top.CommandDefinition.SetHelpTemplate(printTopHelp())
 	top.CommandDefinition.SetUsageTemplate(printTopHelp())
+
+	// Setup 'web' sub-command
+	pgcenter.AddCommand(web.CommandDefinition)
+	web.CommandDefinition.SetVersionTemplate(versionStr)
+	web.CommandDefinition.SetHelpTemplate(printWebHelp())
+	web.CommandDefinition.SetUsageTemplate(printWebHelp())
 }
 
 func main() {
@@ -0,0 +1,37 @@
+// Entry point for 'pgcenter record annotate' command.
+
+package record
+
+import (
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/record"
+	"github.com/spf13/cobra"
+)
+
+var (
+	annotateFile       string
+	annotateKeyOptions stat.KeyOptions
+
+	// AnnotateCommandDefinition defines 'record annotate' sub-command.
+	AnnotateCommandDefinition = &cobra.Command{
+		Use:   "annotate",
+		Short: "write annotation into stats file",
+		Long:  `'pgcenter record annotate' writes annotation into stats file, the file could be annotated while it is recorded.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			key, err := annotateKeyOptions.Key()
+			if err != nil {
+				return err
+			}
+
+			return record.Annotate(annotateFile, args[0], key)
+		},
+	}
+)
+
+func init() {
+	AnnotateCommandDefinition.Flags().StringVarP(&annotateFile, "file", "f", "pgcenter.stat.tar", "file where annotation is written")
+	AnnotateCommandDefinition.Flags().StringVarP(&annotateKeyOptions.KeyFile, "key-file", "", "", "key file of encrypted file")
+	AnnotateCommandDefinition.Flags().BoolVarP(&annotateKeyOptions.Passphrase, "passphrase", "", false, "use passphrase of encrypted file (taken from "+stat.PassphraseEnv+" or asked)")
+	CommandDefinition.AddCommand(AnnotateCommandDefinition)
+}
@@ -4,6 +4,8 @@ package record
 
 import (
 	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
 	"github.com/lesovsky/pgcenter/record"
 	"github.com/spf13/cobra"
 	"time"
@@ -12,6 +14,7 @@ import (
 var (
 	recordConfig record.Config
 	connOptions  postgres.ConnectionOptions
+	keyOptions   stat.KeyOptions
 	oneshot      bool
 
 	// CommandDefinition defines 'record' sub-command.
@@ -38,6 +41,11 @@ var (
 				return err
 			}
 
+			recordConfig.Key, err = keyOptions.Key()
+			if err != nil {
+				return err
+			}
+
 			return record.RunMain(pgConfig, recordConfig)
 		},
 	}
@@ -56,4 +64,11 @@ func init() {
 	CommandDefinition.Flags().BoolVarP(&recordConfig.AppendFile, "append", "a", false, "append statistics to file (default: true)")
 	CommandDefinition.Flags().IntVarP(&recordConfig.StringLimit, "strlimit", "t", 0, "maximum query length to record (default: 0, no limit)")
 	CommandDefinition.Flags().BoolVarP(&oneshot, "oneshot", "1", false, "append single statistics snapshot to file and exit")
+	CommandDefinition.Flags().DurationVarP(&recordConfig.ExplainInterval, "explain-interval", "", 0, "capture plans of top statements with specified interval (default: 0, disabled)")
+	CommandDefinition.Flags().IntVarP(&recordConfig.ExplainTop, "explain-top", "", 5, "number of top statements by execution time which plans are captured")
+	CommandDefinition.Flags().BoolVarP(&recordConfig.Redact, "redact", "", false, "replace literals in query texts with placeholders")
+	CommandDefinition.Flags().StringVarP(&keyOptions.KeyFile, "key-file", "", "", "encrypt file using key from specified file")
+	CommandDefinition.Flags().BoolVarP(&keyOptions.Passphrase, "passphrase", "", false, "encrypt file using passphrase (taken from "+stat.PassphraseEnv+" or asked)")
+	CommandDefinition.Flags().BoolVarP(&recordConfig.RawProcStat, "raw-procstat", "", false, "record raw procpidstat values, required for 'report --rate-window'")
+	CommandDefinition.Flags().StringVarP(&recordConfig.ConfigFile, "config", "", view.DefaultConfigFile(), "configuration file with definitions of custom views")
 }
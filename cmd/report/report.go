@@ -3,7 +3,10 @@
 package report
 
 import (
+	"encoding/csv"
 	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
 	"github.com/lesovsky/pgcenter/report"
 	"github.com/spf13/cobra"
 	"regexp"
@@ -29,15 +32,24 @@ type options struct {
 	showStatements  string // Show stats from pg_stat_statements
 	showProgress    string // Show stats from pg_stat_progress_* stats
 	showProcPidStat bool   // Show per-process system stats (procpidstat)
-
-	inputFile      string // Input file with statistics
-	tsStart, tsEnd string // Show stats within an interval
-	orderColName   string // Name of the column used for sorting
-	orderDesc      bool   // Specify to use descendant order
-	orderAsc       bool   // Specify to use ascendant order
-	filter         string // Perform filtering
-	rowLimit       int    // Number of rows per timestamp
-	strLimit       int    // Trim all strings longer than this limit
+	showView        string // Show stats of the view specified by name, including custom views
+	configFile      string // Configuration file with definitions of custom views
+
+	inputFile      string          // Input file with statistics
+	tsStart, tsEnd string          // Show stats within an interval
+	orderColName   string          // Name of the column used for sorting
+	orderDesc      bool            // Specify to use descendant order
+	orderAsc       bool            // Specify to use ascendant order
+	filter         string          // Perform filtering
+	rowLimit       int             // Number of rows per timestamp
+	strLimit       int             // Trim all strings longer than this limit
+	columns        string          // Comma-separated list of columns to print
+	derive         []string        // Derived columns definitions
+	rateWindow     time.Duration   // Interval used for calculating rates
+	export         string          // Export stats in specified format
+	plans          string          // Print captured plans of statement with specified queryid
+	redact         bool            // Replace literals in query texts with placeholders
+	key            stat.KeyOptions // Key file or passphrase of encrypted file
 }
 
 var (
@@ -54,6 +66,11 @@ var (
 				return err
 			}
 
+			reportOpts.Key, err = opts.key.Key()
+			if err != nil {
+				return err
+			}
+
 			return report.RunMain(reportOpts)
 		},
 	}
@@ -75,6 +92,8 @@ func init() {
 	CommandDefinition.Flags().StringVarP(&opts.showStatements, "statements", "X", "", "show pg_stat_statements report")
 	CommandDefinition.Flags().StringVarP(&opts.showProgress, "progress", "P", "", "show pg_stat_progress_* report")
 	CommandDefinition.Flags().BoolVarP(&opts.showProcPidStat, "proc-stats", "N", false, "show per-process system stats report")
+	CommandDefinition.Flags().StringVarP(&opts.showView, "view", "", "", "show report of the view specified by name, including custom views")
+	CommandDefinition.Flags().StringVarP(&opts.configFile, "config", "", view.DefaultConfigFile(), "configuration file with definitions of custom views")
 
 	CommandDefinition.Flags().StringVarP(&opts.inputFile, "file", "f", "pgcenter.stat.tar", "read stats from file")
 	CommandDefinition.Flags().StringVarP(&opts.tsStart, "start", "s", "", "starting time of the report")
@@ -85,16 +104,43 @@ func init() {
 	CommandDefinition.Flags().StringVarP(&opts.filter, "grep", "g", "", "grep values in specified column (format: colname:filter_pattern)")
 	CommandDefinition.Flags().IntVarP(&opts.rowLimit, "limit", "l", 0, "print only limited number of rows per sample")
 	CommandDefinition.Flags().IntVarP(&opts.strLimit, "strlimit", "t", 32, "maximum string size for long lines to print (default: 32)")
+	CommandDefinition.Flags().StringVarP(&opts.columns, "columns", "", "", "comma-separated list of columns to print")
+	CommandDefinition.Flags().StringArrayVarP(&opts.derive, "derive", "", nil, "add column computed from numeric columns (format: name=expression)")
+	CommandDefinition.Flags().StringVarP(&opts.export, "export", "", "", "export stats in specified format (openmetrics, influx)")
+	CommandDefinition.Flags().StringVarP(&opts.plans, "plans", "", "", "print captured plans of statement with specified queryid")
+	CommandDefinition.Flags().BoolVarP(&opts.redact, "redact", "", false, "replace literals in query texts with placeholders")
+	CommandDefinition.Flags().StringVarP(&opts.key.KeyFile, "key-file", "", "", "decrypt file using key from specified file")
+	CommandDefinition.Flags().BoolVarP(&opts.key.Passphrase, "passphrase", "", false, "decrypt file using passphrase (taken from "+stat.PassphraseEnv+" or asked)")
+	CommandDefinition.Flags().DurationVarP(&opts.rateWindow, "rate-window", "", 0, "calculate rates using samples taken at least specified interval apart")
 }
 
 // validate parses and validates options passed by user and returns options ready for 'pgcenter report'.
 func (opts options) validate() (report.Config, error) {
 	// Select report type
 	r := selectReport(opts)
-	if r == "" {
+	if r == "" && opts.export == "" && opts.plans == "" {
 		return report.Config{}, fmt.Errorf("report type is not specified, quit")
 	}
 
+	// Read custom views, view specified by name should be either built-in or custom.
+	custom, err := view.ReadCustomViews(opts.configFile)
+	if err != nil {
+		return report.Config{}, err
+	}
+
+	if opts.showView != "" {
+		if _, ok := view.New()[opts.showView]; !ok {
+			if _, ok := custom[opts.showView]; !ok {
+				return report.Config{}, fmt.Errorf("unknown view '%s'", opts.showView)
+			}
+		}
+	}
+
+	// Check export format, when report type is not specified all stats are exported.
+	if opts.export != "" && opts.export != report.ExportOpenMetrics && opts.export != report.ExportInflux {
+		return report.Config{}, fmt.Errorf("unknown export format '%s', use '%s' or '%s'", opts.export, report.ExportOpenMetrics, report.ExportInflux)
+	}
+
 	// Define report start/end interval.
 	tsStart, tsEnd, err := setReportInterval(opts.tsStart, opts.tsEnd)
 	if err != nil {
@@ -107,6 +153,26 @@ func (opts options) validate() (report.Config, error) {
 		return report.Config{}, err
 	}
 
+	// Parse list of printed columns.
+	columns, err := parseColumnsString(opts.columns)
+	if err != nil {
+		return report.Config{}, err
+	}
+
+	// Parse derived columns.
+	derive := make([]report.Derive, 0, len(opts.derive))
+	for _, s := range opts.derive {
+		d, err := report.ParseDerive(s)
+		if err != nil {
+			return report.Config{}, err
+		}
+		derive = append(derive, d)
+	}
+
+	if opts.rateWindow < 0 {
+		return report.Config{}, fmt.Errorf("invalid rate window specified")
+	}
+
 	// Define order settings.
 	desc := opts.orderDesc
 	if opts.orderAsc {
@@ -125,6 +191,13 @@ func (opts options) validate() (report.Config, error) {
 		FilterRE:      re,
 		RowLimit:      opts.rowLimit,
 		TruncLimit:    opts.strLimit,
+		Columns:       columns,
+		Derive:        derive,
+		RateWindow:    opts.rateWindow,
+		Export:        opts.export,
+		Plans:         opts.plans,
+		Redact:        opts.redact,
+		CustomViews:   custom,
 	}, nil
 }
 
@@ -182,6 +255,8 @@ func selectReport(opts options) string {
 		case "j":
 			return "statements_jit"
 		}
+	case opts.showView != "":
+		return opts.showView
 	case opts.showProgress != "":
 		switch opts.showProgress {
 		case "v":
@@ -305,3 +380,28 @@ func parseFilterString(filter string) (string, *regexp.Regexp, error) {
 
 	return colname, re, nil
 }
+
+// parseColumnsString parses comma-separated list of columns names. Names which contain commas (e.g. "all,ms")
+// should be double-quoted.
+func parseColumnsString(columns string) ([]string, error) {
+	if columns == "" {
+		return nil, nil
+	}
+
+	r := csv.NewReader(strings.NewReader(columns))
+	r.TrimLeadingSpace = true
+
+	s, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid list of columns specified: %w", err)
+	}
+
+	for i := range s {
+		s[i] = strings.TrimSpace(s[i])
+		if s[i] == "" {
+			return nil, fmt.Errorf("invalid list of columns specified")
+		}
+	}
+
+	return s, nil
+}
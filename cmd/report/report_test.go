@@ -3,6 +3,8 @@ package report
 import (
 	"github.com/lesovsky/pgcenter/report"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -18,6 +20,17 @@ func Test_options_validate(t *testing.T) {
 		{valid: false, opts: options{tsStart: "2021-01-01 12:00:00", tsEnd: "2021-01-01 13:00:00"}}, // no report type specified
 		{valid: false, opts: options{showActivity: true, tsStart: "2021-01-32"}},                    // invalid report start timestamp
 		{valid: false, opts: options{showActivity: true, filter: `colname:"["`}},                    // invalid regexp
+		{valid: true, opts: options{showActivity: true, columns: "pid,query", derive: []string{"x=pid*2"}}},
+		{valid: false, opts: options{showActivity: true, columns: "pid,,query"}},      // invalid columns list
+		{valid: false, opts: options{showActivity: true, derive: []string{"x=pid*"}}}, // invalid derived column
+		{valid: true, opts: options{showTables: true, rateWindow: time.Minute}},
+		{valid: false, opts: options{showTables: true, rateWindow: -time.Minute}}, // invalid rate window
+		{valid: true, opts: options{export: "openmetrics"}},
+		{valid: true, opts: options{showTables: true, export: "influx"}},
+		{valid: false, opts: options{export: "csv"}}, // unknown export format
+		{valid: true, opts: options{plans: "1a2b3c4d5e"}},
+		{valid: true, opts: options{showView: "tables"}},
+		{valid: false, opts: options{showView: "unknown"}}, // unknown view
 	}
 
 	for _, tc := range testcases {
@@ -64,6 +77,7 @@ func Test_selectReport(t *testing.T) {
 		{opts: options{showStatements: "j"}, want: "statements_jit"},
 		{opts: options{showStatIO: "x"}, want: ""},     // invalid -J value
 		{opts: options{showStatements: "z"}, want: ""}, // invalid -X value
+		{opts: options{showView: "queue"}, want: "queue"},
 		{opts: options{}, want: ""},
 	}
 
@@ -72,6 +86,21 @@ func Test_selectReport(t *testing.T) {
 	}
 }
 
+func Test_options_validate_customViews(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(filename, []byte("[custom_views.queue]\nquery = \"SELECT 1\"\n"), 0600))
+
+	got, err := options{showView: "queue", configFile: filename}.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, "queue", got.ReportType)
+	assert.Contains(t, got.CustomViews, "queue")
+
+	// Bad definitions are rejected.
+	assert.NoError(t, os.WriteFile(filename, []byte("[custom_views.queue]\nhotkey = \"e\"\n"), 0600))
+	_, err = options{showActivity: true, configFile: filename}.validate()
+	assert.Error(t, err)
+}
+
 func Test_setReportInterval(t *testing.T) {
 	today := time.Now().Format("2006-01-02")
 
@@ -221,3 +250,27 @@ func Test_parseFilterString(t *testing.T) {
 		}
 	}
 }
+
+func Test_parseColumnsString(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		in    string
+		want  []string
+	}{
+		{valid: true, in: "", want: nil},
+		{valid: true, in: "calls,total,query", want: []string{"calls", "total", "query"}},
+		{valid: true, in: `calls, "all,ms", query`, want: []string{"calls", "all,ms", "query"}},
+		{valid: false, in: "calls,,query"},
+		{valid: false, in: `calls,"all,ms`},
+	}
+
+	for _, tc := range testcases {
+		got, err := parseColumnsString(tc.in)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Entry point for 'pgcenter serve' command.
+
+package serve
+
+import (
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/serve"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var (
+	serveConfig serve.Config
+	connOptions postgres.ConnectionOptions
+
+	// CommandDefinition defines 'serve' sub-command.
+	CommandDefinition = &cobra.Command{
+		Use:   "serve",
+		Short: "serve stats over HTTP API",
+		Long:  `'pgcenter serve' connects to PostgreSQL and serves its stats and system stats over read-only HTTP JSON API.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			// Parse extra arguments.
+			if len(args) > 0 {
+				connOptions.ParseExtraArgs(args)
+			}
+
+			// Create connection config.
+			pgConfig, err := postgres.NewConfig(connOptions.Host, connOptions.Port, connOptions.User, connOptions.Dbname)
+			if err != nil {
+				return err
+			}
+
+			return serve.RunMain(pgConfig, serveConfig)
+		},
+	}
+)
+
+func init() {
+	CommandDefinition.Flags().StringVarP(&connOptions.Host, "host", "h", "", "database server host or socket directory")
+	CommandDefinition.Flags().IntVarP(&connOptions.Port, "port", "p", 5432, "database server port")
+	CommandDefinition.Flags().StringVarP(&connOptions.User, "username", "U", "", "database user name")
+	CommandDefinition.Flags().StringVarP(&connOptions.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&serveConfig.Listen, "listen", "l", "127.0.0.1:8080", "address to listen for API requests")
+	CommandDefinition.Flags().DurationVarP(&serveConfig.Interval, "interval", "i", time.Second, "default interval of updates sent to events streams")
+	CommandDefinition.Flags().IntVarP(&serveConfig.StringLimit, "strlimit", "t", 0, "maximum query length (default: 0, no limit)")
+	CommandDefinition.Flags().BoolVarP(&serveConfig.Redact, "redact", "", false, "replace literals in query texts with placeholders")
+}
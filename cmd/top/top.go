@@ -4,12 +4,16 @@ package top
 
 import (
 	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
 	"github.com/lesovsky/pgcenter/top"
 	"github.com/spf13/cobra"
 )
 
 var (
-	opts postgres.ConnectionOptions
+	opts       postgres.ConnectionOptions
+	config     top.Config
+	keyOptions stat.KeyOptions
 
 	// CommandDefinition defines 'top' sub-command.
 	CommandDefinition = &cobra.Command{
@@ -28,7 +32,13 @@ var (
 				return err
 			}
 
-			return top.RunMain(pgConfig)
+			// Key is read before starting UI, passphrase might be asked interactively.
+			config.AnnotateKey, err = keyOptions.Key()
+			if err != nil {
+				return err
+			}
+
+			return top.RunMain(pgConfig, config)
 		},
 	}
 )
@@ -39,4 +49,10 @@ func init() {
 	CommandDefinition.Flags().IntVarP(&opts.Port, "port", "p", 0, "database server port")
 	CommandDefinition.Flags().StringVarP(&opts.User, "username", "U", "", "database user name")
 	CommandDefinition.Flags().StringVarP(&opts.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&config.AnnotateFile, "annotate", "a", "", "stats file of an active recording where annotations are written")
+	CommandDefinition.Flags().StringVarP(&keyOptions.KeyFile, "key-file", "", "", "key file of encrypted file where annotations are written")
+	CommandDefinition.Flags().BoolVarP(&keyOptions.Passphrase, "passphrase", "", false, "use passphrase of encrypted file where annotations are written (taken from "+stat.PassphraseEnv+" or asked)")
+	CommandDefinition.Flags().BoolVarP(&config.Redact, "redact", "", false, "replace literals in query texts with placeholders")
+	CommandDefinition.Flags().BoolVarP(&config.Mouse, "mouse", "", false, "enable mouse support, it disables text selection in terminal")
+	CommandDefinition.Flags().StringVarP(&config.ConfigFile, "config", "", view.DefaultConfigFile(), "configuration file with settings of 'top' and custom views")
 }
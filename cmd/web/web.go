@@ -0,0 +1,50 @@
+// Entry point for 'pgcenter web' command.
+
+package web
+
+import (
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/serve"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var (
+	webConfig   serve.Config
+	connOptions postgres.ConnectionOptions
+
+	// CommandDefinition defines 'web' sub-command.
+	CommandDefinition = &cobra.Command{
+		Use:   "web",
+		Short: "show stats in web browser",
+		Long:  `'pgcenter web' connects to PostgreSQL and shows its stats and system stats in live dashboard in web browser.`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			// Parse extra arguments.
+			if len(args) > 0 {
+				connOptions.ParseExtraArgs(args)
+			}
+
+			// Create connection config.
+			pgConfig, err := postgres.NewConfig(connOptions.Host, connOptions.Port, connOptions.User, connOptions.Dbname)
+			if err != nil {
+				return err
+			}
+
+			webConfig.Dashboard = true
+
+			return serve.RunMain(pgConfig, webConfig)
+		},
+	}
+)
+
+func init() {
+	CommandDefinition.Flags().StringVarP(&connOptions.Host, "host", "h", "", "database server host or socket directory")
+	CommandDefinition.Flags().IntVarP(&connOptions.Port, "port", "p", 5432, "database server port")
+	CommandDefinition.Flags().StringVarP(&connOptions.User, "username", "U", "", "database user name")
+	CommandDefinition.Flags().StringVarP(&connOptions.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&webConfig.Listen, "listen", "l", "127.0.0.1:8080", "address to listen for dashboard requests")
+	CommandDefinition.Flags().DurationVarP(&webConfig.Interval, "interval", "i", time.Second, "default interval of dashboard updates")
+	CommandDefinition.Flags().IntVarP(&webConfig.StringLimit, "strlimit", "t", 0, "maximum query length (default: 0, no limit)")
+	CommandDefinition.Flags().BoolVarP(&webConfig.Redact, "redact", "", false, "replace literals in query texts with placeholders")
+	CommandDefinition.Flags().BoolVarP(&webConfig.Actions, "enable-actions", "", false, "allow cancelling and terminating backends, and reloading configuration")
+}
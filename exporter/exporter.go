@@ -0,0 +1,318 @@
+// 'pgcenter exporter' - exposes Postgres and system stats to Prometheus.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/lesovsky/pgcenter/report"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contentType defines content type of exposed metrics.
+const contentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Config defines config container for configuring 'pgcenter exporter'.
+type Config struct {
+	Listen   string        // Address to listen for scrape requests
+	CacheTTL time.Duration // Time during which collected stats are returned to scrapes without collecting them again
+	Include  []string      // Patterns of views names which should be exported, all views are exported if empty
+	Exclude  []string      // Patterns of views names which should not be exported
+}
+
+// RunMain is the 'pgcenter exporter' main entry point.
+func RunMain(dbConfig postgres.Config, config Config) error {
+	if config.CacheTTL < 0 {
+		return fmt.Errorf("invalid cache ttl: %s", config.CacheTTL)
+	}
+
+	app := newApp(config, dbConfig)
+
+	err := app.setup()
+	if err != nil {
+		return err
+	}
+	defer app.db.Close()
+
+	server := &http.Server{
+		Addr:              config.Listen,
+		Handler:           app.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// In case of SIGINT stop program gracefully
+	doQuit := make(chan os.Signal, 1)
+	signal.Notify(doQuit, os.Interrupt)
+
+	go func() {
+		<-doQuit
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	_, _ = fmt.Fprintf(app.out, "INFO: exporting %d views on %s\n", len(app.views), config.Listen)
+
+	err = server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// app defines 'pgcenter exporter' runtime dependencies.
+type app struct {
+	config    Config
+	dbConfig  postgres.Config
+	views     view.Views // exported views, including system stats views
+	db        *postgres.DB
+	collector *stat.Collector // collector of system stats, nil if system stats are not available
+	out       io.Writer       // where informational messages are printed
+	// collect returns stats of all exported views
+	collect func() (map[string]stat.PGresult, error)
+	// mu serializes collecting, scrapes which come during collecting wait for its result
+	mu       sync.Mutex
+	cache    []byte
+	cachedAt time.Time
+}
+
+// newApp creates new 'pgcenter exporter' app.
+func newApp(config Config, dbConfig postgres.Config) *app {
+	app := &app{
+		config:   config,
+		dbConfig: dbConfig,
+		out:      os.Stdout,
+	}
+	app.collect = app.collectStats
+
+	return app
+}
+
+// setup connects to Postgres and configures exported views depending on Postgres version.
+func (app *app) setup() error {
+	db, err := postgres.Connect(app.dbConfig)
+	if err != nil {
+		return err
+	}
+
+	props, err := stat.GetPostgresProperties(db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, exportQueryLen, props.ExtPGSSSchema)
+
+	views := postgresViews(props.VersionNum, props.ExtPGSSSchema, view.New())
+	if props.ExtPGSSSchema == "" {
+		_, _ = fmt.Fprintln(app.out, "INFO: pg_stat_statements not found, skip exporting it")
+	}
+
+	err = views.Configure(opts)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	// System stats are available for local Postgres, or remote Postgres with installed pgcenter schema.
+	if db.Local || props.SchemaPgcenterAvail {
+		app.collector, err = stat.NewCollector(db)
+		if err != nil {
+			db.Close()
+			return err
+		}
+
+		for name, v := range systemViews() {
+			views[name] = v
+		}
+	} else {
+		_, _ = fmt.Fprintln(app.out, "INFO: pgcenter schema not found, skip exporting system stats")
+	}
+
+	views, err = selectViews(views, app.config.Include, app.config.Exclude)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	app.db = db
+	app.views = views
+
+	return nil
+}
+
+// exportQueryLen defines length of queries texts requested from pg_stat_statements. Texts are not exported,
+// hence they are requested as short as possible.
+const exportQueryLen = 1
+
+// postgresViews returns views which could be exported from Postgres of specified version.
+func postgresViews(version int, pgssSchema string, views view.Views) view.Views {
+	for k, v := range views {
+		// Skip per-process stats, they are collected from procfs of Postgres backends.
+		if v.NotRecordable || k == "procpidstat" {
+			delete(views, k)
+		}
+	}
+
+	return views.Supported(version, pgssSchema)
+}
+
+// selectViews returns views which names match include patterns and don't match exclude patterns. All views are
+// included if no include patterns are specified.
+func selectViews(views view.Views, include []string, exclude []string) (view.Views, error) {
+	match := func(patterns []string, name string) (bool, error) {
+		for _, p := range patterns {
+			ok, err := path.Match(p, name)
+			if err != nil {
+				return false, fmt.Errorf("invalid view pattern '%s': %w", p, err)
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	selected := view.Views{}
+	for name, v := range views {
+		if len(include) > 0 {
+			ok, err := match(include, name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		ok, err := match(exclude, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+
+		selected[name] = v
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no views selected for exporting")
+	}
+
+	return selected, nil
+}
+
+// collectStats queries stats of all exported views. Views which stats could not be collected are skipped,
+// errors are printed.
+func (app *app) collectStats() (map[string]stat.PGresult, error) {
+	err := app.db.PQstatus()
+	if err != nil {
+		err = postgres.Reconnect(app.db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats := map[string]stat.PGresult{}
+
+	names := make([]string, 0, len(app.views))
+	for name := range app.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if strings.HasPrefix(name, systemViewPrefix) {
+			continue
+		}
+
+		res, err := stat.NewPGresultQuery(app.db, app.views[name].Query)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: collect %s stats failed: %s, skip\n", name, err)
+			continue
+		}
+
+		stats[name] = res
+	}
+
+	if app.collector != nil {
+		sys, err := app.collector.UpdateSystem(app.db)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: collect system stats failed: %s, skip\n", err)
+		} else {
+			for name, res := range systemStats(sys) {
+				stats[name] = res
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// metrics returns exported metrics. Stats are collected once per cache TTL, all scrapes during this time get
+// the same metrics.
+func (app *app) metrics() ([]byte, error) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	if app.cache != nil && time.Since(app.cachedAt) < app.config.CacheTTL {
+		return app.cache, nil
+	}
+
+	stats, err := app.collect()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = report.WriteOpenMetrics(&buf, app.views, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	app.cache, app.cachedAt = buf.Bytes(), time.Now()
+
+	return app.cache, nil
+}
+
+// handler returns HTTP handler which serves metrics.
+func (app *app) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		data, err := app.metrics()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collect stats failed: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "pgcenter exporter, metrics are available at /metrics")
+	})
+
+	return mux
+}
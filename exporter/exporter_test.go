@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_postgresViews(t *testing.T) {
+	views := postgresViews(query.PostgresV14, "", view.New())
+	assert.Contains(t, views, "databases_general")
+	assert.Contains(t, views, "databases_sessions")
+	assert.NotContains(t, views, "procpidstat")
+	assert.NotContains(t, views, "statements_timings")
+	assert.NotContains(t, views, "stat_io")
+
+	views = postgresViews(query.PostgresV16, "public", view.New())
+	assert.Contains(t, views, "statements_timings")
+	assert.Contains(t, views, "stat_io")
+}
+
+func Test_selectViews(t *testing.T) {
+	views := view.Views{
+		"databases_general": {}, "databases_sessions": {}, "statements_timings": {}, "system_cpu": {},
+	}
+
+	testcases := []struct {
+		include []string
+		exclude []string
+		want    []string
+		valid   bool
+	}{
+		{want: []string{"databases_general", "databases_sessions", "statements_timings", "system_cpu"}, valid: true},
+		{include: []string{"databases_*", "system_cpu"}, want: []string{"databases_general", "databases_sessions", "system_cpu"}, valid: true},
+		{exclude: []string{"statements_*", "system_*"}, want: []string{"databases_general", "databases_sessions"}, valid: true},
+		{include: []string{"databases_*"}, exclude: []string{"databases_sessions"}, want: []string{"databases_general"}, valid: true},
+		{include: []string{"unknown"}, valid: false},
+		{include: []string{"[databases"}, valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			got, err := selectViews(views, tc.include, tc.exclude)
+			if !tc.valid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var names []string
+			for name := range got {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tc.want, names)
+		})
+	}
+}
+
+func Test_app_handler(t *testing.T) {
+	var calls int
+	app := newApp(Config{CacheTTL: time.Hour}, postgres.Config{})
+	app.views = view.Views{"custom": {Name: "custom", DiffIntvl: [2]int{1, 1}}}
+	app.collect = func() (map[string]stat.PGresult, error) {
+		calls++
+		return map[string]stat.PGresult{
+			"custom": {
+				Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"name", "calls"},
+				Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: fmt.Sprint(calls), Valid: true}}},
+			},
+		}, nil
+	}
+
+	server := httptest.NewServer(app.handler())
+	defer server.Close()
+
+	scrape := func() (int, string, string) {
+		resp, err := http.Get(server.URL + "/metrics")
+		assert.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	want := "# TYPE pgcenter_custom_calls counter\npgcenter_custom_calls_total{name=\"q1\"} 1\n# EOF\n"
+
+	// Stats are collected once per cache TTL.
+	for i := 0; i < 3; i++ {
+		code, typ, body := scrape()
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, contentType, typ)
+		assert.Equal(t, want, body)
+	}
+	assert.Equal(t, 1, calls)
+
+	// Expired cache, stats are collected again.
+	app.cachedAt = time.Now().Add(-2 * time.Hour)
+	_, _, body := scrape()
+	assert.Contains(t, body, "pgcenter_custom_calls_total{name=\"q1\"} 2\n")
+	assert.Equal(t, 2, calls)
+
+	// Collecting failed.
+	app.cache = nil
+	app.collect = func() (map[string]stat.PGresult, error) { return nil, fmt.Errorf("connection refused") }
+	code, _, _ := scrape()
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	resp, err := http.Get(server.URL + "/unknown")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_app_collectStats(t *testing.T) {
+	dbConfig, err := postgres.NewTestConfig()
+	assert.NoError(t, err)
+
+	db, err := postgres.Connect(dbConfig)
+	if err != nil {
+		t.Skipf("postgres is not available: %s", err)
+	}
+	db.Close()
+
+	app := newApp(Config{Include: []string{"databases_general", "system_*"}}, dbConfig)
+	app.out = io.Discard
+	assert.NoError(t, app.setup())
+	defer app.db.Close()
+
+	data, err := app.metrics()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "pgcenter_databases_general_")
+	assert.Contains(t, string(data), "pgcenter_system_loadavg_load1 ")
+	assert.Regexp(t, "# EOF\n$", string(data))
+}
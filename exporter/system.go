@@ -0,0 +1,35 @@
+package exporter
+
+import (
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+)
+
+// systemViewPrefix defines prefix of names of views which describe system stats.
+const systemViewPrefix = "system_"
+
+// systemViews returns views which describe system stats. Views with single row have no unique key, views of
+// devices use device name as a label.
+func systemViews() view.Views {
+	views := view.Views{}
+	for _, name := range stat.SystemResultNames {
+		v := view.View{Name: systemViewPrefix + name, UniqueKey: 0}
+		if name == "loadavg" || name == "cpu" || name == "memory" {
+			v.UniqueKey = -1
+		}
+		views[v.Name] = v
+	}
+
+	return views
+}
+
+// systemStats converts system stats into results of system views. CPU usage, disks and network interfaces
+// stats are calculated over the interval since the previous collecting, hence all values are gauges.
+func systemStats(s stat.System) map[string]stat.PGresult {
+	stats := map[string]stat.PGresult{}
+	for name, res := range s.Results() {
+		stats[systemViewPrefix+name] = res
+	}
+
+	return stats
+}
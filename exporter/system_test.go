@@ -0,0 +1,39 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_systemStats(t *testing.T) {
+	s := stat.System{
+		LoadAvg: stat.LoadAvg{One: 0.5, Five: 0.25, Fifteen: 0.1},
+		Meminfo: stat.Meminfo{MemTotal: 1024, MemFree: 512},
+		CPUStat: stat.CPUStat{User: 10.5, Idle: 89.5},
+		Diskstats: stat.Diskstats{
+			{Device: "sda", Util: 12.5, Await: 1.25},
+			{}, // inactive device
+		},
+		Netdevs: stat.Netdevs{{Ifname: "eth0", Rbytes: 1000}},
+		Fsstats: stat.Fsstats{{Mount: stat.Mount{Mountpoint: "/"}, Size: 4096, Pused: 50}},
+	}
+
+	stats := systemStats(s)
+	assert.Len(t, stats, len(systemViews()))
+	assert.Equal(t, 1, stats["system_diskstats"].Nrows)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteOpenMetrics(&buf, systemViews(), stats))
+	out := buf.String()
+	assert.Contains(t, out, "pgcenter_system_loadavg_load1 0.5\n")
+	assert.Contains(t, out, "pgcenter_system_cpu_user_pct 10.5\n")
+	assert.Contains(t, out, "pgcenter_system_memory_total_mib 1024\n")
+	assert.Contains(t, out, "pgcenter_system_diskstats_util_pct{device=\"sda\"} 12.5\n")
+	assert.Contains(t, out, "pgcenter_system_netdev_rbytes_s{interface=\"eth0\"} 1000\n")
+	assert.Contains(t, out, "pgcenter_system_fsstats_size_bytes{mountpoint=\"/\"} 4096\n")
+	assert.NotContains(t, out, "counter")
+}
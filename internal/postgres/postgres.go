@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/term"
+	"io"
 	"net"
 	"os"
 	"strconv"
@@ -103,6 +104,20 @@ func Connect(config Config) (*DB, error) {
 	}
 }
 
+// ConnectDatabase connects to another database using settings of existing connection. Password is not asked,
+// the password of existing connection is used.
+func ConnectDatabase(db *DB, dbname string) (*DB, error) {
+	config := db.Config.Config.Copy()
+	config.Database = dbname
+
+	conn, err := pgx.ConnectConfig(context.TODO(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{Config: Config{Config: config}, Conn: conn, Local: db.Local}, nil
+}
+
 // Reconnect reconnects to Postgres using existing config and swaps failed DB connection.
 func Reconnect(db *DB) error {
 	newdb, err := Connect(db.Config)
@@ -130,6 +145,16 @@ func (db *DB) Query(query string, args ...any) (pgx.Rows, error) {
 	return db.Conn.Query(context.TODO(), query, args...)
 }
 
+// CopyFrom is a wrapper over pgconn.CopyFrom, it executes COPY FROM STDIN statement and sends data from passed reader.
+func (db *DB) CopyFrom(r io.Reader, sql string) (pgconn.CommandTag, error) {
+	return db.Conn.PgConn().CopyFrom(context.TODO(), r, sql)
+}
+
+// ExecRaw is a wrapper over pgconn.Exec, it sends query as is, without substitution of parameters placeholders.
+func (db *DB) ExecRaw(sql string) ([]*pgconn.Result, error) {
+	return db.Conn.PgConn().Exec(context.TODO(), sql).ReadAll()
+}
+
 // Close closes connection to Postgres.
 func (db *DB) Close() {
 	if err := db.Conn.Close(context.TODO()); err != nil {
@@ -155,6 +155,26 @@ func TestReconnect(t *testing.T) {
 	c2.Close()
 }
 
+func TestConnectDatabase(t *testing.T) {
+	db, err := NewTestConnect()
+	if err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	}
+	defer db.Close()
+
+	conn, err := ConnectDatabase(db, "postgres")
+	assert.NoError(t, err)
+
+	var dbname string
+	assert.NoError(t, conn.QueryRow("SELECT current_database()").Scan(&dbname))
+	assert.Equal(t, "postgres", dbname)
+	assert.Equal(t, "pgcenter_fixtures", db.Config.Config.Database) // config of existing connection is not changed
+	conn.Close()
+
+	_, err = ConnectDatabase(db, "pgcenter_unknown_database")
+	assert.Error(t, err)
+}
+
 func TestDB_ALL(t *testing.T) {
 	conn, err := NewTestConnect()
 	assert.NoError(t, err)
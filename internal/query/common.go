@@ -21,6 +21,11 @@ const (
 	ExecCancelQuery = "SELECT pg_cancel_backend($1)"
 	// ExecTerminateBackend terminates the backend with specified PID.
 	ExecTerminateBackend = "SELECT pg_terminate_backend($1)"
+	// SelectActivityQueryText queries full text of query executed by backend with specified PID.
+	SelectActivityQueryText = "SELECT query FROM pg_stat_activity WHERE pid = $1"
+	// SelectStatementsQueryText queries full text of pg_stat_statements query with specified short queryid.
+	SelectStatementsQueryText = "SELECT query FROM {{.PGSSSchema}}.pg_stat_statements " +
+		"WHERE left(md5(userid::text || dbid::text || queryid::text), 10) = $1 LIMIT 1"
 	// ExecCancelQueryGroup cancels a group of queries based on specified criteria.
 	ExecCancelQueryGroup = "SELECT count(pg_cancel_backend(pid)) " +
 		"FROM pg_stat_activity WHERE {{.BackendState}} " +
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// PgStatStatementsExecTimePG13 defines query which returns total execution time of statements (PG 13+).
+	PgStatStatementsExecTimePG13 = "SELECT left(md5(p.userid::text || p.dbid::text || p.queryid::text), 10) AS queryid, " +
+		"d.datname AS database, p.total_exec_time AS exec_time, p.query AS query " +
+		"FROM {{.PGSSSchema}}.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
+
+	// PgStatStatementsExecTimePG12 defines query which returns total execution time of statements (PG 12 and older).
+	PgStatStatementsExecTimePG12 = "SELECT left(md5(p.userid::text || p.dbid::text || p.queryid::text), 10) AS queryid, " +
+		"d.datname AS database, p.total_time AS exec_time, p.query AS query " +
+		"FROM {{.PGSSSchema}}.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
+)
+
+// SelectStatStatementsExecTimeQuery returns proper query which returns total execution time of statements depending
+// on Postgres version.
+func SelectStatStatementsExecTimeQuery(version int) string {
+	if version < PostgresV13 {
+		return PgStatStatementsExecTimePG12
+	}
+	return PgStatStatementsExecTimePG13
+}
+
+var (
+	// explainableRE defines statements which could be explained.
+	explainableRE = regexp.MustCompile(`(?i)^(select|insert|update|delete|merge|with|values|table)\s`)
+	// explainParamRE defines parameters placeholders of normalized statements.
+	explainParamRE = regexp.MustCompile(`\$\d+`)
+)
+
+var (
+	// ErrNotExplainable is returned for statements which could not be explained.
+	ErrNotExplainable = errors.New("only single SELECT, INSERT, UPDATE, DELETE, MERGE, VALUES or TABLE statement could be explained")
+	// ErrGenericPlanRequired is returned for statements with parameters when Postgres doesn't support GENERIC_PLAN.
+	ErrGenericPlanRequired = errors.New("statement with parameters could be explained using GENERIC_PLAN option available since Postgres 16")
+)
+
+// ExplainQuery returns EXPLAIN statement for the query. Statements with parameters could be explained only using
+// GENERIC_PLAN option available since Postgres 16. Returns false if the statement could not be explained.
+func ExplainQuery(version int, q string) (string, bool) {
+	explain, err := Explain(version, q)
+	return explain, err == nil
+}
+
+// Explain returns EXPLAIN statement for the query, or error describing why the statement could not be explained.
+func Explain(version int, q string) (string, error) {
+	q = strings.TrimSuffix(strings.TrimSpace(q), ";")
+
+	// Explain only single statements which don't do anything except planning.
+	if !explainableRE.MatchString(q) || strings.Contains(q, ";") {
+		return "", ErrNotExplainable
+	}
+
+	if !explainParamRE.MatchString(q) {
+		return "EXPLAIN " + q, nil
+	}
+
+	if version < PostgresV16 {
+		return "", ErrGenericPlanRequired
+	}
+
+	return "EXPLAIN (GENERIC_PLAN) " + q, nil
+}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SelectStatStatementsExecTimeQuery(t *testing.T) {
+	assert.Equal(t, PgStatStatementsExecTimePG12, SelectStatStatementsExecTimeQuery(PostgresV12))
+	assert.Equal(t, PgStatStatementsExecTimePG13, SelectStatStatementsExecTimeQuery(PostgresV13))
+	assert.Equal(t, PgStatStatementsExecTimePG13, SelectStatStatementsExecTimeQuery(PostgresV18))
+}
+
+func Test_ExplainQuery(t *testing.T) {
+	testcases := []struct {
+		version int
+		query   string
+		want    string
+		ok      bool
+	}{
+		{version: PostgresV15, query: "SELECT 1;", want: "EXPLAIN SELECT 1", ok: true},
+		{version: PostgresV15, query: "select * from t where id = $1", ok: false},
+		{version: PostgresV16, query: "select * from t where id = $1", want: "EXPLAIN (GENERIC_PLAN) select * from t where id = $1", ok: true},
+		{version: PostgresV16, query: "UPDATE t SET v = $1\nWHERE id = $2", want: "EXPLAIN (GENERIC_PLAN) UPDATE t SET v = $1\nWHERE id = $2", ok: true},
+		{version: PostgresV16, query: "VACUUM t", ok: false},
+		{version: PostgresV16, query: "SELECT 1; DROP TABLE t", ok: false},
+		{version: PostgresV16, query: "", ok: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("%d/%s", tc.version, tc.query), func(t *testing.T) {
+			got, ok := ExplainQuery(tc.version, tc.query)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_Explain(t *testing.T) {
+	testcases := []struct {
+		version int
+		query   string
+		want    string
+		err     error
+	}{
+		{version: PostgresV15, query: "SELECT 1", want: "EXPLAIN SELECT 1"},
+		{version: PostgresV15, query: "select * from t where id = $1", err: ErrGenericPlanRequired},
+		{version: PostgresV16, query: "select * from t where id = $1", want: "EXPLAIN (GENERIC_PLAN) select * from t where id = $1"},
+		{version: PostgresV16, query: "VACUUM t", err: ErrNotExplainable},
+		{version: PostgresV16, query: "SELECT 1; DROP TABLE t", err: ErrNotExplainable},
+	}
+
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("%d/%s", tc.version, tc.query), func(t *testing.T) {
+			got, err := Explain(tc.version, tc.query)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
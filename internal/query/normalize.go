@@ -0,0 +1,196 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Normalize replaces string and numeric literals of the query with parameters placeholders ($1, $2, ...), like
+// pg_stat_statements does. Numbering of placeholders continues after parameters already used in the query.
+// Identifiers, quoted identifiers, comments and keywords are kept as is. Unterminated literals (e.g. in truncated
+// query texts) are replaced up to the end of the query.
+func Normalize(q string) string {
+	b := make([]byte, 0, len(q))
+
+	n := maxParam(q)
+	placeholder := func() {
+		n++
+		b = append(b, '$')
+		b = strconv.AppendInt(b, int64(n), 10)
+	}
+
+	for i := 0; i < len(q); {
+		c := q[i]
+
+		switch {
+		case c == '-' && strings.HasPrefix(q[i:], "--"):
+			// Single-line comment.
+			end := strings.IndexByte(q[i:], '\n')
+			if end < 0 {
+				end = len(q) - i
+			}
+			b = append(b, q[i:i+end]...)
+			i += end
+		case c == '/' && strings.HasPrefix(q[i:], "/*"):
+			// Multi-line comment.
+			end := strings.Index(q[i+2:], "*/")
+			if end < 0 {
+				end = len(q) - i
+			} else {
+				end += 4
+			}
+			b = append(b, q[i:i+end]...)
+			i += end
+		case c == '"':
+			// Quoted identifier.
+			end := quotedEnd(q, i, '"', false)
+			b = append(b, q[i:end]...)
+			i = end
+		case c == '\'':
+			// String literal. Prefix of escape, bit and national strings (E'', B'', X'', N'') is a part of the literal.
+			prefix := literalPrefix(q, i)
+			escape := strings.EqualFold(prefix, "e")
+			b = b[:len(b)-len(prefix)]
+			placeholder()
+			i = quotedEnd(q, i, '\'', escape)
+		case c == '$':
+			// Parameter or dollar-quoted string.
+			if j := digitsEnd(q, i+1); j > i+1 {
+				b = append(b, q[i:j]...)
+				i = j
+				continue
+			}
+
+			if tag, ok := dollarTag(q, i); ok {
+				end := strings.Index(q[i+len(tag):], tag)
+				if end < 0 {
+					i = len(q)
+				} else {
+					i += 2*len(tag) + end
+				}
+				placeholder()
+				continue
+			}
+
+			b = append(b, c)
+			i++
+		case isIdentChar(c) && !isDigit(c):
+			// Keyword or identifier, digits inside it are not literals.
+			j := i
+			for j < len(q) && (isIdentChar(q[j]) || q[j] == '$') {
+				j++
+			}
+			b = append(b, q[i:j]...)
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(q) && isDigit(q[i+1])):
+			i = numberEnd(q, i)
+			placeholder()
+		default:
+			b = append(b, c)
+			i++
+		}
+	}
+
+	return string(b)
+}
+
+// maxParam returns the greatest number of parameter placeholder used in the query.
+func maxParam(q string) int {
+	var max int
+	for i := 0; i < len(q); i++ {
+		if q[i] != '$' {
+			continue
+		}
+
+		j := digitsEnd(q, i+1)
+		if n, err := strconv.Atoi(q[i+1 : j]); err == nil && n > max {
+			max = n
+		}
+	}
+
+	return max
+}
+
+// literalPrefix returns single-letter prefix of the string literal started at position i.
+func literalPrefix(q string, i int) string {
+	if i == 0 || !strings.ContainsRune("eEbBxXnN", rune(q[i-1])) {
+		return ""
+	}
+
+	if i >= 2 && (isIdentChar(q[i-2]) || q[i-2] == '$') {
+		return ""
+	}
+
+	return q[i-1 : i]
+}
+
+// quotedEnd returns position after the closing quote of the literal started at position i. Doubled quotes are
+// part of the literal, backslash escapes are considered only in escape strings.
+func quotedEnd(q string, i int, quote byte, escape bool) int {
+	for j := i + 1; j < len(q); j++ {
+		switch {
+		case escape && q[j] == '\\':
+			j++
+		case q[j] == quote:
+			if j+1 < len(q) && q[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+
+	return len(q)
+}
+
+// dollarTag returns tag of dollar-quoted string started at position i, e.g. $$ or $body$.
+func dollarTag(q string, i int) (string, bool) {
+	// Dollar sign is a part of identifier, e.g. 'a$b'.
+	if i > 0 && isIdentChar(q[i-1]) {
+		return "", false
+	}
+
+	for j := i + 1; j < len(q); j++ {
+		if q[j] == '$' {
+			return q[i : j+1], true
+		}
+		if !isIdentChar(q[j]) {
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// numberEnd returns position after the numeric literal started at position i, including fraction, exponent,
+// hexadecimal, octal and binary forms and underscores between digits.
+func numberEnd(q string, i int) int {
+	j := i
+	for j < len(q) && (isIdentChar(q[j]) || q[j] == '.') {
+		// Exponent sign.
+		if (q[j] == 'e' || q[j] == 'E') && j+1 < len(q) && (q[j+1] == '+' || q[j+1] == '-') {
+			j++
+		}
+		j++
+	}
+
+	return j
+}
+
+// digitsEnd returns position after the digits sequence started at position i.
+func digitsEnd(q string, i int) int {
+	for i < len(q) && isDigit(q[i]) {
+		i++
+	}
+	return i
+}
+
+// isIdentChar returns true if character could be a part of identifier.
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// isDigit returns true if character is a digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,31 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	testcases := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "SELECT 1", want: "SELECT $1"},
+		{query: "SELECT * FROM users WHERE email = 'alice@example.org' AND balance > 100.50", want: "SELECT * FROM users WHERE email = $1 AND balance > $2"},
+		{query: "UPDATE t SET token = 'it''s secret' WHERE id = $1", want: "UPDATE t SET token = $2 WHERE id = $1"},
+		{query: `SELECT E'a\'b', e'x', X'1F', B'101', N'text', name'`, want: "SELECT $1, $2, $3, $4, $5, name$6"},
+		{query: "SELECT $$secret$$, $tag$a $$ b$tag$, a$b FROM t1", want: "SELECT $1, $2, a$b FROM t1"},
+		{query: `SELECT "col1", t2.c3 FROM "t 1" t2 LIMIT 10 OFFSET 2e3`, want: `SELECT "col1", t2.c3 FROM "t 1" t2 LIMIT $1 OFFSET $2`},
+		{query: "SELECT .5, 1.5e-3, 0x1F, 1_000, -1", want: "SELECT $1, $2, $3, $4, -$5"},
+		{query: "SELECT 1 -- comment 2\n, 3 /* comment 4 */", want: "SELECT $1 -- comment 2\n, $2 /* comment 4 */"},
+		{query: "SELECT interval '1 day', '{1,2}'::int[]", want: "SELECT interval $1, $2::int[]"},
+		{query: "SELECT * FROM t WHERE note = 'unterminated secr", want: "SELECT * FROM t WHERE note = $1"},
+		{query: "SELECT $1, $2", want: "SELECT $1, $2"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, Normalize(tc.query), tc.query)
+	}
+}
@@ -0,0 +1,10 @@
+package stat
+
+// AnnotationName defines name of archive entries which contain operator's annotations.
+const AnnotationName = "annotation"
+
+// Annotation defines operator's note written into stats archive, e.g. about actions done during an incident.
+// Time of the annotation is stored in the entry name.
+type Annotation struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,58 @@
+package stat
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"strings"
+)
+
+// ConfigureCustomViews checks queries of custom views using connected Postgres and defines number of columns of
+// the views. Queries are executed as subqueries which don't return rows. Views should be configured and filtered
+// out depending on Postgres version before.
+func ConfigureCustomViews(db *postgres.DB, views view.Views) error {
+	for name, v := range views {
+		if !v.Custom {
+			continue
+		}
+
+		cols, err := describeQuery(db, v.Query)
+		if err != nil {
+			return fmt.Errorf("custom view '%s': check query failed: %w", name, err)
+		}
+
+		err = v.ValidateColumns(len(cols))
+		if err != nil {
+			return err
+		}
+
+		views[name] = v
+	}
+
+	return nil
+}
+
+// describeQuery returns names of columns returned by query.
+func describeQuery(db *postgres.DB, query string) ([]string, error) {
+	q := strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+
+	rows, err := db.Query("SELECT * FROM (" + q + "\n) AS custom LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+
+	descs := rows.FieldDescriptions()
+	cols := make([]string, len(descs))
+	for i, d := range descs {
+		cols[i] = d.Name
+	}
+
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return cols, nil
+}
@@ -0,0 +1,41 @@
+package stat
+
+import (
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfigureCustomViews(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if err != nil {
+		t.Skipf("postgres is not available: %s", err)
+	}
+	defer db.Close()
+
+	views := view.Views{
+		"activity": view.New()["activity"],
+		"queue":    view.Custom{Query: "SELECT datname, xact_commit FROM pg_stat_database;", DiffInterval: []int{1, 1}}.View("queue"),
+	}
+	for name, v := range views {
+		v.Query = v.QueryTmpl
+		views[name] = v
+	}
+
+	assert.NoError(t, ConfigureCustomViews(db, views))
+	assert.Equal(t, 2, views["queue"].Ncols)
+	assert.Equal(t, 14, views["activity"].Ncols) // built-in views are not changed
+
+	testcases := []view.Custom{
+		{Query: "SELECT 1", OrderKey: 1},
+		{Query: "SELECT * FROM unknown_table"},
+		{Query: "SELECT FROM pg_stat_database"},
+	}
+
+	for _, tc := range testcases {
+		v := tc.View("queue")
+		v.Query = v.QueryTmpl
+		assert.Error(t, ConfigureCustomViews(db, view.Views{"queue": v}))
+	}
+}
@@ -0,0 +1,354 @@
+package stat
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// EncryptionName defines name of archive entry which describes encryption of the archive. The entry is the first
+// entry of encrypted archive and it is not encrypted. Contents of all other entries are encrypted using AES-256-GCM,
+// names of entries are not encrypted, hence index of the archive could be used for seeking.
+const EncryptionName = "encryption"
+
+// PassphraseEnv defines environment variable used as a source of passphrase of encrypted archives.
+const PassphraseEnv = "PGCENTER_PASSPHRASE"
+
+// EncryptionOverhead defines number of bytes added to contents of every encrypted entry: nonce and authentication tag.
+const EncryptionOverhead = 12 + 16
+
+const (
+	encryptionVersion       = 1
+	encryptionKeySize       = 32
+	encryptionSaltSize      = 16
+	encryptionHeaderMaxSize = 4096
+	kdfPBKDF2               = "pbkdf2-sha256"
+	kdfHKDF                 = "hkdf-sha256"
+	pbkdf2Iterations        = 600000
+	pbkdf2MaxIterations     = 10000000
+	hkdfInfo                = "pgcenter archive"
+	keyCheckName            = "pgcenter archive key check"
+)
+
+// EncryptionHeader describes how the key of encrypted archive is derived from user's key or passphrase.
+type EncryptionHeader struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt"`
+	Check      []byte `json:"check"` // encrypted empty text, used for checking key is correct
+}
+
+// ArchiveKey defines user's secret used for encrypting archives: key read from key file or passphrase.
+type ArchiveKey struct {
+	Key        []byte
+	Passphrase string
+}
+
+// IsEmpty returns true if no secret is specified.
+func (k ArchiveKey) IsEmpty() bool {
+	return len(k.Key) == 0 && k.Passphrase == ""
+}
+
+// KeyOptions defines user-specified options of archives encryption.
+type KeyOptions struct {
+	KeyFile    string // file which contains 32-bytes key, raw or hex-encoded
+	Passphrase bool   // use passphrase, it is taken from PGCENTER_PASSPHRASE or asked interactively
+}
+
+// Key returns secret specified by options. Empty secret is returned if encryption is not requested.
+func (o KeyOptions) Key() (ArchiveKey, error) {
+	if o.KeyFile != "" && o.Passphrase {
+		return ArchiveKey{}, fmt.Errorf("key file and passphrase could not be used together")
+	}
+
+	if o.KeyFile != "" {
+		key, err := ReadKeyFile(o.KeyFile)
+		if err != nil {
+			return ArchiveKey{}, err
+		}
+		return ArchiveKey{Key: key}, nil
+	}
+
+	if !o.Passphrase {
+		return ArchiveKey{}, nil
+	}
+
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		_, _ = fmt.Fprint(os.Stderr, "Passphrase: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		_, _ = fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return ArchiveKey{}, err
+		}
+		passphrase = string(b)
+	}
+
+	if passphrase == "" {
+		return ArchiveKey{}, fmt.Errorf("empty passphrase")
+	}
+
+	return ArchiveKey{Passphrase: passphrase}, nil
+}
+
+// ReadKeyFile reads key from file. File should contain 32 bytes, or 64 hex digits.
+func ReadKeyFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == encryptionKeySize {
+		return data, nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("key file %s must contain %d bytes key, raw or hex-encoded", filename, encryptionKeySize)
+	}
+
+	return key, nil
+}
+
+// ArchiveCipher encrypts and decrypts contents of archive entries.
+type ArchiveCipher struct {
+	header EncryptionHeader
+	aead   cipher.AEAD
+}
+
+// NewArchiveCipher creates cipher for a new archive.
+func NewArchiveCipher(k ArchiveKey) (*ArchiveCipher, error) {
+	h := EncryptionHeader{Version: encryptionVersion, KDF: kdfHKDF, Salt: make([]byte, encryptionSaltSize)}
+	if k.Passphrase != "" {
+		h.KDF, h.Iterations = kdfPBKDF2, pbkdf2Iterations
+	}
+
+	_, err := rand.Read(h.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newArchiveCipher(k, h)
+	if err != nil {
+		return nil, err
+	}
+
+	c.header.Check = c.Seal(keyCheckName, nil)
+
+	return c, nil
+}
+
+// OpenArchiveCipher creates cipher for existing archive described by header. Returns error if the key doesn't match the archive.
+func OpenArchiveCipher(k ArchiveKey, h EncryptionHeader) (*ArchiveCipher, error) {
+	if h.Version != encryptionVersion {
+		return nil, fmt.Errorf("unsupported archive encryption version %d", h.Version)
+	}
+
+	c, err := newArchiveCipher(k, h)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Open(keyCheckName, h.Check)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or passphrase")
+	}
+
+	return c, nil
+}
+
+// newArchiveCipher derives archive key from user's secret and creates cipher.
+func newArchiveCipher(k ArchiveKey, h EncryptionHeader) (*ArchiveCipher, error) {
+	var key []byte
+	var err error
+
+	switch h.KDF {
+	case kdfPBKDF2:
+		if k.Passphrase == "" {
+			return nil, fmt.Errorf("archive is encrypted using passphrase")
+		}
+		if h.Iterations <= 0 || h.Iterations > pbkdf2MaxIterations {
+			return nil, fmt.Errorf("invalid number of key derivation iterations %d", h.Iterations)
+		}
+		key, err = pbkdf2.Key(sha256.New, k.Passphrase, h.Salt, h.Iterations, encryptionKeySize)
+	case kdfHKDF:
+		if len(k.Key) == 0 {
+			return nil, fmt.Errorf("archive is encrypted using key file")
+		}
+		key, err = hkdf.Key(sha256.New, k.Key, h.Salt, hkdfInfo, encryptionKeySize)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %s", h.KDF)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArchiveCipher{header: h, aead: aead}, nil
+}
+
+// Header returns header which describes encryption of the archive.
+func (c *ArchiveCipher) Header() EncryptionHeader {
+	return c.header
+}
+
+// Seal encrypts contents of the entry. Name of the entry is authenticated, hence encrypted contents could not be
+// moved to another entry.
+func (c *ArchiveCipher) Seal(name string, data []byte) []byte {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	_, _ = rand.Read(nonce)
+
+	return c.aead.Seal(nonce, nonce, data, []byte(name))
+}
+
+// Open decrypts contents of the entry.
+func (c *ArchiveCipher) Open(name string, data []byte) ([]byte, error) {
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+
+	return c.aead.Open(nil, data[:c.aead.NonceSize()], data[c.aead.NonceSize():], []byte(name))
+}
+
+// ReadArchiveEncryption reads the first entry of the archive and returns header of encryption. Nil is returned if the
+// archive is not encrypted.
+func ReadArchiveEncryption(r io.Reader) (*EncryptionHeader, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(hdr.Name, EncryptionName+".") {
+		return nil, nil
+	}
+
+	return readEncryptionHeader(tr, hdr.Size)
+}
+
+// readEncryptionHeader reads and decodes encryption header entry.
+func readEncryptionHeader(r io.Reader, size int64) (*EncryptionHeader, error) {
+	if size < 0 || size > encryptionHeaderMaxSize {
+		return nil, fmt.Errorf("encryption header size %d exceeds limit %d bytes", size, encryptionHeaderMaxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+
+	var h EncryptionHeader
+	err = json.Unmarshal(data, &h)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption header failed: %w", err)
+	}
+
+	return &h, nil
+}
+
+// DecryptArchive returns reader of the archive with decrypted contents of entries. Not encrypted archives are read
+// as is. Returns error if the archive is encrypted and the key doesn't match it.
+func DecryptArchive(r io.Reader, k ArchiveKey) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	// Name of the first entry is at the beginning of the first tar block.
+	block, err := br.Peek(100)
+	if err != nil || !strings.HasPrefix(string(block), EncryptionName+".") {
+		return br, nil
+	}
+
+	if k.IsEmpty() {
+		return nil, fmt.Errorf("archive is encrypted, key file or passphrase is required")
+	}
+
+	tr := tar.NewReader(br)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := readEncryptionHeader(tr, hdr.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := OpenArchiveCipher(k, *h)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.decryptEntries(tr, tar.NewWriter(pw)))
+	}()
+
+	return pr, nil
+}
+
+// decryptEntries reads encrypted entries and writes decrypted entries.
+func (c *ArchiveCipher) decryptEntries(r *tar.Reader, w *tar.Writer) error {
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return w.Close()
+		} else if err != nil {
+			return err
+		}
+
+		// Encryption header is written once, skip headers appended by mistake.
+		if strings.HasPrefix(hdr.Name, EncryptionName+".") {
+			continue
+		}
+
+		// Limit size of encrypted entries in the same way as size of not encrypted entries.
+		if hdr.Size < 0 || hdr.Size > MaxResultFileSize+EncryptionOverhead {
+			return fmt.Errorf("result file size %d exceeds limit %d bytes", hdr.Size, MaxResultFileSize)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r, hdr.Size))
+		if err != nil {
+			return err
+		}
+
+		plain, err := c.Open(hdr.Name, data)
+		if err != nil {
+			return fmt.Errorf("decrypt entry %s failed: %w", hdr.Name, err)
+		}
+
+		err = w.WriteHeader(&tar.Header{Name: hdr.Name, Mode: hdr.Mode, Size: int64(len(plain)), ModTime: hdr.ModTime})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(plain)
+		if err != nil {
+			return err
+		}
+	}
+}
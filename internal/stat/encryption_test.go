@@ -0,0 +1,193 @@
+package stat
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestArchive creates archive with specified entries, contents of entries are encrypted if cipher is not nil.
+func newTestArchive(t *testing.T, c *ArchiveCipher, entries map[string]string, names ...string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	write := func(name string, data []byte) {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		assert.NoError(t, err)
+	}
+
+	if c != nil {
+		data, err := json.Marshal(c.Header())
+		assert.NoError(t, err)
+		write("encryption.20260519T100000.000.json", data)
+	}
+
+	for _, name := range names {
+		data := []byte(entries[name])
+		if c != nil {
+			data = c.Seal(name, data)
+		}
+		write(name, data)
+	}
+
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+// readTestArchive reads entries of the archive.
+func readTestArchive(t *testing.T, r io.Reader) map[string]string {
+	got := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		got[hdr.Name] = string(data)
+	}
+	return got
+}
+
+func TestArchiveCipher(t *testing.T) {
+	key := ArchiveKey{Key: bytes.Repeat([]byte{1}, 32)}
+
+	c, err := NewArchiveCipher(key)
+	assert.NoError(t, err)
+
+	sealed := c.Seal("meta.json", []byte("secret"))
+	assert.Len(t, sealed, len("secret")+EncryptionOverhead)
+	assert.NotContains(t, string(sealed), "secret")
+
+	plain, err := c.Open("meta.json", sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plain))
+
+	// Contents could not be moved to another entry.
+	_, err = c.Open("tables.json", sealed)
+	assert.Error(t, err)
+
+	// Same key opens the archive, other keys and passphrases don't.
+	_, err = OpenArchiveCipher(key, c.Header())
+	assert.NoError(t, err)
+	_, err = OpenArchiveCipher(ArchiveKey{Key: bytes.Repeat([]byte{2}, 32)}, c.Header())
+	assert.Error(t, err)
+	_, err = OpenArchiveCipher(ArchiveKey{Passphrase: "secret"}, c.Header())
+	assert.Error(t, err)
+
+	// Passphrase.
+	c, err = NewArchiveCipher(ArchiveKey{Passphrase: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, kdfPBKDF2, c.Header().KDF)
+	_, err = OpenArchiveCipher(ArchiveKey{Passphrase: "secret"}, c.Header())
+	assert.NoError(t, err)
+	_, err = OpenArchiveCipher(ArchiveKey{Passphrase: "wrong"}, c.Header())
+	assert.Error(t, err)
+}
+
+func TestDecryptArchive(t *testing.T) {
+	key := ArchiveKey{Key: bytes.Repeat([]byte{1}, 32)}
+	c, err := NewArchiveCipher(key)
+	assert.NoError(t, err)
+
+	entries := map[string]string{
+		"meta.20260519T100000.000.json":   `{"valid":true}`,
+		"tables.20260519T100000.000.json": `{"valid":false}`,
+	}
+	names := []string{"meta.20260519T100000.000.json", "tables.20260519T100000.000.json"}
+
+	// Encrypted archive.
+	archive := newTestArchive(t, c, entries, names...)
+	assert.NotContains(t, string(archive), `"valid"`)
+
+	h, err := ReadArchiveEncryption(bytes.NewReader(archive))
+	assert.NoError(t, err)
+	assert.Equal(t, c.Header(), *h)
+
+	r, err := DecryptArchive(bytes.NewReader(archive), key)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, readTestArchive(t, r))
+
+	_, err = DecryptArchive(bytes.NewReader(archive), ArchiveKey{})
+	assert.Error(t, err)
+	_, err = DecryptArchive(bytes.NewReader(archive), ArchiveKey{Key: bytes.Repeat([]byte{2}, 32)})
+	assert.Error(t, err)
+
+	// Not encrypted archive is read as is, regardless of the key.
+	archive = newTestArchive(t, nil, entries, names...)
+
+	h, err = ReadArchiveEncryption(bytes.NewReader(archive))
+	assert.NoError(t, err)
+	assert.Nil(t, h)
+
+	for _, k := range []ArchiveKey{{}, key} {
+		r, err = DecryptArchive(bytes.NewReader(archive), k)
+		assert.NoError(t, err)
+		assert.Equal(t, entries, readTestArchive(t, r))
+	}
+}
+
+func TestDecryptArchive_sizeLimit(t *testing.T) {
+	key := ArchiveKey{Key: bytes.Repeat([]byte{1}, 32)}
+	c, err := NewArchiveCipher(key)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	data, err := json.Marshal(c.Header())
+	assert.NoError(t, err)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "encryption.20260519T100000.000.json", Mode: 0644, Size: int64(len(data))}))
+	_, err = tw.Write(data)
+	assert.NoError(t, err)
+	// Header of crafted entry which size exceeds the limit, contents are not written.
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "meta.20260519T100000.000.json", Mode: 0644, Size: MaxResultFileSize + EncryptionOverhead + 1}))
+
+	r, err := DecryptArchive(bytes.NewReader(buf.Bytes()), key)
+	assert.NoError(t, err)
+
+	_, err = tar.NewReader(r).Next()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds limit")
+}
+
+func TestKeyOptions_Key(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "raw.key")
+	assert.NoError(t, os.WriteFile(raw, bytes.Repeat([]byte{1}, 32), 0600))
+	hexKey := filepath.Join(dir, "hex.key")
+	assert.NoError(t, os.WriteFile(hexKey, []byte(strings.Repeat("01", 32)+"\n"), 0600))
+	bad := filepath.Join(dir, "bad.key")
+	assert.NoError(t, os.WriteFile(bad, []byte("short"), 0600))
+
+	for _, filename := range []string{raw, hexKey} {
+		k, err := KeyOptions{KeyFile: filename}.Key()
+		assert.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{1}, 32), k.Key)
+	}
+
+	_, err := KeyOptions{KeyFile: bad}.Key()
+	assert.Error(t, err)
+	_, err = KeyOptions{KeyFile: raw, Passphrase: true}.Key()
+	assert.Error(t, err)
+
+	k, err := KeyOptions{}.Key()
+	assert.NoError(t, err)
+	assert.True(t, k.IsEmpty())
+
+	t.Setenv(PassphraseEnv, "secret")
+	k, err = KeyOptions{Passphrase: true}.Key()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", k.Passphrase)
+}
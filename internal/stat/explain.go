@@ -0,0 +1,26 @@
+package stat
+
+import (
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"strings"
+)
+
+// ExplainPlan executes EXPLAIN statement and returns plan. Statement is sent as is, hence parameters placeholders
+// of statements explained using GENERIC_PLAN option are not substituted.
+func ExplainPlan(db *postgres.DB, explain string) (string, error) {
+	results, err := db.ExecRaw(explain)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, r := range results {
+		for _, row := range r.Rows {
+			if len(row) > 0 {
+				lines = append(lines, string(row[0]))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
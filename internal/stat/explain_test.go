@@ -0,0 +1,22 @@
+package stat
+
+import (
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExplainPlan(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	}
+	defer db.Close()
+
+	plan, err := ExplainPlan(db, "EXPLAIN (GENERIC_PLAN) SELECT * FROM pg_class WHERE oid = $1")
+	assert.NoError(t, err)
+	assert.Contains(t, plan, "Index Scan")
+
+	_, err = ExplainPlan(db, "EXPLAIN SELECT * FROM pgcenter_unknown_table")
+	assert.Error(t, err)
+}
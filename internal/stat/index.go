@@ -0,0 +1,57 @@
+package stat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IndexEntry defines position of single entry of stats archive. Entry name contains name of the view and timestamp
+// when stats have been recorded.
+type IndexEntry struct {
+	Offset int64  // offset of entry's header in the archive
+	Name   string // name of the entry
+}
+
+// IndexFilename returns name of the index file of the stats archive.
+func IndexFilename(archive string) string {
+	return archive + ".idx"
+}
+
+// FormatIndexEntry returns line of index file which describes the entry.
+func FormatIndexEntry(e IndexEntry) string {
+	return fmt.Sprintf("%d %s\n", e.Offset, e.Name)
+}
+
+// ReadIndex reads and parses index file. Entries have to be ordered by their offsets.
+func ReadIndex(r io.Reader) ([]IndexEntry, error) {
+	var entries []IndexEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), " ", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("bad index line: %s", scanner.Text())
+		}
+
+		offset, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("bad index offset: %s", parts[0])
+		}
+
+		if n := len(entries); n > 0 && offset <= entries[n-1].Offset {
+			return nil, fmt.Errorf("index entries are not ordered at offset %d", offset)
+		}
+
+		entries = append(entries, IndexEntry{Offset: offset, Name: parts[1]})
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,30 @@
+package stat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIndex(t *testing.T) {
+	in := FormatIndexEntry(IndexEntry{Offset: 0, Name: "meta.20260519T100000.000.json"}) +
+		FormatIndexEntry(IndexEntry{Offset: 1024, Name: "tables.20260519T100000.000.json"})
+
+	got, err := ReadIndex(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Equal(t, []IndexEntry{
+		{Offset: 0, Name: "meta.20260519T100000.000.json"},
+		{Offset: 1024, Name: "tables.20260519T100000.000.json"},
+	}, got)
+
+	for _, in := range []string{
+		"0\n",
+		"abc meta.20260519T100000.000.json\n",
+		"-1 meta.20260519T100000.000.json\n",
+		"1024 meta.20260519T100000.000.json\n0 tables.20260519T100000.000.json\n",
+	} {
+		_, err := ReadIndex(strings.NewReader(in))
+		assert.Error(t, err)
+	}
+}
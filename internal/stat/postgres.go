@@ -16,9 +16,12 @@ import (
 
 // Pgstat describes collected Postgres stats.
 type Pgstat struct {
-	Activity Activity
-	Result   PGresult
-	Overview PgstatOverview
+	Activity  Activity
+	Result    PGresult
+	Overview  PgstatOverview
+	Query     string    // query of the view used for collecting Result
+	Raw       PGresult  // result of the query before enrichment and calculating deltas
+	Collected time.Time // time when Raw is collected
 }
 
 // PgstatOverview holds the flat aggregates backing the five verbose pgstat panel rows
@@ -659,6 +662,11 @@ func diff(curr PGresult, prev PGresult, itv int, interval [2]int, ukey int) (PGr
 	return diff, nil
 }
 
+// Sort is public wrapper over sort.
+func (r *PGresult) Sort(key int, desc bool) {
+	r.sort(key, desc)
+}
+
 // sort performs sorting of PGresult using order key and order.
 func (r *PGresult) sort(key int, desc bool) {
 	if r.Nrows == 0 {
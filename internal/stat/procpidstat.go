@@ -38,6 +38,10 @@ const (
 	ColIODelayTotalS = 11
 )
 
+// ProcPidRawName is the name of recorded stats which contain raw procpidstat
+// values produced by BuildProcPidResultRaw.
+const ProcPidRawName = "procpidstat_raw"
+
 // ProcPidStat describes raw per-process CPU usage values from /proc/[pid]/stat.
 // Values are unscaled (jiffies), not seconds.
 type ProcPidStat struct {
@@ -243,9 +247,9 @@ func formatCPUTime(jiffies, ticks float64) string {
 //   - cpuCount            — number of CPUs used to normalize %all/%us/%sy.
 //
 // BuildProcPidResult is a composition: it assembles raw numeric values via
-// buildProcPidResultRaw (cols 6-11 hold float strings, cols 12-17 hold
+// BuildProcPidResultRaw (cols 6-11 hold float strings, cols 12-17 hold
 // already-display rate strings), then formats raw cols 6-11 for display via
-// formatProcPidResultForDisplay. The split keeps recorder-side raw assembly
+// FormatProcPidResultForDisplay. The split keeps recorder-side raw assembly
 // reusable while preserving the established display contract.
 func BuildProcPidResult(
 	activity PGresult,
@@ -257,17 +261,17 @@ func BuildProcPidResult(
 	itv float64,
 	cpuCount int,
 ) PGresult {
-	raw := buildProcPidResultRaw(
+	raw := BuildProcPidResultRaw(
 		activity,
 		prevStats, currStats,
 		prevIO, currIO,
 		ioAvailable, delayAcctAvailable,
 		ticks, itv, cpuCount,
 	)
-	return formatProcPidResultForDisplay(raw, ticks)
+	return FormatProcPidResultForDisplay(raw, ticks)
 }
 
-// buildProcPidResultRaw is the model stage of the BuildProcPidResult pipeline.
+// BuildProcPidResultRaw is the model stage of the BuildProcPidResult pipeline.
 // It produces the same 19-column PGresult as BuildProcPidResult except that
 // cols 6-11 hold *raw* numeric values as float strings instead of
 // display-formatted ones:
@@ -281,9 +285,13 @@ func BuildProcPidResult(
 //     is unavailable.
 //
 // Cols 12-17 are computed as today (delta rates) and already display-ready;
-// formatProcPidResultForDisplay passes them through unchanged. SQL-derived
+// FormatProcPidResultForDisplay passes them through unchanged. SQL-derived
 // cols 0-5 and col 18 (query) are copied verbatim.
-func buildProcPidResultRaw(
+//
+// The recorder stores the raw result in the archive alongside the display one,
+// so the report can recompute rates over arbitrary windows with
+// CompareProcPidResultRaw.
+func BuildProcPidResultRaw(
 	activity PGresult,
 	prevStats, currStats map[int]ProcPidStat,
 	prevIO, currIO map[int]ProcPidIO,
@@ -433,7 +441,102 @@ func buildProcPidResultRaw(
 	}
 }
 
-// formatProcPidResultForDisplay is the view stage of the BuildProcPidResult
+// CompareProcPidResultRaw recalculates rate cols 12-17 of the raw result curr
+// using the raw result prev taken itv seconds before, and returns the result
+// formatted for display. Rows are matched by PID; rows which have no match in
+// prev get the same "0"/"0.00"/"" values as on the first tick of
+// BuildProcPidResultRaw. The sentinels of raw cols 6-11 are used to tell
+// invalid PIDs and unavailable IO or delay accounting apart.
+func CompareProcPidResultRaw(curr, prev PGresult, itv float64, ticks float64, cpuCount int) PGresult {
+	prevRows := make(map[string][]sql.NullString, len(prev.Values))
+	for _, row := range prev.Values {
+		if len(row) == procPidResultNcols {
+			prevRows[row[0].String] = row
+		}
+	}
+
+	values := make([][]sql.NullString, 0, len(curr.Values))
+	for _, src := range curr.Values {
+		if len(src) != procPidResultNcols {
+			continue
+		}
+
+		row := make([]sql.NullString, procPidResultNcols)
+		copy(row, src)
+
+		pid, perr := strconv.Atoi(strings.TrimSpace(src[0].String))
+		validPID := perr == nil && pid > 0
+		p, havePrev := prevRows[src[0].String]
+		havePrev = havePrev && validPID && itv > 0
+
+		// Cols 12..14 — CPU rate %all, %us, %sy.
+		row[12], row[13], row[14] = nullString("0"), nullString("0"), nullString("0")
+		if havePrev && ticks > 0 && cpuCount > 0 {
+			denom := itv * ticks
+			scale := 100.0 / float64(cpuCount)
+			for i, idx := range []int{6, 7, 8} {
+				if d, ok := rawDelta(p[idx], src[idx]); ok {
+					row[12+i] = nullString(strconv.FormatFloat(d/denom*scale, 'f', 2, 64))
+				}
+			}
+		}
+
+		// Cols 15..16 — IO rate read,KiB/s, write,KiB/s.
+		for i, idx := range []int{9, 10} {
+			switch {
+			case src[idx].String == "":
+				row[15+i] = nullString("")
+			case havePrev:
+				d, _ := rawDelta(p[idx], src[idx])
+				row[15+i] = nullString(strconv.FormatFloat(d/itv/1024, 'f', 2, 64))
+			default:
+				row[15+i] = nullString("0.00")
+			}
+		}
+
+		// Col 17 — %iodelay rate.
+		switch {
+		case src[11].String == "":
+			row[17] = nullString("")
+		case !validPID:
+			row[17] = nullString("0.00")
+		case havePrev && ticks > 0:
+			d, _ := rawDelta(p[11], src[11])
+			row[17] = nullString(strconv.FormatFloat(d/(itv*ticks)*100, 'f', 2, 64))
+		default:
+			row[17] = nullString("")
+		}
+
+		values = append(values, row)
+	}
+
+	cols := make([]string, procPidResultNcols)
+	copy(cols, procPidResultCols)
+
+	return FormatProcPidResultForDisplay(PGresult{
+		Valid:  true,
+		Ncols:  procPidResultNcols,
+		Nrows:  len(values),
+		Cols:   cols,
+		Values: values,
+	}, ticks)
+}
+
+// rawDelta parses two raw float cells and returns their delta. Returns false
+// if any of cells is not a number.
+func rawDelta(prev, curr sql.NullString) (float64, bool) {
+	p, err := strconv.ParseFloat(prev.String, 64)
+	if err != nil {
+		return 0, false
+	}
+	c, err := strconv.ParseFloat(curr.String, 64)
+	if err != nil {
+		return 0, false
+	}
+	return delta(p, c), true
+}
+
+// FormatProcPidResultForDisplay is the view stage of the BuildProcPidResult
 // pipeline. It converts raw cols 6-11 into display strings:
 //
 //   - cols 6-8  — raw jiffies float string → HH:MM:SS via formatCPUTime.
@@ -447,7 +550,7 @@ func buildProcPidResultRaw(
 //     yields >0 in production) the format falls back to "0:00:00".
 //
 // All other cols (0-5, 12-17, 18) are passed through unchanged: cols 12-17
-// are already display-ready rate strings produced by buildProcPidResultRaw.
+// are already display-ready rate strings produced by BuildProcPidResultRaw.
 //
 // Invariant for callers: cells in cols 6-11 of a raw PGresult are either the
 // documented sentinel ("0" for cols 6-8/11, "" for cols 9-11) or a valid
@@ -455,7 +558,7 @@ func buildProcPidResultRaw(
 // pass through any other value unchanged — silent passthrough is intentional
 // for sentinel safety; an unexpected value indicates a bug in the raw stage,
 // not a runtime condition to format.
-func formatProcPidResultForDisplay(raw PGresult, ticks float64) PGresult {
+func FormatProcPidResultForDisplay(raw PGresult, ticks float64) PGresult {
 	values := make([][]sql.NullString, 0, raw.Nrows)
 
 	for _, src := range raw.Values {
@@ -513,7 +616,7 @@ func formatProcPidResultForDisplay(raw PGresult, ticks float64) PGresult {
 }
 
 // formatJiffiesCell converts a raw CPU-jiffies float string to HH:MM:SS.
-// The "0" sentinel (used by buildProcPidResultRaw to mark an invalid PID)
+// The "0" sentinel (used by BuildProcPidResultRaw to mark an invalid PID)
 // is passed through unchanged. Parse failures are also passed through to
 // avoid silently corrupting unexpected upstream values.
 func formatJiffiesCell(cell sql.NullString, ticks float64) sql.NullString {
@@ -505,13 +505,13 @@ func TestBuildProcPidResult_DelayUnavailable(t *testing.T) {
 	assert.True(t, row[17].Valid)
 }
 
-// TestBuildProcPidResultRaw verifies that the private raw-stage builder
+// TestBuildProcPidResultRaw verifies that the raw-stage builder
 // produces a 19-col PGresult where cols 6-8 contain raw jiffies as float
 // strings (no HH:MM:SS — no ":" separator), cols 9-10 contain raw bytes as
 // float strings (not KiB-divided), col 11 contains raw iodelay ticks as a
 // float string, and the SQL-derived cols (0-5, 18) are unchanged. Cols 12-17
 // are already display-ready rate strings produced inside the raw stage —
-// they pass through formatProcPidResultForDisplay unchanged.
+// they pass through FormatProcPidResultForDisplay unchanged.
 func TestBuildProcPidResultRaw(t *testing.T) {
 	activity := newTestActivityResult([][]string{
 		{"800", "postgres", "alice", "active", "Lock", "transactionid", "SELECT 8"},
@@ -529,7 +529,7 @@ func TestBuildProcPidResultRaw(t *testing.T) {
 		800: {ReadBytes: 10240, WriteBytes: 20480},
 	}
 
-	raw := buildProcPidResultRaw(activity, prevStats, currStats, prevIO, currIO, true, true, 100, 1, 4)
+	raw := BuildProcPidResultRaw(activity, prevStats, currStats, prevIO, currIO, true, true, 100, 1, 4)
 
 	assert.True(t, raw.Valid)
 	assert.Equal(t, 19, raw.Ncols)
@@ -595,7 +595,7 @@ func TestBuildProcPidResultRaw_InvalidPID(t *testing.T) {
 	})
 
 	// With delayAcctAvailable=false → col 11 must be "" sentinel.
-	rawNoDelay := buildProcPidResultRaw(activity, nil, map[int]ProcPidStat{}, nil, map[int]ProcPidIO{}, true, false, 100, 1, 4)
+	rawNoDelay := BuildProcPidResultRaw(activity, nil, map[int]ProcPidStat{}, nil, map[int]ProcPidIO{}, true, false, 100, 1, 4)
 	assert.Equal(t, 3, rawNoDelay.Nrows)
 	for i, row := range rawNoDelay.Values {
 		assert.Equalf(t, "0", row[6].String, "row %d col 6 must be '0' sentinel", i)
@@ -608,7 +608,7 @@ func TestBuildProcPidResultRaw_InvalidPID(t *testing.T) {
 
 	// With delayAcctAvailable=true → col 11 must be "0" sentinel (raw stage),
 	// which the format stage converts to "00:00:00".
-	rawWithDelay := buildProcPidResultRaw(activity, nil, map[int]ProcPidStat{}, nil, map[int]ProcPidIO{}, true, true, 100, 1, 4)
+	rawWithDelay := BuildProcPidResultRaw(activity, nil, map[int]ProcPidStat{}, nil, map[int]ProcPidIO{}, true, true, 100, 1, 4)
 	for i, row := range rawWithDelay.Values {
 		assert.Equalf(t, "0", row[11].String, "row %d col 11 must be '0' sentinel (delayAcct=true, invalid PID)", i)
 	}
@@ -646,7 +646,7 @@ func TestFormatProcPidResultForDisplay(t *testing.T) {
 		}},
 	}
 
-	got := formatProcPidResultForDisplay(raw, 100)
+	got := FormatProcPidResultForDisplay(raw, 100)
 
 	assert.True(t, got.Valid)
 	assert.Equal(t, 19, got.Ncols)
@@ -706,7 +706,7 @@ func TestFormatProcPidResultForDisplay_TicksZero(t *testing.T) {
 		}},
 	}
 
-	got := formatProcPidResultForDisplay(raw, 0)
+	got := FormatProcPidResultForDisplay(raw, 0)
 	assert.Equal(t, "0:00:00", got.Values[0][11].String)
 }
 
@@ -733,7 +733,7 @@ func TestFormatProcPidResultForDisplay_Sentinels(t *testing.T) {
 		}},
 	}
 
-	got := formatProcPidResultForDisplay(raw, 100)
+	got := FormatProcPidResultForDisplay(raw, 100)
 	row := got.Values[0]
 
 	// Cols 6-8: "0" sentinel passes through (preserves pre-split behavior).
@@ -778,3 +778,42 @@ func TestProcPidColIndexConstants(t *testing.T) {
 	assert.Equal(t, "write_total,KiB", procPidResultCols[ColWriteTotalKiB])
 	assert.Equal(t, "iodelay_total,s", procPidResultCols[ColIODelayTotalS])
 }
+
+// TestCompareProcPidResultRaw verifies that rates recalculated from two raw
+// results are the same as rates calculated by BuildProcPidResult from the
+// procfs snapshots these raw results were built from.
+func TestCompareProcPidResultRaw(t *testing.T) {
+	activity := newTestActivityResult([][]string{
+		{"100", "postgres", "alice", "active", "", "", "SELECT 1"},
+		{"200", "postgres", "bob", "active", "", "", "SELECT 2"},
+		{"abc", "postgres", "carol", "active", "", "", "SELECT 3"},
+	})
+	prevStats := map[int]ProcPidStat{100: {Utime: 100, Stime: 50, IODelay: 10}}
+	currStats := map[int]ProcPidStat{100: {Utime: 700, Stime: 350, IODelay: 40}, 200: {Utime: 10, Stime: 5}}
+	prevIO := map[int]ProcPidIO{100: {ReadBytes: 4096, WriteBytes: 8192}}
+	currIO := map[int]ProcPidIO{100: {ReadBytes: 64 * 1024 * 60, WriteBytes: 8192}, 200: {ReadBytes: 1024}}
+
+	// PID 200 has started after the previous sample.
+	prevActivity := newTestActivityResult([][]string{
+		{"100", "postgres", "alice", "active", "", "", "SELECT 1"},
+		{"abc", "postgres", "carol", "active", "", "", "SELECT 3"},
+	})
+
+	prev := BuildProcPidResultRaw(prevActivity, nil, prevStats, nil, prevIO, true, true, 100, 0, 4)
+	curr := BuildProcPidResultRaw(activity, prevStats, currStats, prevIO, currIO, true, true, 100, 1, 4)
+
+	got := CompareProcPidResultRaw(curr, prev, 60, 100, 4)
+	want := BuildProcPidResult(activity, prevStats, currStats, prevIO, currIO, true, true, 100, 60, 4)
+	assert.Equal(t, want, got)
+
+	// Rates of PID 100 over 60 seconds: 900 jiffies of 4 CPUs, ~64KiB/s read, 30 jiffies of iodelay.
+	assert.Equal(t, []string{"3.75", "2.50", "1.25", "63.93", "0.00", "0.50"}, []string{
+		got.Values[0][12].String, got.Values[0][13].String, got.Values[0][14].String,
+		got.Values[0][15].String, got.Values[0][16].String, got.Values[0][17].String,
+	})
+
+	// No previous sample at all - first tick values.
+	got = CompareProcPidResultRaw(curr, PGresult{}, 60, 100, 4)
+	want = BuildProcPidResult(activity, nil, currStats, nil, currIO, true, true, 100, 60, 4)
+	assert.Equal(t, want, got)
+}
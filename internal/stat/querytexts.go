@@ -0,0 +1,95 @@
+package stat
+
+import "database/sql"
+
+// QueryTextsName defines name of archive entries which contain texts of pg_stat_statements queries. Recorded
+// statements snapshots don't contain queries texts, the texts are stored once per queryid in these entries.
+const QueryTextsName = "query_texts"
+
+// PlansName defines name of archive entries which contain captured plans of pg_stat_statements queries.
+const PlansName = "plans"
+
+// queryTextsCols returns indexes of 'queryid' and 'query' columns of the result. Returns false if the result
+// doesn't contain them.
+func queryTextsCols(res PGresult) (int, int, bool) {
+	qid, text := -1, -1
+	for i, col := range res.Cols {
+		switch col {
+		case "queryid":
+			qid = i
+		case "query":
+			text = i
+		}
+	}
+
+	return qid, text, qid >= 0 && text >= 0
+}
+
+// NewQueryTexts creates result which contains passed queryid and query text pairs.
+func NewQueryTexts(rows [][]sql.NullString) PGresult {
+	return PGresult{Valid: true, Cols: []string{"queryid", "query"}, Ncols: 2, Nrows: len(rows), Values: rows}
+}
+
+// DedupQueryTexts removes queries texts from the result and returns the result without texts. Texts which are
+// not in seen (or differ from it) are added to seen and returned as queryid and query pairs, these texts have
+// to be recorded before the result.
+func DedupQueryTexts(res PGresult, seen map[string]string) (PGresult, [][]sql.NullString) {
+	var texts [][]sql.NullString
+
+	qid, text, ok := queryTextsCols(res)
+	if !ok {
+		return res, nil
+	}
+
+	values := make([][]sql.NullString, len(res.Values))
+	for i, row := range res.Values {
+		if qid >= len(row) || text >= len(row) || !row[qid].Valid || !row[text].Valid {
+			values[i] = row
+			continue
+		}
+
+		id := row[qid].String
+		if s, ok := seen[id]; !ok || s != row[text].String {
+			seen[id] = row[text].String
+			texts = append(texts, []sql.NullString{row[qid], row[text]})
+		}
+
+		values[i] = make([]sql.NullString, len(row))
+		copy(values[i], row)
+		values[i][text] = sql.NullString{}
+	}
+
+	res.Values = values
+	return res, texts
+}
+
+// ResolveQueryTexts restores queries texts removed by DedupQueryTexts using recorded texts.
+func ResolveQueryTexts(res PGresult, texts map[string]string) PGresult {
+	qid, text, ok := queryTextsCols(res)
+	if !ok || len(texts) == 0 {
+		return res
+	}
+
+	for _, row := range res.Values {
+		if qid >= len(row) || text >= len(row) || row[text].Valid {
+			continue
+		}
+
+		if s, ok := texts[row[qid].String]; ok {
+			row[text] = sql.NullString{String: s, Valid: true}
+		}
+	}
+
+	return res
+}
+
+// UpdateQueryTexts adds recorded texts to texts.
+func UpdateQueryTexts(texts map[string]string, res PGresult) {
+	for _, row := range res.Values {
+		if len(row) < 2 || !row[0].Valid || !row[1].Valid {
+			continue
+		}
+
+		texts[row[0].String] = row[1].String
+	}
+}
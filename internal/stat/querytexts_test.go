@@ -0,0 +1,50 @@
+package stat
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupQueryTexts(t *testing.T) {
+	mkRes := func(texts ...sql.NullString) PGresult {
+		res := PGresult{Valid: true, Ncols: 3, Nrows: len(texts), Cols: []string{"calls", "queryid", "query"}}
+		for i, text := range texts {
+			res.Values = append(res.Values, []sql.NullString{
+				{String: "10", Valid: true}, {String: string(rune('a' + i)), Valid: true}, text,
+			})
+		}
+		return res
+	}
+
+	seen := map[string]string{}
+	in := mkRes(sql.NullString{String: "SELECT 1", Valid: true}, sql.NullString{})
+
+	// New texts are returned and removed from the result, NULL texts are kept as is.
+	got, texts := DedupQueryTexts(in, seen)
+	assert.Equal(t, mkRes(sql.NullString{}, sql.NullString{}), got)
+	assert.Equal(t, [][]sql.NullString{{{String: "a", Valid: true}, {String: "SELECT 1", Valid: true}}}, texts)
+	assert.Equal(t, map[string]string{"a": "SELECT 1"}, seen)
+	assert.Equal(t, "SELECT 1", in.Values[0][2].String) // source result is not modified
+
+	// Already seen texts are not returned, changed texts are returned again.
+	_, texts = DedupQueryTexts(in, seen)
+	assert.Nil(t, texts)
+	_, texts = DedupQueryTexts(mkRes(sql.NullString{String: "SELECT 2", Valid: true}), seen)
+	assert.Len(t, texts, 1)
+
+	// Results without queries texts are not changed.
+	res := PGresult{Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"query"}, Values: [][]sql.NullString{{{String: "SELECT 1", Valid: true}}}}
+	got, texts = DedupQueryTexts(res, seen)
+	assert.Equal(t, res, got)
+	assert.Nil(t, texts)
+
+	// Texts are resolved back.
+	texts2 := map[string]string{}
+	UpdateQueryTexts(texts2, NewQueryTexts([][]sql.NullString{{{String: "a", Valid: true}, {String: "SELECT 1", Valid: true}}}))
+	assert.Equal(t,
+		mkRes(sql.NullString{String: "SELECT 1", Valid: true}, sql.NullString{}),
+		ResolveQueryTexts(mkRes(sql.NullString{}, sql.NullString{}), texts2),
+	)
+}
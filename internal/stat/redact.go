@@ -0,0 +1,37 @@
+package stat
+
+import (
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/query"
+)
+
+// RedactQueries returns copy of the result where literals of query texts are replaced with placeholders. Query
+// texts are values of 'query' column which is used in activity, per-process and statements stats.
+func RedactQueries(res PGresult) PGresult {
+	text := -1
+	for i, col := range res.Cols {
+		if col == "query" {
+			text = i
+			break
+		}
+	}
+
+	if text < 0 {
+		return res
+	}
+
+	values := make([][]sql.NullString, len(res.Values))
+	for i, row := range res.Values {
+		if text >= len(row) || !row[text].Valid {
+			values[i] = row
+			continue
+		}
+
+		values[i] = make([]sql.NullString, len(row))
+		copy(values[i], row)
+		values[i][text].String = query.Normalize(row[text].String)
+	}
+
+	res.Values = values
+	return res
+}
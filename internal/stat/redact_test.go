@@ -0,0 +1,29 @@
+package stat
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactQueries(t *testing.T) {
+	row := []sql.NullString{{String: "123", Valid: true}, {String: "SELECT 'alice@example.org'", Valid: true}}
+	res := PGresult{
+		Valid: true, Ncols: 2, Nrows: 3, Cols: []string{"pid", "query"},
+		Values: [][]sql.NullString{row, {{String: "124", Valid: true}, {}}, {{String: "125", Valid: true}}},
+	}
+
+	got := RedactQueries(res)
+	assert.Equal(t, "SELECT $1", got.Values[0][1].String)
+	assert.Equal(t, "123", got.Values[0][0].String)
+	assert.Equal(t, sql.NullString{}, got.Values[1][1])
+	assert.Len(t, got.Values[2], 1)
+
+	// Source result is not modified.
+	assert.Equal(t, "SELECT 'alice@example.org'", row[1].String)
+
+	// Results without queries texts are returned as is.
+	res = PGresult{Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"datname"}, Values: [][]sql.NullString{{{String: "db1", Valid: true}}}}
+	assert.Equal(t, res, RedactQueries(res))
+}
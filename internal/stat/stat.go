@@ -325,6 +325,9 @@ func (c *Collector) Update(db *postgres.DB, view view.View, refresh time.Duratio
 	}
 
 	s.Pgstat.Result = res
+	s.Pgstat.Query = view.Query
+	s.Pgstat.Raw = res
+	s.Pgstat.Collected = time.Now()
 
 	// Per-process system stats enrichment. When the active view requests
 	// per-PID procfs data, replace the 7-column SQL result with the 19-column
@@ -447,6 +450,46 @@ func (c *Collector) Update(db *postgres.DB, view view.View, refresh time.Duratio
 	return s, nil
 }
 
+// UpdateSystem collects system stats only, including disks, network interfaces and filesystems stats. Usage stats
+// are calculated over the interval since the previous call. Failure of extra stats doesn't abort collecting, failed
+// stats are left empty.
+func (c *Collector) UpdateSystem(db *postgres.DB) (System, error) {
+	var s System
+
+	loadavg, err := readLoadAverage(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+	s.LoadAvg = loadavg
+
+	meminfo, err := readMeminfo(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+	s.Meminfo = meminfo
+
+	cpustat, err := readCPUStat(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+
+	c.prevCPUStat = c.currCPUStat
+	c.currCPUStat = cpustat
+	s.CPUStat = countCPUUsage(c.prevCPUStat, c.currCPUStat, c.config.ticks)
+
+	if diskstats, err := c.collectDiskstats(db); err == nil {
+		s.Diskstats = diskstats
+	}
+	if netdevs, err := c.collectNetdevs(db); err == nil {
+		s.Netdevs = netdevs
+	}
+	if fsstats, err := c.collectFsstats(db); err == nil {
+		s.Fsstats = fsstats
+	}
+
+	return s, nil
+}
+
 // ToggleCollectExtra toggle collector's setting related to extra stats.
 func (c *Collector) ToggleCollectExtra(e int) {
 	c.config.collectExtra = e
@@ -0,0 +1,105 @@
+package stat
+
+import (
+	"database/sql"
+	"strconv"
+)
+
+// SystemResultNames defines names of results returned by System.Results.
+var SystemResultNames = []string{"loadavg", "cpu", "memory", "diskstats", "netdev", "fsstats"}
+
+// Results converts system stats into results with the same layout as Postgres stats: load average, CPU and memory
+// usage are single-row results, disks, network interfaces and filesystems are described by a row per device.
+// Inactive devices are skipped.
+func (s System) Results() map[string]PGresult {
+	stats := map[string]PGresult{
+		"loadavg": systemResult(
+			[]string{"load1", "load5", "load15"},
+			[][]string{{formatFloat(s.LoadAvg.One), formatFloat(s.LoadAvg.Five), formatFloat(s.LoadAvg.Fifteen)}},
+		),
+		"cpu": systemResult(
+			[]string{"user,%", "nice,%", "system,%", "idle,%", "iowait,%", "irq,%", "softirq,%", "steal,%"},
+			[][]string{{
+				formatFloat(s.CPUStat.User), formatFloat(s.CPUStat.Nice), formatFloat(s.CPUStat.Sys), formatFloat(s.CPUStat.Idle),
+				formatFloat(s.CPUStat.Iowait), formatFloat(s.CPUStat.Irq), formatFloat(s.CPUStat.Softirq), formatFloat(s.CPUStat.Steal),
+			}},
+		),
+		"memory": systemResult(
+			[]string{"total,MiB", "free,MiB", "used,MiB", "cached,MiB", "buffers,MiB", "dirty,MiB", "writeback,MiB", "slab,MiB", "swap_total,MiB", "swap_free,MiB", "swap_used,MiB"},
+			[][]string{{
+				formatUint(s.Meminfo.MemTotal), formatUint(s.Meminfo.MemFree), formatUint(s.Meminfo.MemUsed),
+				formatUint(s.Meminfo.MemCached), formatUint(s.Meminfo.MemBuffers), formatUint(s.Meminfo.MemDirty),
+				formatUint(s.Meminfo.MemWriteback), formatUint(s.Meminfo.MemSlab), formatUint(s.Meminfo.SwapTotal),
+				formatUint(s.Meminfo.SwapFree), formatUint(s.Meminfo.SwapUsed),
+			}},
+		),
+	}
+
+	// Inactive devices have empty names.
+	var rows [][]string
+	for _, d := range s.Diskstats {
+		if d.Device == "" {
+			continue
+		}
+		rows = append(rows, []string{
+			d.Device, formatFloat(d.Rmerged), formatFloat(d.Wmerged), formatFloat(d.Rawait),
+			formatFloat(d.Wawait), formatFloat(d.Await), formatFloat(d.Arqsz), formatFloat(d.Util),
+		})
+	}
+	stats["diskstats"] = systemResult(
+		[]string{"device", "rmerged,/s", "wmerged,/s", "rawait,ms", "wawait,ms", "await,ms", "arqsz", "util,%"}, rows,
+	)
+
+	rows = nil
+	for _, n := range s.Netdevs {
+		if n.Ifname == "" {
+			continue
+		}
+		rows = append(rows, []string{
+			n.Ifname, formatFloat(n.Rbytes), formatFloat(n.Tbytes), formatFloat(n.Rpackets), formatFloat(n.Tpackets),
+			formatFloat(n.Rerrs), formatFloat(n.Terrs), formatFloat(n.Tcolls), formatFloat(n.Saturation), formatFloat(n.Utilization),
+		})
+	}
+	stats["netdev"] = systemResult(
+		[]string{"interface", "rbytes,/s", "tbytes,/s", "rpackets,/s", "tpackets,/s", "rerrs,/s", "terrs,/s", "colls,/s", "saturation,/s", "util,%"}, rows,
+	)
+
+	rows = nil
+	for _, f := range s.Fsstats {
+		if f.Mount.Mountpoint == "" {
+			continue
+		}
+		rows = append(rows, []string{
+			f.Mount.Mountpoint, formatFloat(f.Size), formatFloat(f.Free), formatFloat(f.Avail), formatFloat(f.Used),
+			formatFloat(f.Reserved), formatFloat(f.Pused), formatFloat(f.Files), formatFloat(f.Filesfree), formatFloat(f.Filesused),
+		})
+	}
+	stats["fsstats"] = systemResult(
+		[]string{"mountpoint", "size,bytes", "free,bytes", "avail,bytes", "used,bytes", "reserved,bytes", "used,%", "files", "files_free", "files_used"}, rows,
+	)
+
+	return stats
+}
+
+// systemResult creates result from passed columns and rows.
+func systemResult(cols []string, rows [][]string) PGresult {
+	res := PGresult{Valid: true, Ncols: len(cols), Nrows: len(rows), Cols: cols, Values: make([][]sql.NullString, len(rows))}
+	for i, row := range rows {
+		res.Values[i] = make([]sql.NullString, len(row))
+		for j, v := range row {
+			res.Values[i][j] = sql.NullString{String: v, Valid: true}
+		}
+	}
+
+	return res
+}
+
+// formatFloat formats float value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatUint formats unsigned integer value.
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
@@ -0,0 +1,32 @@
+package stat
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystem_Results(t *testing.T) {
+	s := System{
+		LoadAvg:   LoadAvg{One: 0.5, Five: 0.25, Fifteen: 0.1},
+		Diskstats: Diskstats{{Device: "sda", Util: 12.5}, {}},
+		Fsstats:   Fsstats{{Mount: Mount{Mountpoint: "/"}, Size: 4096}},
+	}
+
+	res := s.Results()
+	assert.Len(t, res, len(SystemResultNames))
+	for _, name := range SystemResultNames {
+		assert.True(t, res[name].Valid)
+		assert.Equal(t, len(res[name].Cols), res[name].Ncols)
+	}
+
+	assert.Equal(t, []string{"load1", "load5", "load15"}, res["loadavg"].Cols)
+	assert.Equal(t, [][]sql.NullString{{{String: "0.5", Valid: true}, {String: "0.25", Valid: true}, {String: "0.1", Valid: true}}}, res["loadavg"].Values)
+
+	assert.Equal(t, 1, res["diskstats"].Nrows)
+	assert.Equal(t, "sda", res["diskstats"].Values[0][0].String)
+	assert.Equal(t, "12.5", res["diskstats"].Values[0][7].String)
+	assert.Equal(t, 0, res["netdev"].Nrows)
+	assert.Equal(t, "4096", res["fsstats"].Values[0][1].String)
+}
@@ -0,0 +1,181 @@
+package view
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Custom describes user-defined view declared in the 'custom_views' section of configuration file.
+type Custom struct {
+	Description  string `toml:"description,omitempty"`   // Text shown when switching to the view
+	Query        string `toml:"query"`                   // Query template, fields of query.Options could be used
+	MinVersion   int    `toml:"min_version,omitempty"`   // Minimum required Postgres version, e.g. 140000
+	DiffInterval []int  `toml:"diff_interval,omitempty"` // First and last columns with cumulative values, no diffs if empty
+	UniqueKey    int    `toml:"unique_key,omitempty"`    // Index of column which uniquely identifies rows
+	OrderKey     int    `toml:"order_key,omitempty"`     // Index of column used for ordering
+	OrderDesc    *bool  `toml:"order_desc,omitempty"`    // Ordering direction, descending by default
+	Hotkey       string `toml:"hotkey,omitempty"`        // Key used for switching to the view in 'pgcenter top'
+	Menu         bool   `toml:"menu,omitempty"`          // Show the view in menu of custom views in 'pgcenter top'
+	Recordable   *bool  `toml:"recordable,omitempty"`    // Record stats of the view by 'pgcenter record', true by default
+}
+
+var (
+	// customNameRE defines allowed names of custom views, names are used in names of recorded files.
+	customNameRE = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	// customReservedNames defines names of files stored in stats archive along with views stats.
+	customReservedNames = map[string]bool{
+		"meta": true, "sysinfo": true, "manifest": true, "annotation": true, "encryption": true,
+		"query_texts": true, "plans": true, "index": true, "procpidstat_raw": true,
+	}
+)
+
+// DefaultConfigFile returns path to the default configuration file: $XDG_CONFIG_HOME/pgcenter/config.toml, or
+// ~/.config/pgcenter/config.toml if XDG_CONFIG_HOME is not set.
+func DefaultConfigFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "pgcenter", "config.toml")
+}
+
+// ReadCustomViews reads definitions of custom views from configuration file and returns views made of them.
+// Other settings of the file are ignored. Missing file is not an error, no views are returned in this case.
+func ReadCustomViews(filename string) (Views, error) {
+	if filename == "" {
+		return Views{}, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Views{}, nil
+		}
+		return nil, err
+	}
+
+	var c struct {
+		CustomViews map[string]Custom `toml:"custom_views"`
+	}
+
+	_, err = toml.Decode(string(data), &c)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s failed: %w", filename, err)
+	}
+
+	views, err := NewCustomViews(c.CustomViews)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s failed: %w", filename, err)
+	}
+
+	return views, nil
+}
+
+// NewCustomViews validates definitions of custom views and returns views made of them.
+func NewCustomViews(defs map[string]Custom) (Views, error) {
+	views := Views{}
+	for name, c := range defs {
+		err := c.Validate(name)
+		if err != nil {
+			return nil, err
+		}
+		views[name] = c.View(name)
+	}
+
+	return views, nil
+}
+
+// Validate checks definition of custom view. Columns referenced by definition are checked when the view's
+// query is described by Postgres (see View.ValidateColumns).
+func (c Custom) Validate(name string) error {
+	if !customNameRE.MatchString(name) {
+		return fmt.Errorf("invalid name of custom view '%s': only lowercase letters, digits and underscores are allowed", name)
+	}
+
+	if _, ok := New()[name]; ok || customReservedNames[name] {
+		return fmt.Errorf("invalid name of custom view '%s': name is reserved", name)
+	}
+
+	if c.Query == "" {
+		return fmt.Errorf("custom view '%s': query is not specified", name)
+	}
+
+	_, err := query.Format(c.Query, query.Options{})
+	if err != nil {
+		return fmt.Errorf("custom view '%s': invalid query template: %w", name, err)
+	}
+
+	if c.MinVersion < 0 {
+		return fmt.Errorf("custom view '%s': invalid min_version %d", name, c.MinVersion)
+	}
+
+	if len(c.DiffInterval) != 0 && (len(c.DiffInterval) != 2 || c.DiffInterval[0] < 0 || c.DiffInterval[0] > c.DiffInterval[1]) {
+		return fmt.Errorf("custom view '%s': diff_interval should contain first and last columns, e.g. [1, 5]", name)
+	}
+
+	if c.UniqueKey < 0 || c.OrderKey < 0 {
+		return fmt.Errorf("custom view '%s': unique_key and order_key should not be negative", name)
+	}
+
+	if c.Hotkey != "" {
+		r, size := utf8.DecodeRuneInString(c.Hotkey)
+		if size != len(c.Hotkey) || r > unicode.MaxASCII || !unicode.IsGraphic(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("custom view '%s': hotkey should be a single printable character", name)
+		}
+	}
+
+	return nil
+}
+
+// View returns view made of definition of custom view.
+func (c Custom) View(name string) View {
+	v := View{
+		Name:               name,
+		MinRequiredVersion: c.MinVersion,
+		QueryTmpl:          c.Query,
+		UniqueKey:          c.UniqueKey,
+		OrderKey:           c.OrderKey,
+		OrderDesc:          c.OrderDesc == nil || *c.OrderDesc,
+		ColsWidth:          map[int]int{},
+		Msg:                c.Description,
+		Filters:            map[int]*regexp.Regexp{},
+		NotRecordable:      c.Recordable != nil && !*c.Recordable,
+		Custom:             true,
+	}
+
+	if len(c.DiffInterval) == 2 {
+		v.DiffIntvl = [2]int{c.DiffInterval[0], c.DiffInterval[1]}
+	}
+
+	if v.Msg == "" {
+		v.Msg = fmt.Sprintf("Show %s statistics", name)
+	}
+
+	return v
+}
+
+// ValidateColumns checks columns referenced by the view are returned by its query, and defines number of columns.
+func (v *View) ValidateColumns(ncols int) error {
+	if ncols == 0 {
+		return fmt.Errorf("custom view '%s': query returns no columns", v.Name)
+	}
+
+	if v.DiffIntvl[1] >= ncols || v.UniqueKey >= ncols || v.OrderKey >= ncols {
+		return fmt.Errorf("custom view '%s': diff_interval, unique_key or order_key refer to column out of %d columns returned by query", v.Name, ncols)
+	}
+
+	v.Ncols = ncols
+	return nil
+}
@@ -0,0 +1,149 @@
+package view
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestDefaultConfigFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	assert.Equal(t, "/tmp/xdg/pgcenter/config.toml", DefaultConfigFile())
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	assert.Equal(t, "/home/user/.config/pgcenter/config.toml", DefaultConfigFile())
+}
+
+func TestReadCustomViews(t *testing.T) {
+	dir := t.TempDir()
+
+	// Missing file is not an error.
+	views, err := ReadCustomViews(filepath.Join(dir, "missing.toml"))
+	assert.NoError(t, err)
+	assert.Len(t, views, 0)
+
+	views, err = ReadCustomViews("")
+	assert.NoError(t, err)
+	assert.Len(t, views, 0)
+
+	testcases := []struct {
+		data  string
+		want  []string
+		valid bool
+	}{
+		{
+			// Settings of 'top' are ignored.
+			data: `view = "tables"
+refresh = 5
+
+[views.tables]
+order_column = "seq_scan"
+
+[custom_views.queue]
+query = "SELECT name, count(*) FROM queue GROUP BY name"
+
+[custom_views.partitions]
+query = "SELECT relname, n_tup_ins FROM pg_stat_user_tables"
+diff_interval = [1, 1]
+hotkey = "e"
+`,
+			want:  []string{"partitions", "queue"},
+			valid: true,
+		},
+		{data: `refresh = 5`, want: []string{}, valid: true},
+		{data: "[custom_views.queue]\nquery = 1", valid: false},
+		{data: "[custom_views.queue]\ndescription = \"no query\"", valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			filename := filepath.Join(dir, "config.toml")
+			assert.NoError(t, os.WriteFile(filename, []byte(tc.data), 0600))
+
+			got, err := ReadCustomViews(filename)
+			if tc.valid {
+				assert.NoError(t, err)
+				names := []string{}
+				for name := range got {
+					names = append(names, name)
+				}
+				assert.ElementsMatch(t, tc.want, names)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCustom_Validate(t *testing.T) {
+	testcases := []struct {
+		name  string
+		c     Custom
+		valid bool
+	}{
+		{name: "queue", c: Custom{Query: "SELECT 1"}, valid: true},
+		{name: "queue_v2", c: Custom{Query: "SELECT {{.Version}}", MinVersion: 140000, DiffInterval: []int{1, 3}, Hotkey: "e"}, valid: true},
+		{name: "Queue", c: Custom{Query: "SELECT 1"}, valid: false},
+		{name: "queue.1", c: Custom{Query: "SELECT 1"}, valid: false},
+		{name: "tables", c: Custom{Query: "SELECT 1"}, valid: false},
+		{name: "manifest", c: Custom{Query: "SELECT 1"}, valid: false},
+		{name: "queue", c: Custom{}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT {{.Unknown}}"}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", MinVersion: -1}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", DiffInterval: []int{1}}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", DiffInterval: []int{3, 1}}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", OrderKey: -1}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", Hotkey: "ee"}, valid: false},
+		{name: "queue", c: Custom{Query: "SELECT 1", Hotkey: " "}, valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.c.Validate(tc.name))
+			} else {
+				assert.Error(t, tc.c.Validate(tc.name))
+			}
+		})
+	}
+}
+
+func TestCustom_View(t *testing.T) {
+	asc, recordable := false, false
+	c := Custom{
+		Description: "Show queue", Query: "SELECT 1", MinVersion: 140000, DiffInterval: []int{2, 4},
+		UniqueKey: 1, OrderKey: 3, OrderDesc: &asc, Recordable: &recordable,
+	}
+
+	assert.Equal(t, View{
+		Name: "queue", MinRequiredVersion: 140000, QueryTmpl: "SELECT 1", DiffIntvl: [2]int{2, 4}, UniqueKey: 1,
+		OrderKey: 3, OrderDesc: false, ColsWidth: map[int]int{}, Msg: "Show queue", Filters: map[int]*regexp.Regexp{},
+		NotRecordable: true, Custom: true,
+	}, c.View("queue"))
+
+	// Defaults.
+	v := Custom{Query: "SELECT 1"}.View("queue")
+	assert.True(t, v.OrderDesc)
+	assert.False(t, v.NotRecordable)
+	assert.Equal(t, [2]int{0, 0}, v.DiffIntvl)
+	assert.Equal(t, "Show queue statistics", v.Msg)
+
+	// Custom views are configured as built-in views.
+	views := Views{"queue": Custom{Query: "SELECT {{.Version}}"}.View("queue")}
+	assert.NoError(t, views.Configure(query.Options{Version: 140000}))
+	assert.Equal(t, "SELECT 140000", views["queue"].Query)
+}
+
+func TestView_ValidateColumns(t *testing.T) {
+	v := Custom{Query: "SELECT 1", DiffInterval: []int{1, 2}, OrderKey: 2}.View("queue")
+	assert.NoError(t, v.ValidateColumns(3))
+	assert.Equal(t, 3, v.Ncols)
+
+	assert.Error(t, v.ValidateColumns(2))
+	assert.Error(t, v.ValidateColumns(0))
+}
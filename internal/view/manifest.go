@@ -0,0 +1,51 @@
+package view
+
+// Manifest describes recorded views. It is stored in the archive by 'pgcenter record' and allows to replay the
+// recorded stats regardless of views definitions of the pgcenter version used for replaying.
+type Manifest struct {
+	Version string                  `json:"version"` // Version of pgcenter used for recording
+	Views   map[string]ViewManifest `json:"views"`   // Descriptions of recorded views
+}
+
+// ViewManifest describes properties of recorded view required for replaying its stats.
+type ViewManifest struct {
+	Cols      []string `json:"cols"`
+	DiffIntvl [2]int   `json:"diff_intvl"`
+	UniqueKey int      `json:"unique_key"`
+	OrderKey  int      `json:"order_key"`
+	OrderDesc bool     `json:"order_desc"`
+	Query     string   `json:"query"`
+}
+
+// NewManifest creates manifest of passed views. Columns of views are taken from cols, because views columns are
+// not known until views queries are executed.
+func NewManifest(version string, views Views, cols map[string][]string) Manifest {
+	m := Manifest{Version: version, Views: map[string]ViewManifest{}}
+
+	for name, v := range views {
+		m.Views[name] = ViewManifest{
+			Cols:      cols[name],
+			DiffIntvl: v.DiffIntvl,
+			UniqueKey: v.UniqueKey,
+			OrderKey:  v.OrderKey,
+			OrderDesc: v.OrderDesc,
+			Query:     v.Query,
+		}
+	}
+
+	return m
+}
+
+// Apply returns view with properties taken from manifest.
+func (m ViewManifest) Apply(v View) View {
+	v.DiffIntvl = m.DiffIntvl
+	v.UniqueKey = m.UniqueKey
+	v.OrderKey = m.OrderKey
+	v.OrderDesc = m.OrderDesc
+	v.Query = m.Query
+	if len(m.Cols) > 0 {
+		v.Ncols = len(m.Cols)
+	}
+
+	return v
+}
@@ -0,0 +1,31 @@
+package view
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewManifest(t *testing.T) {
+	views := Views{
+		"statements": {Name: "statements", Query: "SELECT 1", DiffIntvl: [2]int{2, 5}, UniqueKey: 8, OrderKey: 3, OrderDesc: true},
+	}
+
+	got := NewManifest("v0.10.0", views, map[string][]string{"statements": {"a", "b"}, "meta": {"version"}})
+	assert.Equal(t, Manifest{
+		Version: "v0.10.0",
+		Views: map[string]ViewManifest{
+			"statements": {Cols: []string{"a", "b"}, DiffIntvl: [2]int{2, 5}, UniqueKey: 8, OrderKey: 3, OrderDesc: true, Query: "SELECT 1"},
+		},
+	}, got)
+}
+
+func TestViewManifest_Apply(t *testing.T) {
+	v := View{Name: "tables", Query: "SELECT 2", Ncols: 10, DiffIntvl: [2]int{1, 9}, UniqueKey: 0, OrderKey: 1}
+
+	m := ViewManifest{Cols: []string{"a", "b", "c"}, DiffIntvl: [2]int{1, 2}, UniqueKey: 2, OrderKey: 2, OrderDesc: true, Query: "SELECT 1"}
+	assert.Equal(t, View{Name: "tables", Query: "SELECT 1", Ncols: 3, DiffIntvl: [2]int{1, 2}, UniqueKey: 2, OrderKey: 2, OrderDesc: true}, m.Apply(v))
+
+	// Number of columns is kept when columns are not known.
+	m.Cols = nil
+	assert.Equal(t, 10, m.Apply(v).Ncols)
+}
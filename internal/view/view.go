@@ -3,6 +3,7 @@ package view
 import (
 	"github.com/lesovsky/pgcenter/internal/query"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -29,6 +30,7 @@ type View struct {
 	IOAvailable        bool                   // True when /proc/[pid]/io is readable; carries the capability flag to the Collector.
 	DelayAcctAvailable bool                   // True when /proc/sys/kernel/task_delayacct == "1"; enables iodelay columns in procpidstat view.
 	NotRecordable      bool                   // When true, record/record.go:filterViews() skips this view.
+	Custom             bool                   // View is defined by user in configuration file, see Custom.
 }
 
 // Views is a list of all used context units.
@@ -417,6 +419,18 @@ func (v Views) Configure(opts query.Options) error {
 	return nil
 }
 
+// Supported removes views not supported by Postgres of specified version. Statements views are supported only
+// if pg_stat_statements is installed.
+func (v Views) Supported(version int, pgssSchema string) Views {
+	for k, view := range v {
+		if !view.VersionOK(version) || (strings.HasPrefix(k, "statements_") && pgssSchema == "") {
+			delete(v, k)
+		}
+	}
+
+	return v
+}
+
 // VersionOK tests current version of Postgres is suitable for view.
 func (v View) VersionOK(version int) bool {
 	return version >= v.MinRequiredVersion
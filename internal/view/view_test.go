@@ -246,3 +246,16 @@ func TestView_VersionOK(t *testing.T) {
 		assert.Equal(t, tc.total, total)
 	}
 }
+
+func TestViews_Supported(t *testing.T) {
+	views := New().Supported(query.PostgresV13, "")
+	assert.Contains(t, views, "databases_general")
+	assert.Contains(t, views, "progress_analyze")
+	assert.NotContains(t, views, "databases_sessions")
+	assert.NotContains(t, views, "statements_timings")
+
+	views = New().Supported(query.PostgresV13, "public")
+	assert.Contains(t, views, "statements_timings")
+	assert.Contains(t, views, "statements_wal")
+	assert.NotContains(t, views, "statements_jit")
+}
@@ -3,8 +3,10 @@
 package profile
 
 import (
+	"errors"
 	"fmt"
 	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"io"
 	"os"
@@ -37,8 +39,12 @@ type Config struct {
 	Frequency time.Duration // Interval used for collecting activity statistics
 	Strsize   int           // Limit length for query string
 	NoWorkers bool          // Don't collect statistics about children parallel workers
+	Redact    bool          // Replace literals in query texts with placeholders
 }
 
+// ErrInterrupted is returned when profiling is stopped by signal.
+var ErrInterrupted = errors.New("got interrupt")
+
 // RunMain is the main entry point for 'pgcenter profile' command
 func RunMain(dbConfig postgres.Config, config Config) error {
 	// Connect to Postgres
@@ -55,6 +61,12 @@ func RunMain(dbConfig postgres.Config, config Config) error {
 	return profileLoop(os.Stdout, conn, config, doQuit)
 }
 
+// Profile profiles the backend using existing connection and prints profiling results into writer. Profiling is
+// stopped when profiled process quits or something is received from doQuit channel.
+func Profile(w io.Writer, conn *postgres.DB, config Config, doQuit chan os.Signal) error {
+	return profileLoop(w, conn, config, doQuit)
+}
+
 // waitEventsStat defines local statistics storage for single, profiled query.
 type waitEventsStat struct {
 	real        float64            // Number of seconds the query has been executed
@@ -140,7 +152,7 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 		switch {
 		case prev[pid].state != "active" && curr[pid].state == "active":
 			// !active -> active - a query has been started - begin to count stats.
-			err := printHeader(w, curr[pid], cfg.Strsize)
+			err := printHeader(w, curr[pid], cfg.Strsize, cfg.Redact)
 			if err != nil {
 				return err
 			}
@@ -179,7 +191,7 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 			if err != nil {
 				return err
 			}
-			return fmt.Errorf("got interrupt")
+			return ErrInterrupted
 		}
 	}
 }
@@ -265,8 +277,12 @@ func countWaitEvents(s waitEventsStat, targetPid int, curr map[int]profileStat,
 }
 
 // printHeader prints report header.
-func printHeader(w io.Writer, curr profileStat, strsize int) error {
-	q := truncateQuery(curr.queryText, strsize)
+func printHeader(w io.Writer, curr profileStat, strsize int, redact bool) error {
+	q := curr.queryText
+	if redact {
+		q = query.Normalize(q)
+	}
+	q = truncateQuery(q, strsize)
 
 	tmpl := `------ ------------ -----------------------------
 %% time      seconds wait_event                     query: %s
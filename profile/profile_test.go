@@ -180,8 +180,15 @@ func Test_printHeader(t *testing.T) {
 	assert.NoError(t, err)
 
 	var buf bytes.Buffer
-	assert.NoError(t, printHeader(&buf, s, 64))
+	assert.NoError(t, printHeader(&buf, s, 64, false))
 	assert.Equal(t, string(want), buf.String())
+
+	// Literals are replaced with placeholders.
+	s.queryText = "SELECT * FROM t1 WHERE f1 = 'secret'"
+	buf.Reset()
+	assert.NoError(t, printHeader(&buf, s, 64, true))
+	assert.Contains(t, buf.String(), "query: SELECT * FROM t1 WHERE f1 = $1")
+	assert.NotContains(t, buf.String(), "secret")
 }
 
 func Test_printStat(t *testing.T) {
@@ -0,0 +1,64 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CheckAnnotationKey checks the key is suitable for annotating the file: encrypted file requires its key, and not
+// encrypted file requires no key. Not existing file is not checked, it might be created later by recording.
+func CheckAnnotationKey(filename string, key stat.ArchiveKey) error {
+	if !archiveExists(filename) {
+		return nil
+	}
+
+	_, err := archiveCipher(filename, false, key)
+	return err
+}
+
+// Annotate writes operator's annotation into existing stats file. It is safe to annotate the file which is being
+// recorded, the file is locked while the annotation is written. Key is required for annotating encrypted files.
+func Annotate(filename string, text string, key stat.ArchiveKey) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("empty annotation")
+	}
+
+	_, err := os.Stat(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(stat.Annotation{Text: text})
+	if err != nil {
+		return err
+	}
+
+	cipher, err := archiveCipher(filename, false, key)
+	if err != nil {
+		return err
+	}
+
+	c := &tarRecorder{config: tarConfig{filename: filename, append: true, cipher: cipher}, fileFlags: os.O_RDWR}
+
+	err = c.open()
+	if err != nil {
+		return err
+	}
+
+	err = c.writeEntry(time.Now(), stat.AnnotationName, data)
+	if err == nil {
+		err = c.writeIndex()
+	}
+
+	if cerr := c.close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,77 @@
+package record
+
+import (
+	"archive/tar"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stats.tar")
+
+	// Annotating non-existing file or using empty text is not allowed.
+	assert.Error(t, Annotate(filename, "failover started", stat.ArchiveKey{}))
+	assert.Error(t, Annotate(filename, "  ", stat.ArchiveKey{}))
+
+	stats := map[string]stat.PGresult{
+		"pgcenter_record_testing": {
+			Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"col1"},
+			Values: [][]sql.NullString{{{String: "alfa", Valid: true}}},
+		},
+	}
+
+	tc := newTarRecorder(tarConfig{filename: filename})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	assert.NoError(t, Annotate(filename, "failover started", stat.ArchiveKey{}))
+
+	// Recording continues after annotation.
+	tc = newTarRecorder(tarConfig{filename: filename, append: true})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	f, err := os.Open(filepath.Clean(filename))
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+
+		if regexp.MustCompile(`^annotation\.`).MatchString(hdr.Name) {
+			data, err := io.ReadAll(tr)
+			assert.NoError(t, err)
+			var a stat.Annotation
+			assert.NoError(t, json.Unmarshal(data, &a))
+			assert.Equal(t, "failover started", a.Text)
+		}
+	}
+	assert.Len(t, names, 5)
+	assert.Regexp(t, `^annotation\.`, names[2])
+
+	// Annotation is indexed.
+	idx, err := os.Open(stat.IndexFilename(filename))
+	assert.NoError(t, err)
+	defer func() { _ = idx.Close() }()
+	entries, err := stat.ReadIndex(idx)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+	assert.Equal(t, names[2], entries[2].Name)
+}
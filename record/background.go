@@ -0,0 +1,149 @@
+package record
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"sync"
+	"time"
+)
+
+// Background defines recording of statistics running in background. It is used by 'pgcenter top' for
+// recording statistics without running separate 'pgcenter record'.
+type Background struct {
+	app    *app
+	stop   chan struct{}
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error // error which stopped recording
+	closed bool
+}
+
+// StartBackground starts recording of statistics into file in background. Recording continues until Stop is called.
+func StartBackground(dbConfig postgres.Config, config Config) (*Background, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid recording interval: %s", config.Interval)
+	}
+
+	app := newApp(config, dbConfig)
+	app.out = io.Discard
+	app.shared = newSharedResults()
+
+	err := app.setup()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Background{
+		app:  app,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go b.run()
+
+	return b, nil
+}
+
+// run records statistics until recording is stopped or an error occurs.
+func (b *Background) run() {
+	defer close(b.done)
+
+	t := time.NewTicker(b.app.config.Interval)
+	defer t.Stop()
+
+	for {
+		err := b.app.recordSnapshot()
+		if err != nil {
+			b.mu.Lock()
+			b.err = err
+			b.mu.Unlock()
+			return
+		}
+
+		select {
+		case <-t.C:
+			continue
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Filename returns name of the file where statistics are recorded.
+func (b *Background) Filename() string {
+	return b.app.config.OutputFile
+}
+
+// Err returns error which stopped recording, nil is returned while recording is running.
+func (b *Background) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Share passes result of the query already collected by caller at the specified time. The result is recorded in
+// the next snapshot instead of querying Postgres again, with the time of its collecting.
+func (b *Background) Share(query string, res stat.PGresult, ts time.Time) {
+	b.app.shared.put(query, res, ts)
+}
+
+// Stop stops recording and waits until the snapshot being recorded is written.
+func (b *Background) Stop() error {
+	b.mu.Lock()
+	if !b.closed {
+		close(b.stop)
+		b.closed = true
+	}
+	b.mu.Unlock()
+
+	<-b.done
+
+	return b.Err()
+}
+
+// sharedResult defines result of the query collected outside of recorder.
+type sharedResult struct {
+	res stat.PGresult
+	ts  time.Time // time when the result is collected
+}
+
+// sharedResults keeps results of queries collected outside of recorder, each result is recorded once.
+type sharedResults struct {
+	mu      sync.Mutex
+	results map[string]sharedResult
+}
+
+// newSharedResults creates new sharedResults.
+func newSharedResults() *sharedResults {
+	return &sharedResults{results: map[string]sharedResult{}}
+}
+
+// put remembers result of the query and time of its collecting, previous result of the same query is replaced.
+func (s *sharedResults) put(query string, res stat.PGresult, ts time.Time) {
+	if !res.Valid || query == "" || ts.IsZero() {
+		return
+	}
+
+	s.mu.Lock()
+	s.results[query] = sharedResult{res: res, ts: ts}
+	s.mu.Unlock()
+}
+
+// take returns and forgets result of the query. Nothing is returned when results are not shared.
+func (s *sharedResults) take(query string) (sharedResult, bool) {
+	if s == nil {
+		return sharedResult{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.results[query]
+	if ok {
+		delete(s.results, query)
+	}
+
+	return r, ok
+}
@@ -0,0 +1,76 @@
+package record
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sharedResults(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"col1"},
+		Values: [][]sql.NullString{{{String: "alfa", Valid: true}}},
+	}
+
+	// Recorder without shared results.
+	var empty *sharedResults
+	_, ok := empty.take("SELECT 1")
+	assert.False(t, ok)
+
+	ts := time.Date(2026, 5, 19, 10, 0, 0, 0, time.UTC)
+	s := newSharedResults()
+	s.put("SELECT 1", res, ts)
+	s.put("SELECT 2", stat.PGresult{}, ts) // invalid results are not shared
+	s.put("", res, ts)                     // results without query are not shared
+	s.put("SELECT 3", res, time.Time{})    // results without collecting time are not shared
+
+	got, ok := s.take("SELECT 1")
+	assert.True(t, ok)
+	assert.Equal(t, sharedResult{res: res, ts: ts}, got)
+
+	// Result is recorded once.
+	_, ok = s.take("SELECT 1")
+	assert.False(t, ok)
+
+	_, ok = s.take("SELECT 2")
+	assert.False(t, ok)
+	_, ok = s.take("SELECT 3")
+	assert.False(t, ok)
+	assert.Len(t, s.results, 0)
+}
+
+func TestStartBackground(t *testing.T) {
+	dbconfig, err := postgres.NewTestConfig()
+	assert.NoError(t, err)
+
+	_, err = StartBackground(dbconfig, Config{OutputFile: "/tmp/pgcenter-record-background.stat.tar"})
+	assert.Error(t, err)
+
+	if conn, err := postgres.NewTestConnect(); err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	filename := "/tmp/pgcenter-record-background.stat.tar"
+	b, err := StartBackground(dbconfig, Config{Interval: 100 * time.Millisecond, OutputFile: filename})
+	assert.NoError(t, err)
+	assert.Equal(t, filename, b.Filename())
+
+	time.Sleep(300 * time.Millisecond)
+	assert.NoError(t, b.Err())
+	assert.NoError(t, b.Stop())
+	assert.NoError(t, b.Stop()) // stopping twice is ok
+
+	st, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Greater(t, st.Size(), int64(0))
+
+	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
@@ -0,0 +1,56 @@
+package record
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"os"
+	"path/filepath"
+)
+
+// archiveCipher returns cipher used for encrypting entries written into the archive. Existing archive is encrypted
+// with the key it was created with. Nil is returned if the archive should not be encrypted.
+func archiveCipher(filename string, truncate bool, key stat.ArchiveKey) (*stat.ArchiveCipher, error) {
+	h, err := readArchiveEncryption(filename, truncate)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case h == nil && key.IsEmpty():
+		return nil, nil
+	case h == nil:
+		if !truncate && archiveExists(filename) {
+			return nil, fmt.Errorf("archive %s is not encrypted, encrypted stats could not be appended", filename)
+		}
+		return stat.NewArchiveCipher(key)
+	case key.IsEmpty():
+		return nil, fmt.Errorf("archive %s is encrypted, key file or passphrase is required", filename)
+	default:
+		return stat.OpenArchiveCipher(key, *h)
+	}
+}
+
+// readArchiveEncryption returns encryption header of the existing archive. Nil is returned for not existing, empty
+// and not encrypted archives, and for archives which are truncated.
+func readArchiveEncryption(filename string, truncate bool) (*stat.EncryptionHeader, error) {
+	if truncate {
+		return nil, nil
+	}
+
+	f, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return stat.ReadArchiveEncryption(f)
+}
+
+// archiveExists returns true if the archive exists and is not empty.
+func archiveExists(filename string) bool {
+	st, err := os.Stat(filepath.Clean(filename))
+	return err == nil && st.Size() > 0
+}
@@ -0,0 +1,105 @@
+package record
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_archiveCipher(t *testing.T) {
+	key := stat.ArchiveKey{Key: bytes.Repeat([]byte{1}, 32)}
+	dir := t.TempDir()
+
+	// New and truncated files are encrypted only if key is specified.
+	c, err := archiveCipher(filepath.Join(dir, "new.tar"), false, stat.ArchiveKey{})
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = archiveCipher(filepath.Join(dir, "new.tar"), true, key)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	stats := map[string]stat.PGresult{
+		"activity": {
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"pid", "query"},
+			Values: [][]sql.NullString{{{String: "123", Valid: true}, {String: "SELECT 'alice@example.org'", Valid: true}}},
+		},
+	}
+
+	plain := filepath.Join(dir, "plain.tar")
+	tc := newTarRecorder(tarConfig{filename: plain})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	encrypted := filepath.Join(dir, "encrypted.tar")
+	tc = newTarRecorder(tarConfig{filename: encrypted, cipher: c})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	// Encrypted stats could not be appended to not encrypted file, and vice versa.
+	_, err = archiveCipher(plain, false, key)
+	assert.Error(t, err)
+	_, err = archiveCipher(encrypted, false, stat.ArchiveKey{})
+	assert.Error(t, err)
+	_, err = archiveCipher(encrypted, false, stat.ArchiveKey{Key: bytes.Repeat([]byte{2}, 32)})
+	assert.Error(t, err)
+
+	// Appending to encrypted file uses the key of the file.
+	c, err = archiveCipher(encrypted, false, key)
+	assert.NoError(t, err)
+	tc = newTarRecorder(tarConfig{filename: encrypted, append: true, cipher: c})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	// Key is checked before annotating, e.g. at start of 'top'.
+	assert.NoError(t, CheckAnnotationKey(filepath.Join(dir, "missing.tar"), stat.ArchiveKey{}))
+	assert.NoError(t, CheckAnnotationKey(plain, stat.ArchiveKey{}))
+	assert.Error(t, CheckAnnotationKey(plain, key))
+	assert.Error(t, CheckAnnotationKey(encrypted, stat.ArchiveKey{}))
+	assert.NoError(t, CheckAnnotationKey(encrypted, key))
+
+	assert.Error(t, Annotate(encrypted, "failover started", stat.ArchiveKey{}))
+	assert.NoError(t, Annotate(encrypted, "failover started", key))
+
+	data, err := os.ReadFile(encrypted)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "alice@example.org")
+	assert.NotContains(t, string(data), "failover started")
+
+	// All entries are decrypted, the header is written once.
+	f, err := os.Open(encrypted)
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	r, err := stat.DecryptArchive(f, key)
+	assert.NoError(t, err)
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Len(t, names, 5) // activity and sysinfo twice, and annotation
+
+	idx, err := os.Open(stat.IndexFilename(encrypted))
+	assert.NoError(t, err)
+	defer func() { _ = idx.Close() }()
+	entries, err := stat.ReadIndex(idx)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6)
+	assert.Regexp(t, `^encryption\.`, entries[0].Name)
+}
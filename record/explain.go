@@ -0,0 +1,159 @@
+package record
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// explainStatementTimeout defines statement_timeout used for explaining statements.
+	explainStatementTimeout = 5 * time.Second
+	// explainCaptureTimeout defines total time of capturing plans, statements left unexplained when the time is
+	// over are skipped until the next capture. Capture runs within collecting stats, and should not stall it.
+	explainCaptureTimeout = 10 * time.Second
+)
+
+// explainCandidate defines statement which plan should be captured.
+type explainCandidate struct {
+	queryid  string
+	database string
+	query    string
+	execTime float64 // execution time since the previous capture, in milliseconds
+}
+
+// explainStatements captures plans of top statements by execution time spent since the previous capture. At the
+// first capture total execution time is used. Capture takes no longer than explainCaptureTimeout.
+func (c *tarRecorder) explainStatements(db *postgres.DB) (stat.PGresult, error) {
+	deadline := time.Now().Add(explainCaptureTimeout)
+
+	q, err := query.Format(query.SelectStatStatementsExecTimeQuery(c.config.pgVersion), query.Options{PGSSSchema: c.config.pgssSchema})
+	if err != nil {
+		return stat.PGresult{}, err
+	}
+
+	res, err := stat.NewPGresultQuery(db, q)
+	if err != nil {
+		return stat.PGresult{}, err
+	}
+
+	var top []explainCandidate
+	top, c.explainTimes = topStatements(res, c.explainTimes, c.config.explainTop)
+
+	plans := stat.PGresult{Valid: true, Cols: []string{"queryid", "database", "exec_time", "plan"}, Ncols: 4}
+
+	// Statements are explained in their databases, keep connections until all statements are explained.
+	conns := map[string]*postgres.DB{}
+	defer func() {
+		for _, conn := range conns {
+			if conn != nil {
+				conn.Close()
+			}
+		}
+	}()
+
+	for _, s := range top {
+		timeout := explainTimeout(deadline, time.Now())
+		if timeout <= 0 {
+			break
+		}
+
+		explain, ok := query.ExplainQuery(c.config.pgVersion, s.query)
+		if !ok {
+			continue
+		}
+
+		conn, ok := conns[s.database]
+		if !ok {
+			conn = connectDatabase(db, s.database)
+			conns[s.database] = conn
+		}
+		if conn == nil {
+			continue
+		}
+
+		_, err = conn.Exec(fmt.Sprintf("SET statement_timeout TO %d", timeout.Milliseconds()))
+		if err != nil {
+			continue
+		}
+
+		// Plans of statements which could not be explained (e.g. due to lack of privileges) are skipped.
+		plan, err := stat.ExplainPlan(conn, explain)
+		if err != nil {
+			continue
+		}
+
+		plans.Values = append(plans.Values, []sql.NullString{
+			{String: s.queryid, Valid: true},
+			{String: s.database, Valid: true},
+			{String: strconv.FormatFloat(s.execTime, 'f', 2, 64), Valid: true},
+			{String: plan, Valid: true},
+		})
+		plans.Nrows++
+	}
+
+	return plans, nil
+}
+
+// topStatements returns k statements with the highest execution time spent since the previous capture, and total
+// execution times of all statements which should be used at the next capture.
+func topStatements(res stat.PGresult, prev map[string]float64, k int) ([]explainCandidate, map[string]float64) {
+	times := make(map[string]float64, len(res.Values))
+	candidates := make([]explainCandidate, 0, len(res.Values))
+
+	for _, row := range res.Values {
+		if len(row) < 4 || !row[0].Valid || !row[3].Valid {
+			continue
+		}
+
+		total, err := strconv.ParseFloat(row[2].String, 64)
+		if err != nil {
+			continue
+		}
+		times[row[0].String] = total
+
+		// Stats might be reset since the previous capture, use total time in this case.
+		delta := total
+		if p, ok := prev[row[0].String]; ok && p <= total {
+			delta = total - p
+		}
+
+		if delta > 0 {
+			candidates = append(candidates, explainCandidate{queryid: row[0].String, database: row[1].String, query: row[3].String, execTime: delta})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].execTime > candidates[j].execTime })
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	return candidates, times
+}
+
+// explainTimeout returns statement_timeout for explaining the next statement, limited by time left until the
+// deadline of the capture. Zero (or negative) value means no time is left and explaining should be stopped.
+func explainTimeout(deadline time.Time, now time.Time) time.Duration {
+	left := deadline.Sub(now).Truncate(time.Millisecond)
+	if left < explainStatementTimeout {
+		return left
+	}
+	return explainStatementTimeout
+}
+
+// connectDatabase connects to the specified database using settings of existing connection. Password is not asked,
+// because recording runs unattended. Returns nil if connection failed.
+func connectDatabase(db *postgres.DB, dbname string) *postgres.DB {
+	conndb, err := postgres.ConnectDatabase(db, dbname)
+	if err != nil {
+		return nil
+	}
+
+	return conndb
+}
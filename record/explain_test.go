@@ -0,0 +1,46 @@
+package record
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_topStatements(t *testing.T) {
+	mkRes := func(times ...string) stat.PGresult {
+		res := stat.PGresult{Valid: true, Ncols: 4, Cols: []string{"queryid", "database", "exec_time", "query"}}
+		for i, v := range times {
+			res.Values = append(res.Values, []sql.NullString{
+				{String: string(rune('a' + i)), Valid: true}, {String: "db", Valid: true}, {String: v, Valid: true}, {String: "SELECT 1", Valid: true},
+			})
+		}
+		res.Nrows = len(res.Values)
+		return res
+	}
+
+	// First capture, total times are used.
+	top, times := topStatements(mkRes("100", "300", "200"), nil, 2)
+	assert.Equal(t, []explainCandidate{
+		{queryid: "b", database: "db", query: "SELECT 1", execTime: 300},
+		{queryid: "c", database: "db", query: "SELECT 1", execTime: 200},
+	}, top)
+	assert.Equal(t, map[string]float64{"a": 100, "b": 300, "c": 200}, times)
+
+	// Next capture, deltas are used; statement with reset stats uses total time, idle statements are skipped.
+	top, _ = topStatements(mkRes("400", "300", "50"), times, 5)
+	assert.Equal(t, []explainCandidate{
+		{queryid: "a", database: "db", query: "SELECT 1", execTime: 300},
+		{queryid: "c", database: "db", query: "SELECT 1", execTime: 50},
+	}, top)
+}
+
+func Test_explainTimeout(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, explainStatementTimeout, explainTimeout(now.Add(explainCaptureTimeout), now))
+	assert.Equal(t, 1500*time.Millisecond, explainTimeout(now.Add(1500*time.Millisecond+300*time.Microsecond), now))
+	assert.LessOrEqual(t, explainTimeout(now.Add(300*time.Microsecond), now), time.Duration(0))
+	assert.Less(t, explainTimeout(now, now.Add(time.Second)), time.Duration(0))
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/version"
 	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
 	"os"
 	"os/signal"
 	"runtime"
@@ -24,10 +26,24 @@ type Config struct {
 	OutputFile  string        // File where statistics will be saved
 	AppendFile  bool          // Append data to file
 	StringLimit int           // Limit of the length, to which query should be trimmed
+	// ExplainInterval defines interval of capturing plans of top statements, zero disables capturing
+	ExplainInterval time.Duration
+	ExplainTop      int  // Number of top statements which plans are captured
+	Redact          bool // Replace literals in query texts with placeholders
+	// Key defines key file or passphrase used for encrypting the file, file is not encrypted if empty
+	Key stat.ArchiveKey
+	// ConfigFile defines configuration file with definitions of custom views, only recordable ones are recorded
+	ConfigFile string
+	// RawProcStat enables recording of raw procpidstat values, required for reporting procpidstat with rate window
+	RawProcStat bool
 }
 
 // RunMain is the 'pgcenter record' main entry point.
 func RunMain(dbConfig postgres.Config, config Config) error {
+	if config.ExplainInterval < 0 || (config.ExplainInterval > 0 && config.ExplainTop <= 0) {
+		return fmt.Errorf("invalid plans capturing settings: interval %s, top %d", config.ExplainInterval, config.ExplainTop)
+	}
+
 	app := newApp(config, dbConfig)
 
 	err := app.setup()
@@ -51,6 +67,8 @@ type app struct {
 	dbConfig postgres.Config
 	views    view.Views
 	recorder recorder
+	out      io.Writer      // where informational messages are printed
+	shared   *sharedResults // stats already collected by 'pgcenter top', nil when recording is not started from top
 }
 
 // newApp creates new 'pgcenter record' app.
@@ -58,11 +76,18 @@ func newApp(config Config, dbConfig postgres.Config) *app {
 	return &app{
 		config:   config,
 		dbConfig: dbConfig,
+		out:      os.Stdout,
 	}
 }
 
 // setup configures necessary queries depending on Postgres version.
 func (app *app) setup() error {
+	// Read custom views before connecting, to not connect if they are defined badly.
+	custom, err := view.ReadCustomViews(app.config.ConfigFile)
+	if err != nil {
+		return err
+	}
+
 	db, err := postgres.Connect(app.dbConfig)
 	if err != nil {
 		return err
@@ -83,9 +108,18 @@ func (app *app) setup() error {
 	// Create and configure stats views depending on running Postgres.
 	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, app.config.StringLimit, props.ExtPGSSSchema)
 
-	n, views := filterViews(props.VersionNum, props.ExtPGSSSchema, view.New())
+	all := view.New()
+	for name, v := range custom {
+		all[name] = v
+	}
+
+	n, views := filterViews(props.VersionNum, props.ExtPGSSSchema, all)
 	if n > 0 {
-		fmt.Println("INFO: some statistics is not supported by the current version of Postgres and will be skipped")
+		_, _ = fmt.Fprintln(app.out, "INFO: some statistics is not supported by the current version of Postgres and will be skipped")
+	}
+
+	if props.ExtPGSSSchema == "" {
+		_, _ = fmt.Fprintln(app.out, "INFO: pg_stat_statements not found, skip recording it")
 	}
 
 	// Local/remote gate for procpidstat — runtime locality is orthogonal to
@@ -100,7 +134,7 @@ func (app *app) setup() error {
 	)
 	if !isLocal {
 		delete(views, "procpidstat")
-		fmt.Println("INFO: procpidstat skipped (remote mode: /proc not available)")
+		_, _ = fmt.Fprintln(app.out, "INFO: procpidstat skipped (remote mode: /proc not available)")
 	} else {
 		ticks, err = stat.GetSysticksLocal()
 		if err != nil {
@@ -131,8 +165,26 @@ func (app *app) setup() error {
 		return err
 	}
 
+	err = stat.ConfigureCustomViews(db, views)
+	if err != nil {
+		return err
+	}
+
 	app.views = views
 
+	// Plans are captured for statements tracked by pg_stat_statements.
+	explainInterval := app.config.ExplainInterval
+	if explainInterval > 0 && props.ExtPGSSSchema == "" {
+		_, _ = fmt.Fprintln(app.out, "INFO: pg_stat_statements not found, skip capturing plans")
+		explainInterval = 0
+	}
+
+	// Encrypt the file if requested, or if it is already encrypted.
+	cipher, err := archiveCipher(app.config.OutputFile, !app.config.AppendFile, app.config.Key)
+	if err != nil {
+		return err
+	}
+
 	// Create tar recorder.
 	app.recorder = newTarRecorder(tarConfig{
 		filename:           app.config.OutputFile,
@@ -142,6 +194,16 @@ func (app *app) setup() error {
 		cpuCount:           cpuCount,
 		ioAvailable:        ioAvailable,
 		delayAcctAvailable: delayAcctAvailable,
+		views:              views,
+		version:            pgcenterVersion(),
+		pgVersion:          props.VersionNum,
+		pgssSchema:         props.ExtPGSSSchema,
+		explainInterval:    explainInterval,
+		explainTop:         app.config.ExplainTop,
+		shared:             app.shared,
+		redact:             app.config.Redact,
+		cipher:             cipher,
+		rawProcStat:        app.config.RawProcStat,
 	})
 
 	return nil
@@ -164,22 +226,7 @@ func (app *app) record(doQuit chan os.Signal) error {
 		}
 		n++
 
-		err := app.recorder.open()
-		if err != nil {
-			return err
-		}
-
-		stats, err := app.recorder.collect(app.dbConfig, app.views)
-		if err != nil {
-			return err
-		}
-
-		err = app.recorder.write(stats)
-		if err != nil {
-			return err
-		}
-
-		err = app.recorder.close()
+		err := app.recordSnapshot()
 		if err != nil {
 			return err
 		}
@@ -196,15 +243,42 @@ func (app *app) record(doQuit chan os.Signal) error {
 	return nil
 }
 
+// recordSnapshot collects single snapshot of statistics and appends it into file.
+func (app *app) recordSnapshot() error {
+	err := app.recorder.open()
+	if err != nil {
+		return err
+	}
+
+	stats, err := app.recorder.collect(app.dbConfig, app.views)
+	if err != nil {
+		_ = app.recorder.close()
+		return err
+	}
+
+	err = app.recorder.write(stats)
+	if err != nil {
+		_ = app.recorder.close()
+		return err
+	}
+
+	return app.recorder.close()
+}
+
+// pgcenterVersion returns version of pgcenter stored in archive manifest.
+func pgcenterVersion() string {
+	_, tag, commit, branch := version.Version()
+	return fmt.Sprintf("%s %s-%s", tag, commit, branch)
+}
+
 // filterViews removes views which are not suitable for specified version and used configuration.
 func filterViews(version int, pgssSchema string, views view.Views) (int, view.Views) {
 	var filtered int
-	var pgssNotfound bool
 
 	for k, v := range views {
-		// Skip views explicitly marked as not recordable. No production view sets
-		// NotRecordable=true currently; this branch is retained for views that are
-		// only meaningful live in the TUI and is covered by a synthetic guard test.
+		// Skip views explicitly marked as not recordable. No built-in view sets
+		// NotRecordable=true currently; custom views set it when they are declared
+		// with 'recordable = false' in the configuration file.
 		if v.NotRecordable {
 			delete(views, k)
 			filtered++
@@ -221,13 +295,8 @@ func filterViews(version int, pgssSchema string, views view.Views) (int, view.Vi
 		if strings.HasPrefix(k, "statements_") && pgssSchema == "" {
 			delete(views, k)
 			filtered++
-			pgssNotfound = true
 		}
 	}
 
-	if pgssNotfound {
-		fmt.Println("INFO: pg_stat_statements not found, skip recording it")
-	}
-
 	return filtered, views
 }
@@ -213,3 +213,58 @@ func countRecordable(views view.Views) int {
 	}
 	return n
 }
+
+func TestFilterViews_Custom(t *testing.T) {
+	recordable := false
+	custom, err := view.NewCustomViews(map[string]view.Custom{
+		"queue":      {Query: "SELECT 1"},
+		"counters":   {Query: "SELECT 1", Recordable: &recordable},
+		"partitions": {Query: "SELECT 1", MinVersion: 150000},
+	})
+	assert.NoError(t, err)
+
+	n, v := filterViews(140000, "", custom)
+	assert.Equal(t, 2, n)
+	assert.Len(t, v, 1)
+	assert.Contains(t, v, "queue")
+}
+
+func Test_app_setup_custom(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.toml")
+
+	// Bad definitions are rejected before connecting to Postgres.
+	assert.NoError(t, os.WriteFile(filename, []byte("[custom_views.tables]\nquery = \"SELECT 1\"\n"), 0600))
+	app := newApp(Config{OutputFile: "/tmp/pgcenter-record-testing.stat.tar", ConfigFile: filename}, postgres.Config{})
+	assert.Error(t, app.setup())
+
+	dbconfig, err := postgres.NewTestConfig()
+	assert.NoError(t, err)
+	if db, err := postgres.Connect(dbconfig); err != nil {
+		t.Skipf("postgres is not available: %s", err)
+	} else {
+		db.Close()
+	}
+
+	data := `[custom_views.queue]
+query = "SELECT datname, xact_commit FROM pg_stat_database"
+diff_interval = [1, 1]
+
+[custom_views.not_recorded]
+query = "SELECT 1"
+recordable = false
+`
+	assert.NoError(t, os.WriteFile(filename, []byte(data), 0600))
+	app = newApp(Config{OutputFile: "/tmp/pgcenter-record-testing.stat.tar", ConfigFile: filename}, dbconfig)
+	app.out = io.Discard
+	assert.NoError(t, app.setup())
+	assert.Contains(t, app.views, "queue")
+	assert.Equal(t, 2, app.views["queue"].Ncols)
+	assert.NotContains(t, app.views, "not_recorded")
+
+	// Columns referenced by definition should be returned by query.
+	data = "[custom_views.queue]\nquery = \"SELECT 1\"\norder_key = 3\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(data), 0600))
+	app = newApp(Config{OutputFile: "/tmp/pgcenter-record-testing.stat.tar", ConfigFile: filename}, dbconfig)
+	app.out = io.Discard
+	assert.Error(t, app.setup())
+}
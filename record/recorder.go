@@ -2,6 +2,7 @@ package record
 
 import (
 	"archive/tar"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"github.com/lesovsky/pgcenter/internal/postgres"
@@ -11,8 +12,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -37,6 +40,16 @@ type tarConfig struct {
 	cpuCount           int
 	ioAvailable        bool
 	delayAcctAvailable bool
+	views              view.Views          // recorded views, described in manifest
+	version            string              // pgcenter version, stored in manifest
+	pgVersion          int                 // Postgres version
+	pgssSchema         string              // schema where pg_stat_statements is installed
+	explainInterval    time.Duration       // interval of capturing plans of top statements, zero disables capturing
+	explainTop         int                 // number of top statements which plans are captured
+	shared             *sharedResults      // stats already collected by 'pgcenter top', used instead of querying Postgres
+	redact             bool                // replace literals in query texts with placeholders
+	cipher             *stat.ArchiveCipher // cipher used for encrypting entries, nil if the archive is not encrypted
+	rawProcStat        bool                // record raw procpidstat values along with formatted ones
 }
 
 // tarRecorder implement recorder interface.
@@ -49,6 +62,8 @@ type tarRecorder struct {
 	file      *os.File
 	fileFlags int
 	writer    *tar.Writer
+	index     *os.File        // index of archive entries used for seeking
+	entries   strings.Builder // index lines of entries written in the current tick
 	// procpidstat stateful fields — zero-value safe; populated only when
 	// config.isLocal is true and the procpidstat view participates in collect().
 	prevProcPidStats map[int]stat.ProcPidStat
@@ -56,6 +71,19 @@ type tarRecorder struct {
 	prevProcPidIO    map[int]stat.ProcPidIO
 	currProcPidIO    map[int]stat.ProcPidIO
 	lastCollect      time.Time
+	// queryTexts keeps queries texts already written in the current recording
+	// session, texts are written once per queryid.
+	queryTexts map[string]string
+	// lastExplain and explainTimes keep time of the previous capture of
+	// statements plans and total execution times of statements at that time.
+	lastExplain  time.Time
+	explainTimes map[string]float64
+	// manifestWritten tells the manifest of recorded views is already written
+	// in the current recording session.
+	manifestWritten bool
+	// sharedTimes keeps collecting time of stats shared by 'pgcenter top'
+	// in the current tick, these stats are written with their own time.
+	sharedTimes map[string]time.Time
 }
 
 // newTarRecorder creates new recorder.
@@ -80,10 +108,19 @@ func (c *tarRecorder) open() error {
 		return err
 	}
 
+	// Lock the file until it is closed, it might be annotated by another process at the same time.
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
 	// Determine seek offset.
 	// If truncate is not requested check the file size. For empty files set
 	// offset to 0 - start writing from beginning. For non-empty files set
 	// offset to -1024 - start writing from last kB, to avoid overwrite tar metadata.
+	indexFlags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	empty := true
 	if (c.fileFlags & os.O_TRUNC) == 0 {
 		var offset int64
 
@@ -94,6 +131,7 @@ func (c *tarRecorder) open() error {
 
 		if st.Size() > 0 {
 			offset = -1024
+			empty = false
 		}
 
 		_, err = f.Seek(offset, io.SeekEnd)
@@ -104,10 +142,29 @@ func (c *tarRecorder) open() error {
 		// If truncate was requested, disable O_TRUNC ans use just O_RDWR to
 		// avoid further archive truncation.
 		c.fileFlags = os.O_RDWR
+		indexFlags |= os.O_TRUNC
+	}
+
+	// Index is truncated together with the archive.
+	idx, err := os.OpenFile(filepath.Clean(stat.IndexFilename(c.config.filename)), indexFlags, 0600)
+	if err != nil {
+		_ = f.Close()
+		return err
 	}
 
 	c.file = f
 	c.writer = tar.NewWriter(c.file)
+	c.index = idx
+
+	// Encrypted archive starts with the header which describes encryption.
+	if empty && c.config.cipher != nil {
+		data, err := json.Marshal(c.config.cipher.Header())
+		if err != nil {
+			return err
+		}
+
+		return c.writeEntry(time.Now(), stat.EncryptionName, data)
+	}
 
 	return nil
 }
@@ -131,8 +188,15 @@ func (c *tarRecorder) collect(dbConfig postgres.Config, views view.Views) (map[s
 
 	stats["meta"] = meta
 
-	// Collect the all necessary stats.
+	// Collect the all necessary stats. Stats already collected by 'pgcenter top' are not queried again.
+	c.sharedTimes = map[string]time.Time{}
 	for k, v := range views {
+		if r, ok := c.config.shared.take(v.Query); ok {
+			stats[k] = r.res
+			c.sharedTimes[k] = r.ts
+			continue
+		}
+
 		res, err := stat.NewPGresultQuery(db, v.Query)
 		if err != nil {
 			return nil, err
@@ -141,6 +205,20 @@ func (c *tarRecorder) collect(dbConfig postgres.Config, views view.Views) (map[s
 		stats[k] = res
 	}
 
+	// Capture plans of top statements periodically, if requested.
+	if c.config.explainInterval > 0 && time.Since(c.lastExplain) >= c.config.explainInterval {
+		// Capturing plans is optional, its failure should not stop recording.
+		plans, err := c.explainStatements(db)
+		c.lastExplain = time.Now()
+		if err != nil {
+			fmt.Printf("WARNING: capture plans of statements failed: %s, skip\n", err)
+		}
+
+		if err == nil && plans.Nrows > 0 {
+			stats[stat.PlansName] = plans
+		}
+	}
+
 	// procpidstat enrichment — replace the 7-column SQL result with the
 	// 19-column display PGresult assembled from per-PID procfs snapshots.
 	// Gated on local mode: on a remote target /proc/[pid]/* belongs to a
@@ -155,7 +233,8 @@ func (c *tarRecorder) collect(dbConfig postgres.Config, views view.Views) (map[s
 // enrichProcPidStat performs the per-tick procfs join for the procpidstat
 // view: rotates prev/curr maps based on PIDs in the current SQL result, reads
 // fresh /proc data per PID, and replaces stats["procpidstat"] with the
-// 19-column enriched PGresult produced by stat.BuildProcPidResult.
+// 19-column enriched PGresult produced by stat.BuildProcPidResult. The raw
+// (unformatted) result is stored as stats["procpidstat_raw"] if requested.
 //
 // Mirrors the map-rotation protocol in stat.Collector.Update so recorder and
 // live TUI produce equivalent display strings.
@@ -215,7 +294,7 @@ func (c *tarRecorder) enrichProcPidStat(stats map[string]stat.PGresult, activity
 	}
 	c.lastCollect = time.Now()
 
-	stats["procpidstat"] = stat.BuildProcPidResult(
+	raw := stat.BuildProcPidResultRaw(
 		activity,
 		c.prevProcPidStats, c.currProcPidStats,
 		c.prevProcPidIO, c.currProcPidIO,
@@ -225,6 +304,14 @@ func (c *tarRecorder) enrichProcPidStat(stats map[string]stat.PGresult, activity
 		itv,
 		c.config.cpuCount,
 	)
+
+	// Raw accumulated jiffies and bytes are kept in the archive only when
+	// requested, report uses them for recalculating rates over the windows
+	// longer than recording interval. They double the size of procpidstat.
+	stats["procpidstat"] = stat.FormatProcPidResultForDisplay(raw, c.config.ticks)
+	if c.config.rawProcStat {
+		stats[stat.ProcPidRawName] = raw
+	}
 }
 
 // write accepts stats data and writes it into tar archive.
@@ -233,27 +320,104 @@ func (c *tarRecorder) enrichProcPidStat(stats map[string]stat.PGresult, activity
 // written in this tick — the stats entries and the sysinfo entry — so all
 // entries from the same write() share an identical timestamp string. The
 // report-side pipeline relies on matching timestamps to pair sysinfo with the
-// per-tick procpidstat snapshot.
+// per-tick procpidstat snapshot. Stats shared by 'pgcenter top' are the
+// exception, they are written with the time of their collecting.
 func (c *tarRecorder) write(stats map[string]stat.PGresult) error {
 	now := time.Now()
 
+	// Redact query texts before anything is written.
+	if c.config.redact {
+		for name, v := range stats {
+			stats[name] = stat.RedactQueries(v)
+		}
+	}
+
+	// Write manifest of recorded views once per recording session, before the
+	// first stats. Columns of views are known only after the first collect.
+	if c.config.views != nil && !c.manifestWritten {
+		cols := make(map[string][]string, len(stats))
+		for name, v := range stats {
+			cols[name] = v.Cols
+		}
+
+		data, err := json.Marshal(view.NewManifest(c.config.version, c.config.views, cols))
+		if err != nil {
+			return err
+		}
+
+		err = c.writeEntry(now, "manifest", data)
+		if err != nil {
+			return err
+		}
+
+		c.manifestWritten = true
+	}
+
+	// Remove queries texts from statements snapshots, new texts are written
+	// once before the snapshots.
+	if c.queryTexts == nil {
+		c.queryTexts = map[string]string{}
+	}
+
+	var texts [][]sql.NullString
 	for name, v := range stats {
-		data, err := json.Marshal(v)
+		var t [][]sql.NullString
+		stats[name], t = stat.DedupQueryTexts(v, c.queryTexts)
+		texts = append(texts, t...)
+	}
+
+	if len(texts) > 0 {
+		data, err := json.Marshal(stat.NewQueryTexts(texts))
+		if err != nil {
+			return err
+		}
+
+		err = c.writeEntry(now, stat.QueryTextsName, data)
 		if err != nil {
 			return err
 		}
+	}
+
+	// Stats shared by 'pgcenter top' could be collected up to the refresh
+	// interval before the tick. They are written first, with the time of
+	// their collecting and along with the copy of metadata, hence they are
+	// read the same way as stats of the regular ticks.
+	shared := make([]string, 0, len(c.sharedTimes))
+	for name := range c.sharedTimes {
+		if _, ok := stats[name]; ok {
+			shared = append(shared, name)
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool { return c.sharedTimes[shared[i]].Before(c.sharedTimes[shared[j]]) })
+
+	var metaTs time.Time
+	for _, name := range shared {
+		ts := c.sharedTimes[name]
+		if meta, ok := stats["meta"]; ok && !ts.Equal(metaTs) {
+			err := c.writeResult(ts, "meta", meta)
+			if err != nil {
+				return err
+			}
+			metaTs = ts
+		}
 
-		hdr := &tar.Header{Name: newFilenameString(now, name), Mode: 0644, Size: int64(len(data)), ModTime: now}
-		err = c.writer.WriteHeader(hdr)
+		err := c.writeResult(ts, name, stats[name])
 		if err != nil {
 			return err
 		}
+	}
 
-		_, err = c.writer.Write(data)
+	for name, v := range stats {
+		if _, ok := c.sharedTimes[name]; ok {
+			continue
+		}
+
+		err := c.writeResult(now, name, v)
 		if err != nil {
 			return err
 		}
 	}
+	c.sharedTimes = nil
 
 	// Append the sysinfo entry. Recorded every tick so the report pipeline
 	// has the runtime constants needed to interpret procpidstat columns even
@@ -263,17 +427,69 @@ func (c *tarRecorder) write(stats map[string]stat.PGresult) error {
 	if err != nil {
 		return err
 	}
-	hdr := &tar.Header{Name: newFilenameString(now, "sysinfo"), Mode: 0644, Size: int64(len(sysinfoData)), ModTime: now}
-	if err := c.writer.WriteHeader(hdr); err != nil {
+	err = c.writeEntry(now, "sysinfo", sysinfoData)
+	if err != nil {
+		return err
+	}
+
+	// Index is written when all entries of the tick are written, hence it never points to incomplete entries.
+	return c.writeIndex()
+}
+
+// writeResult writes single stats result into tar archive.
+func (c *tarRecorder) writeResult(ts time.Time, name string, res stat.PGresult) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return c.writeEntry(ts, name, data)
+}
+
+// writeEntry writes single entry into tar archive and remembers its position for the index.
+func (c *tarRecorder) writeEntry(ts time.Time, name string, data []byte) error {
+	// Write padding of the previous entry, after that the current position is the position of the new header.
+	err := c.writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	offset, err := c.file.Seek(0, io.SeekCurrent)
+	if err != nil {
 		return err
 	}
-	if _, err := c.writer.Write(sysinfoData); err != nil {
+
+	filename := newFilenameString(ts, name)
+	if c.config.cipher != nil && name != stat.EncryptionName {
+		data = c.config.cipher.Seal(filename, data)
+	}
+
+	hdr := &tar.Header{Name: filename, Mode: 0644, Size: int64(len(data)), ModTime: ts}
+	err = c.writer.WriteHeader(hdr)
+	if err != nil {
 		return err
 	}
 
+	_, err = c.writer.Write(data)
+	if err != nil {
+		return err
+	}
+
+	c.entries.WriteString(stat.FormatIndexEntry(stat.IndexEntry{Offset: offset, Name: hdr.Name}))
 	return nil
 }
 
+// writeIndex appends positions of written entries to the index.
+func (c *tarRecorder) writeIndex() error {
+	if c.index == nil || c.entries.Len() == 0 {
+		return nil
+	}
+
+	_, err := c.index.WriteString(c.entries.String())
+	c.entries.Reset()
+	return err
+}
+
 // close closes recorder's file and tar writer descriptors.
 func (c *tarRecorder) close() error {
 	if c.writer != nil {
@@ -283,6 +499,13 @@ func (c *tarRecorder) close() error {
 		}
 	}
 
+	if c.index != nil {
+		err := c.index.Close()
+		if err != nil {
+			fmt.Printf("closing index file failed: %s, continue", err)
+		}
+	}
+
 	return c.file.Close()
 }
 
@@ -13,6 +13,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -94,6 +97,82 @@ func Test_tarRecorder_write(t *testing.T) {
 
 	// Cleanup.
 	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
+
+func Test_tarRecorder_writeQueryTexts(t *testing.T) {
+	stats := map[string]stat.PGresult{
+		"statements_general": {
+			Valid: true, Ncols: 3, Nrows: 1, Cols: []string{"calls", "queryid", "query"},
+			Values: [][]sql.NullString{{{String: "10", Valid: true}, {String: "q1", Valid: true}, {String: "SELECT 1", Valid: true}}},
+		},
+	}
+
+	filename := "/tmp/pgcenter-record-querytexts-testing.stat.tar"
+
+	tc := newTarRecorder(tarConfig{filename: filename})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	f, err := os.Open(filepath.Clean(filename))
+	assert.NoError(t, err)
+
+	// Query text is written once, snapshots don't contain texts.
+	var texts, snapshots int
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		res, err := stat.NewPGresultFile(tr, hdr.Size)
+		switch {
+		case regexp.MustCompile(`^query_texts\.`).MatchString(hdr.Name):
+			assert.NoError(t, err)
+			assert.Equal(t, "SELECT 1", res.Values[0][1].String)
+			texts++
+		case regexp.MustCompile(`^statements_general\.`).MatchString(hdr.Name):
+			assert.NoError(t, err)
+			assert.False(t, res.Values[0][2].Valid)
+			snapshots++
+		}
+	}
+	assert.NoError(t, f.Close())
+	assert.Equal(t, 1, texts)
+	assert.Equal(t, 2, snapshots)
+
+	// Cleanup.
+	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
+
+func Test_tarRecorder_writeRedact(t *testing.T) {
+	stats := map[string]stat.PGresult{
+		"activity": {
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"pid", "query"},
+			Values: [][]sql.NullString{{{String: "123", Valid: true}, {String: "SELECT * FROM users WHERE email = 'alice@example.org'", Valid: true}}},
+		},
+	}
+
+	filename := "/tmp/pgcenter-record-redact-testing.stat.tar"
+
+	tc := newTarRecorder(tarConfig{filename: filename, redact: true})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "alice@example.org")
+	assert.Contains(t, string(data), "SELECT * FROM users WHERE email = $1")
+
+	// Cleanup.
+	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
 }
 
 // TestTarRecorder_WriteSysinfo verifies write() emits a sysinfo.TIMESTAMP.json
@@ -142,6 +221,125 @@ func TestTarRecorder_WriteSysinfo(t *testing.T) {
 	assert.Equal(t, 4, got.CPUCount)
 
 	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
+
+func Test_tarRecorder_writeManifest(t *testing.T) {
+	stats := map[string]stat.PGresult{
+		"activity": {Valid: true, Ncols: 2, Nrows: 0, Cols: []string{"col1", "col2"}},
+	}
+
+	filename := "/tmp/pgcenter-record-manifest-testing.stat.tar"
+
+	views := view.Views{"activity": view.New()["activity"]}
+	tc := newTarRecorder(tarConfig{filename: filename, views: views, version: "v0.0.0"})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+
+	f, err := os.Open(filepath.Clean(filename))
+	assert.NoError(t, err)
+
+	// Manifest is written only once, before the first stats.
+	var names []string
+	var manifests int
+	var manifest view.Manifest
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+
+		if regexp.MustCompile(`^manifest\.`).MatchString(hdr.Name) {
+			data, err := io.ReadAll(tr)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(data, &manifest))
+			manifests++
+		}
+	}
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, 1, manifests)
+	assert.Regexp(t, `^manifest\.`, names[0])
+	assert.Equal(t, "v0.0.0", manifest.Version)
+	assert.Equal(t, []string{"col1", "col2"}, manifest.Views["activity"].Cols)
+	assert.Equal(t, views["activity"].UniqueKey, manifest.Views["activity"].UniqueKey)
+
+	// Cleanup.
+	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
+
+func Test_tarRecorder_writeShared(t *testing.T) {
+	meta := stat.PGresult{Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"version"}, Values: [][]sql.NullString{{{String: "17.1", Valid: true}}}}
+	res := stat.PGresult{Valid: true, Ncols: 1, Nrows: 0, Cols: []string{"col1"}}
+	stats := map[string]stat.PGresult{"meta": meta, "activity": res, "databases_general": res}
+
+	filename := "/tmp/pgcenter-record-shared-testing.stat.tar"
+	collected := time.Now().Add(-time.Second)
+
+	tc := &tarRecorder{config: tarConfig{filename: filename}, fileFlags: os.O_CREATE | os.O_RDWR | os.O_TRUNC}
+	tc.sharedTimes = map[string]time.Time{"activity": collected}
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.close())
+	assert.Nil(t, tc.sharedTimes)
+
+	f, err := os.Open(filepath.Clean(filename))
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+
+	// Shared stats are written first with the time of their collecting, along with metadata.
+	assert.Len(t, names, 5)
+	assert.Equal(t, []string{newFilenameString(collected, "meta"), newFilenameString(collected, "activity")}, names[:2])
+	// Other stats are written with the time of the tick.
+	rest := make([]string, 0, len(names)-2)
+	for _, name := range names[2:] {
+		assert.NotContains(t, name, collected.Format("20060102T150405.000"))
+		rest = append(rest, strings.Split(name, ".")[0])
+	}
+	sort.Strings(rest)
+	assert.Equal(t, []string{"databases_general", "meta", "sysinfo"}, rest)
+
+	assert.NoError(t, os.Remove(filename))
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+}
+
+func Test_tarRecorder_enrichProcPidStat(t *testing.T) {
+	activity := stat.PGresult{
+		Valid: true, Ncols: 7, Nrows: 1,
+		Cols: []string{"pid", "datname", "usename", "state", "wait_etype", "wait_event", "query"},
+		Values: [][]sql.NullString{{
+			{String: strconv.Itoa(os.Getpid()), Valid: true}, {String: "postgres", Valid: true}, {String: "postgres", Valid: true},
+			{String: "active", Valid: true}, {String: "", Valid: true}, {String: "", Valid: true}, {String: "SELECT 1", Valid: true},
+		}},
+	}
+
+	// Raw values are recorded only when requested.
+	for _, raw := range []bool{false, true} {
+		tc := &tarRecorder{config: tarConfig{isLocal: true, ticks: 100, cpuCount: 1, rawProcStat: raw}}
+		stats := map[string]stat.PGresult{"procpidstat": activity}
+		tc.enrichProcPidStat(stats, activity)
+
+		assert.Equal(t, 19, stats["procpidstat"].Ncols)
+		_, ok := stats[stat.ProcPidRawName]
+		assert.Equal(t, raw, ok)
+	}
 }
 
 func Test_newFilenameString(t *testing.T) {
@@ -0,0 +1,347 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"strconv"
+	"strings"
+)
+
+// Derive defines a report column computed from other numeric columns of the same row.
+type Derive struct {
+	Name string // Name of the derived column
+	Expr string // Expression text as specified by user
+	root exprNode
+}
+
+// ParseDerive parses derived column definition in the format 'name=expression'. Expression supports
+// numeric constants, column names, operators + - * / and parentheses. Column names which contain
+// operators, spaces or commas (e.g. "all,ms") should be double-quoted.
+func ParseDerive(s string) (Derive, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return Derive{}, fmt.Errorf("invalid derived column '%s', use format name=expression", s)
+	}
+
+	name, expr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if name == "" || expr == "" {
+		return Derive{}, fmt.Errorf("invalid derived column '%s', use format name=expression", s)
+	}
+
+	tokens, err := tokenizeExpr(expr)
+	if err != nil {
+		return Derive{}, fmt.Errorf("invalid derived column '%s': %w", name, err)
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return Derive{}, fmt.Errorf("invalid derived column '%s': %w", name, err)
+	}
+	if p.pos != len(p.tokens) {
+		return Derive{}, fmt.Errorf("invalid derived column '%s': unexpected '%s'", name, p.tokens[p.pos].text)
+	}
+
+	return Derive{Name: name, Expr: expr, root: root}, nil
+}
+
+// columns returns names of columns referenced in the expression.
+func (d Derive) columns() []string {
+	var cols []string
+	d.root.walk(func(n exprNode) {
+		if n.kind == nodeColumn {
+			cols = append(cols, n.column)
+		}
+	})
+	return cols
+}
+
+// validateColumns checks that all requested, derived and referenced columns are present in the source
+// columns of the report. Derived columns could reference source columns and derived columns defined before.
+func validateColumns(cols []string, derives []Derive, columns []string) error {
+	available := make([]string, len(cols), len(cols)+len(derives))
+	copy(available, cols)
+
+	for _, d := range derives {
+		if _, ok := getColumnIndex(available, d.Name); ok {
+			return fmt.Errorf("derived column '%s' already exists", d.Name)
+		}
+
+		for _, name := range d.columns() {
+			if _, ok := getColumnIndex(available, name); !ok {
+				return fmt.Errorf("derived column '%s': unknown column '%s', available columns: %s", d.Name, name, strings.Join(available, ", "))
+			}
+		}
+
+		available = append(available, d.Name)
+	}
+
+	for _, name := range columns {
+		if _, ok := getColumnIndex(available, name); !ok {
+			return fmt.Errorf("unknown column '%s', available columns: %s", name, strings.Join(available, ", "))
+		}
+	}
+
+	return nil
+}
+
+// appendDerived calculates derived columns for every row and appends them to the result.
+func appendDerived(res *stat.PGresult, derives []Derive) {
+	for _, d := range derives {
+		for i, row := range res.Values {
+			var value sql.NullString
+			if v, ok := d.root.eval(res.Cols, row); ok {
+				value = sql.NullString{String: strconv.FormatFloat(v, 'f', 2, 64), Valid: true}
+			}
+
+			// Use full slice expression to always allocate a new row, the row might be shared with the source snapshot.
+			res.Values[i] = append(row[:len(row):len(row)], value)
+		}
+
+		res.Cols = append(res.Cols, d.Name)
+		res.Ncols = len(res.Cols)
+	}
+}
+
+// projectColumns returns result which contains only requested columns in the requested order.
+func projectColumns(res stat.PGresult, columns []string) stat.PGresult {
+	idx := make([]int, 0, len(columns))
+	for _, name := range columns {
+		if i, ok := getColumnIndex(res.Cols, name); ok {
+			idx = append(idx, i)
+		}
+	}
+
+	values := make([][]sql.NullString, len(res.Values))
+	for i, row := range res.Values {
+		values[i] = make([]sql.NullString, len(idx))
+		for j, k := range idx {
+			values[i][j] = row[k]
+		}
+	}
+
+	cols := make([]string, len(idx))
+	for j, k := range idx {
+		cols[j] = res.Cols[k]
+	}
+
+	return stat.PGresult{
+		Values: values,
+		Cols:   cols,
+		Ncols:  len(cols),
+		Nrows:  res.Nrows,
+		Valid:  res.Valid,
+	}
+}
+
+// exprNodeKind defines kind of the expression tree node.
+type exprNodeKind int
+
+const (
+	nodeNumber exprNodeKind = iota
+	nodeColumn
+	nodeBinary
+	nodeNegate
+)
+
+// exprNode is the node of parsed expression tree.
+type exprNode struct {
+	kind   exprNodeKind
+	number float64
+	column string
+	op     byte
+	left   *exprNode
+	right  *exprNode
+}
+
+// walk calls f for the node and all its descendants.
+func (n exprNode) walk(f func(n exprNode)) {
+	f(n)
+	if n.left != nil {
+		n.left.walk(f)
+	}
+	if n.right != nil {
+		n.right.walk(f)
+	}
+}
+
+// eval evaluates expression using values of the row. Returns false if any referenced value is not a number.
+// Division by zero produces zero, which is the most convenient value for rates, e.g. time per call with no calls.
+func (n exprNode) eval(cols []string, row []sql.NullString) (float64, bool) {
+	switch n.kind {
+	case nodeNumber:
+		return n.number, true
+	case nodeColumn:
+		idx, ok := getColumnIndex(cols, n.column)
+		if !ok || idx >= len(row) {
+			return 0, false
+		}
+		v, err := strconv.ParseFloat(row[idx].String, 64)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	case nodeNegate:
+		v, ok := n.left.eval(cols, row)
+		return -v, ok
+	case nodeBinary:
+		l, ok := n.left.eval(cols, row)
+		if !ok {
+			return 0, false
+		}
+		r, ok := n.right.eval(cols, row)
+		if !ok {
+			return 0, false
+		}
+
+		switch n.op {
+		case '+':
+			return l + r, true
+		case '-':
+			return l - r, true
+		case '*':
+			return l * r, true
+		case '/':
+			if r == 0 {
+				return 0, true
+			}
+			return l / r, true
+		}
+	}
+
+	return 0, false
+}
+
+// exprToken defines lexical token of the expression.
+type exprToken struct {
+	text   string
+	op     bool // token is an operator or parenthesis
+	quoted bool // token is a double-quoted column name
+}
+
+// tokenizeExpr splits expression into tokens.
+func tokenizeExpr(s string) ([]exprToken, error) {
+	var tokens []exprToken
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("+-*/()", c) >= 0:
+			tokens = append(tokens, exprToken{text: string(c), op: true})
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted column name")
+			}
+			tokens = append(tokens, exprToken{text: s[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(s) && strings.IndexByte("+-*/() \t\"", s[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, exprToken{text: s[start:i]})
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	return tokens, nil
+}
+
+// exprParser is the recursive descent parser of arithmetic expressions.
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// peek returns the next operator token text, or empty string if next token is not an operator.
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].op {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// parseExpr parses additive expression: term { (+|-) term }.
+func (p *exprParser) parseExpr() (exprNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return exprNode{}, err
+	}
+
+	for op := p.peek(); op == "+" || op == "-"; op = p.peek() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r := left, right
+		left = exprNode{kind: nodeBinary, op: op[0], left: &l, right: &r}
+	}
+
+	return left, nil
+}
+
+// parseTerm parses multiplicative expression: factor { (*|/) factor }.
+func (p *exprParser) parseTerm() (exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return exprNode{}, err
+	}
+
+	for op := p.peek(); op == "*" || op == "/"; op = p.peek() {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r := left, right
+		left = exprNode{kind: nodeBinary, op: op[0], left: &l, right: &r}
+	}
+
+	return left, nil
+}
+
+// parseFactor parses number, column name, negation or parenthesized expression.
+func (p *exprParser) parseFactor() (exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return exprNode{}, fmt.Errorf("unexpected end of expression")
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case t.op && t.text == "-":
+		operand, err := p.parseFactor()
+		if err != nil {
+			return exprNode{}, err
+		}
+		return exprNode{kind: nodeNegate, left: &operand}, nil
+	case t.op && t.text == "(":
+		n, err := p.parseExpr()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if p.peek() != ")" {
+			return exprNode{}, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	case t.op:
+		return exprNode{}, fmt.Errorf("unexpected '%s'", t.text)
+	case !t.quoted:
+		if v, err := strconv.ParseFloat(t.text, 64); err == nil {
+			return exprNode{kind: nodeNumber, number: v}, nil
+		}
+	}
+
+	return exprNode{kind: nodeColumn, column: t.text}, nil
+}
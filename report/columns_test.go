@@ -0,0 +1,186 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDerive(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		in    string
+		name  string
+		cols  []string
+	}{
+		{valid: true, in: "ms_per_call=all_ms/calls", name: "ms_per_call", cols: []string{"all_ms", "calls"}},
+		{valid: true, in: `ms_per_call = "all,ms" / calls`, name: "ms_per_call", cols: []string{"all,ms", "calls"}},
+		{valid: true, in: "x=(a+b)*2-c", name: "x", cols: []string{"a", "b", "c"}},
+		{valid: true, in: "neg=-a", name: "neg", cols: []string{"a"}},
+		{valid: true, in: "const=100", name: "const"},
+		{valid: false, in: "noexpr"},
+		{valid: false, in: "=a/b"},
+		{valid: false, in: "x="},
+		{valid: false, in: "x=a/"},
+		{valid: false, in: "x=(a+b"},
+		{valid: false, in: "x=a b"},
+		{valid: false, in: `x="a`},
+		{valid: false, in: "x=*a"},
+	}
+
+	for _, tc := range testcases {
+		got, err := ParseDerive(tc.in)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.name, got.Name)
+			assert.Equal(t, tc.cols, got.columns())
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_exprNode_eval(t *testing.T) {
+	cols := []string{"name", "all,ms", "calls", "zero"}
+	row := []sql.NullString{
+		{String: "q1", Valid: true}, {String: "300", Valid: true}, {String: "4", Valid: true}, {String: "0", Valid: true},
+	}
+
+	testcases := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{in: `x="all,ms"/calls`, want: 75, ok: true},
+		{in: `x="all,ms"-calls*2`, want: 292, ok: true},
+		{in: `x=("all,ms"-calls)*2`, want: 592, ok: true},
+		{in: `x=-calls+10`, want: 6, ok: true},
+		{in: `x=calls/zero`, want: 0, ok: true},
+		{in: `x=name/calls`, ok: false},
+		{in: `x=unknown/calls`, ok: false},
+	}
+
+	for _, tc := range testcases {
+		d, err := ParseDerive(tc.in)
+		assert.NoError(t, err)
+		got, ok := d.root.eval(cols, row)
+		assert.Equal(t, tc.ok, ok, tc.in)
+		if tc.ok {
+			assert.InDelta(t, tc.want, got, 0.0001, tc.in)
+		}
+	}
+}
+
+func Test_validateColumns(t *testing.T) {
+	cols := []string{"calls", "all,ms", "query"}
+	d1, err := ParseDerive(`ms_per_call="all,ms"/calls`)
+	assert.NoError(t, err)
+	d2, err := ParseDerive(`us_per_call=ms_per_call*1000`)
+	assert.NoError(t, err)
+	d3, err := ParseDerive(`bad=unknown/calls`)
+	assert.NoError(t, err)
+	d4, err := ParseDerive(`calls=calls*2`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, validateColumns(cols, nil, nil))
+	assert.NoError(t, validateColumns(cols, nil, []string{"query", "calls"}))
+	assert.NoError(t, validateColumns(cols, []Derive{d1, d2}, []string{"us_per_call", "query"}))
+
+	err = validateColumns(cols, nil, []string{"calls", "total"})
+	assert.EqualError(t, err, "unknown column 'total', available columns: calls, all,ms, query")
+	assert.Error(t, validateColumns(cols, []Derive{d2, d1}, nil)) // references derived column defined later
+	assert.Error(t, validateColumns(cols, []Derive{d3}, nil))
+	assert.Error(t, validateColumns(cols, []Derive{d4}, nil))
+}
+
+func Test_appendDerived_projectColumns(t *testing.T) {
+	row := []sql.NullString{{String: "10", Valid: true}, {String: "250", Valid: true}, {String: "SELECT 1", Valid: true}}
+	res := stat.PGresult{
+		Valid: true, Ncols: 3, Nrows: 1,
+		Cols:   []string{"calls", "all,ms", "query"},
+		Values: [][]sql.NullString{row},
+	}
+
+	d, err := ParseDerive(`ms_per_call="all,ms"/calls`)
+	assert.NoError(t, err)
+
+	appendDerived(&res, []Derive{d})
+	assert.Equal(t, []string{"calls", "all,ms", "query", "ms_per_call"}, res.Cols)
+	assert.Equal(t, 4, res.Ncols)
+	assert.Equal(t, sql.NullString{String: "25.00", Valid: true}, res.Values[0][3])
+	assert.Len(t, row, 3) // source row is not modified
+
+	got := projectColumns(res, []string{"query", "ms_per_call"})
+	assert.Equal(t, stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1,
+		Cols:   []string{"query", "ms_per_call"},
+		Values: [][]sql.NullString{{{String: "SELECT 1", Valid: true}, {String: "25.00", Valid: true}}},
+	}, got)
+}
+
+func Test_processData_columns(t *testing.T) {
+	cols := []string{"queryid", "all,ms", "calls", "query"}
+	mkRes := func(rows [][]string) stat.PGresult {
+		res := stat.PGresult{Valid: true, Ncols: len(cols), Nrows: len(rows), Cols: cols}
+		for _, r := range rows {
+			row := make([]sql.NullString, len(r))
+			for i, v := range r {
+				row[i] = sql.NullString{String: v, Valid: true}
+			}
+			res.Values = append(res.Values, row)
+		}
+		return res
+	}
+
+	prev := mkRes([][]string{{"q1", "1000", "10", "SELECT 1"}, {"q2", "1000", "100", "SELECT 2"}, {"q3", "1000", "1", "UPDATE 3"}})
+	curr := mkRes([][]string{{"q1", "2000", "20", "SELECT 1"}, {"q2", "1500", "200", "SELECT 2"}, {"q3", "4000", "2", "UPDATE 3"}})
+
+	v := view.View{Name: "custom", DiffIntvl: [2]int{1, 2}, ColsWidth: map[int]int{}}
+
+	run := func(config Config) (string, error) {
+		app := newApp(config)
+		var buf bytes.Buffer
+		app.writer = &buf
+
+		dataCh := make(chan data)
+		doneCh := make(chan struct{})
+		go func() {
+			dataCh <- data{ts: time.Date(2021, 01, 01, 00, 00, 00, 0, time.UTC), res: prev, meta: metadata{version: 140000}}
+			dataCh <- data{ts: time.Date(2021, 01, 01, 00, 00, 01, 0, time.UTC), res: curr, meta: metadata{version: 140000}}
+			doneCh <- struct{}{}
+		}()
+
+		err := processData(app, v, config, dataCh, doneCh)
+		if err != nil {
+			drainData(dataCh, doneCh)
+		}
+		return stripANSI(buf.String()), err
+	}
+
+	d, err := ParseDerive(`ms_per_call="all,ms"/calls`)
+	assert.NoError(t, err)
+
+	// Order by derived column, filter by the column which is not printed.
+	got, err := run(Config{
+		ReportType: "custom", TruncLimit: 32,
+		OrderColName: "ms_per_call", OrderDesc: true,
+		FilterColName: "query", FilterRE: regexp.MustCompile("SELECT"),
+		Columns: []string{"ms_per_call", "queryid"},
+		Derive:  []Derive{d},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ms_per_call  queryid                           \n"+
+		"2021/01/01 00:00:01, rate: 1s\n"+
+		"100.00       q1\n"+
+		"5.00         q2\n", got)
+
+	// Unknown column
+	_, err = run(Config{ReportType: "custom", TruncLimit: 32, Columns: []string{"total"}})
+	assert.EqualError(t, err, "unknown column 'total', available columns: queryid, all,ms, calls, query")
+}
@@ -0,0 +1,502 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ExportOpenMetrics defines OpenMetrics text format of exported stats.
+	ExportOpenMetrics = "openmetrics"
+	// ExportInflux defines InfluxDB line protocol format of exported stats.
+	ExportInflux = "influx"
+)
+
+// exportLabelColumns defines columns which identify the object the stats belong to. These columns are
+// exported as labels (tags) instead of series.
+var exportLabelColumns = map[string]bool{
+	"datname": true, "usename": true, "appname": true, "cl_addr": true, "cl_port": true, "backend_type": true,
+	"user": true, "database": true, "client": true, "name": true, "queryid": true,
+	"relation": true, "index": true, "function": true, "funcid": true,
+	"object": true, "context": true, "slot_name": true, "slot_type": true, "source": true,
+}
+
+// exportSkipColumns defines columns which are not exported at all. Process ids are neither labels, because
+// every new backend would produce new series, nor values.
+var exportSkipColumns = map[string]bool{
+	"pid": true,
+}
+
+// exportGaugeViews defines views which diffed columns are not monotonic, these columns are exported as gauges.
+var exportGaugeViews = map[string]bool{
+	"sizes": true,
+}
+
+// exportLabel defines single label of exported row.
+type exportLabel struct {
+	name  string
+	value string
+}
+
+// exportField defines single numeric value of exported row.
+type exportField struct {
+	column  string
+	value   float64
+	counter bool
+}
+
+// exportRow defines labels and numeric values of single stats row.
+type exportRow struct {
+	labels []exportLabel
+	fields []exportField
+}
+
+// exporter defines a way of how to write exported stats.
+type exporter interface {
+	add(ts time.Time, view string, rows []exportRow) error
+	flush() error
+}
+
+// exportStats reads all stats from tar stream and writes them in the requested format. If report type is
+// specified, only its stats are exported.
+func exportStats(w io.Writer, r *tar.Reader, c Config) error {
+	var e exporter
+	switch c.Export {
+	case ExportOpenMetrics:
+		// Samples of the whole report window are spooled into temporary file until they can be written.
+		spool, err := os.CreateTemp("", "pgcenter-export-*")
+		if err != nil {
+			return fmt.Errorf("create spool file failed: %w", err)
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+		e = newOpenMetricsExporter(w, spool)
+	case ExportInflux:
+		e = newInfluxExporter(w)
+	default:
+		return fmt.Errorf("unknown export format '%s'", c.Export)
+	}
+
+	// All stats recorded at once have the same timestamp in their names. Collect them until timestamp is
+	// changed, because metadata required for configuring views might be stored after stats.
+	var tick time.Time
+	samples := map[string]stat.PGresult{}
+	version := -1
+	var views view.Views
+	var manifest view.Manifest
+
+	flush := func() error {
+		meta, ok := samples["meta"]
+		if !ok {
+			return nil
+		}
+
+		m, err := readMeta(meta)
+		if err != nil {
+			return err
+		}
+
+		if m.version != version {
+			views, err = configureArchiveViews(m.version, manifest)
+			if err != nil {
+				return err
+			}
+			version = m.version
+		}
+
+		names := make([]string, 0, len(samples))
+		for name := range samples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			v, ok := views[name]
+			if !ok {
+				continue
+			}
+
+			err := e.add(tick, name, exportRows(v, samples[name]))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("advance read position failed: %w", err)
+		}
+
+		name := strings.Split(hdr.Name, ".")[0]
+		if name == "manifest" {
+			err := flush()
+			if err != nil {
+				return err
+			}
+			tick, samples, version = time.Time{}, map[string]stat.PGresult{}, -1
+
+			manifest, err = readArchiveManifest(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "sysinfo" || name == stat.AnnotationName || (c.ReportType != "" && isFilenameOK(hdr.Name, c.ReportType) != nil) {
+			continue
+		}
+
+		ts, err := isFilenameTimestampOK(hdr.Name, c.TsStart, c.TsEnd)
+		if err != nil {
+			continue
+		}
+
+		if !ts.Equal(tick) {
+			err := flush()
+			if err != nil {
+				return err
+			}
+			tick, samples = ts, map[string]stat.PGresult{}
+		}
+
+		res, err := stat.NewPGresultFile(r, hdr.Size)
+		if err != nil {
+			return err
+		}
+		samples[name] = res
+	}
+
+	err := flush()
+	if err != nil {
+		return err
+	}
+
+	return e.flush()
+}
+
+// configureArchiveViews returns views configured for the version of Postgres and described by the archive
+// manifest. Views unknown to this version of pgcenter, e.g. custom views, are described by manifest only.
+func configureArchiveViews(version int, manifest view.Manifest) (view.Views, error) {
+	views := view.New()
+	err := views.Configure(query.Options{Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, vm := range manifest.Views {
+		if v, ok := views[name]; ok {
+			views[name] = vm.Apply(v)
+			continue
+		}
+		views[name] = vm.Apply(view.View{Name: name})
+	}
+
+	return views, nil
+}
+
+// WriteOpenMetrics writes stats of views in OpenMetrics text format. Samples are written without timestamps, as
+// expected from targets scraped by Prometheus. Stats of unknown views are skipped.
+func WriteOpenMetrics(w io.Writer, views view.Views, stats map[string]stat.PGresult) error {
+	e := newOpenMetricsExporter(w, nil)
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, ok := views[name]
+		if !ok {
+			continue
+		}
+
+		err := e.add(time.Time{}, name, exportRows(v, stats[name]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.flush()
+}
+
+// exportRows splits stats rows into labels and numeric values. Identity columns and view's unique key column
+// become labels. Columns which values could not be parsed as numbers are not exported. Values of diffed columns
+// are recorded as is, hence they are exported as counters.
+func exportRows(v view.View, res stat.PGresult) []exportRow {
+	rows := make([]exportRow, 0, len(res.Values))
+
+	for _, values := range res.Values {
+		var row exportRow
+		for i, col := range res.Cols {
+			if i >= len(values) {
+				break
+			}
+
+			if exportSkipColumns[col] {
+				continue
+			}
+
+			if i == v.UniqueKey || exportLabelColumns[col] {
+				if values[i].Valid && values[i].String != "" {
+					row.labels = append(row.labels, exportLabel{name: exportName(col), value: values[i].String})
+				}
+				continue
+			}
+
+			if !values[i].Valid {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(values[i].String, 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+
+			counter := v.DiffIntvl != [2]int{0, 0} && i >= v.DiffIntvl[0] && i <= v.DiffIntvl[1] && !exportGaugeViews[v.Name]
+			row.fields = append(row.fields, exportField{column: col, value: value, counter: counter})
+		}
+
+		if len(row.fields) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// exportName converts column or view name to a name allowed for metrics and labels: percent sign is replaced
+// with 'pct', all other characters except letters and digits are replaced with underscore.
+func exportName(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(strings.ReplaceAll(s, "%", "pct_")) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			continue
+		}
+		if n := b.Len(); n > 0 && b.String()[n-1] != '_' {
+			b.WriteByte('_')
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// openMetricsChunkSize defines size of family's samples buffered in memory before they are spilled into spool file.
+var openMetricsChunkSize = 64 * 1024
+
+// openMetricsFamily defines metric family with its samples.
+type openMetricsFamily struct {
+	typ     string
+	samples bytes.Buffer // samples not spilled yet
+	chunks  []spoolChunk // positions of spilled samples in spool file
+}
+
+// spoolChunk defines position of samples spilled into spool file.
+type spoolChunk struct {
+	offset int64
+	size   int64
+}
+
+// openMetricsExporter writes stats in OpenMetrics text format. Samples of metric family have to be written
+// together, hence all samples are accumulated and written at flush. When spool file is specified, samples are
+// spilled into the file in chunks, hence memory usage doesn't depend on the amount of exported stats.
+type openMetricsExporter struct {
+	writer    io.Writer
+	spool     *os.File
+	spoolSize int64
+	families  map[string]*openMetricsFamily
+	order     []string
+	seen      map[string]bool // samples written at the current timestamp, duplicate samples are not allowed
+	seenTs    time.Time
+}
+
+// newOpenMetricsExporter creates new OpenMetrics exporter. Spool file is optional, samples are kept in memory
+// when it is nil.
+func newOpenMetricsExporter(w io.Writer, spool *os.File) exporter {
+	return &openMetricsExporter{writer: w, spool: spool, families: map[string]*openMetricsFamily{}, seen: map[string]bool{}}
+}
+
+// add accumulates samples of passed rows. Zero timestamp means samples are written without timestamps.
+func (e *openMetricsExporter) add(ts time.Time, view string, rows []exportRow) error {
+	var timestamp string
+	if !ts.IsZero() {
+		timestamp = fmt.Sprintf(" %d.%03d", ts.Unix(), ts.Nanosecond()/int(time.Millisecond))
+	}
+
+	// Samples are added in order of their timestamps, duplicates are possible only within the same timestamp.
+	if !ts.Equal(e.seenTs) {
+		e.seen, e.seenTs = map[string]bool{}, ts
+	}
+
+	for _, row := range rows {
+		var labels string
+		if len(row.labels) > 0 {
+			s := make([]string, len(row.labels))
+			for i, l := range row.labels {
+				s[i] = fmt.Sprintf("%s=\"%s\"", l.name, openMetricsEscape(l.value))
+			}
+			labels = "{" + strings.Join(s, ",") + "}"
+		}
+
+		for _, f := range row.fields {
+			name := "pgcenter_" + exportName(view) + "_" + exportName(f.column)
+			typ := "gauge"
+			if f.counter {
+				name = strings.TrimSuffix(name, "_total")
+				typ = "counter"
+			}
+
+			family, ok := e.families[name]
+			if !ok {
+				family = &openMetricsFamily{typ: typ}
+				e.families[name] = family
+				e.order = append(e.order, name)
+			}
+
+			// Counter samples must have '_total' suffix.
+			var suffix string
+			if family.typ == "counter" {
+				suffix = "_total"
+			}
+
+			// Rows with the same labels would produce duplicate samples, which are not allowed. Don't drop them
+			// silently, the view has no labels which distinguish its rows.
+			key := name + labels
+			if e.seen[key] {
+				var at string
+				if !ts.IsZero() {
+					at = " at " + ts.Format(time.RFC3339Nano)
+				}
+				return fmt.Errorf("duplicate samples of %s%s in '%s' stats%s: rows are not distinguished by labels", name, labels, view, at)
+			}
+			e.seen[key] = true
+
+			family.samples.WriteString(fmt.Sprintf("%s%s%s %s%s\n",
+				name, suffix, labels, strconv.FormatFloat(f.value, 'f', -1, 64), timestamp,
+			))
+
+			if e.spool != nil && family.samples.Len() >= openMetricsChunkSize {
+				err := e.spill(family)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// spill writes buffered samples of the family into spool file.
+func (e *openMetricsExporter) spill(family *openMetricsFamily) error {
+	n, err := e.spool.Write(family.samples.Bytes())
+	if err != nil {
+		return fmt.Errorf("write spool file failed: %w", err)
+	}
+
+	family.chunks = append(family.chunks, spoolChunk{offset: e.spoolSize, size: int64(n)})
+	e.spoolSize += int64(n)
+	family.samples.Reset()
+
+	return nil
+}
+
+// flush writes all accumulated metric families.
+func (e *openMetricsExporter) flush() error {
+	for _, name := range e.order {
+		family := e.families[name]
+		_, err := fmt.Fprintf(e.writer, "# TYPE %s %s\n", name, family.typ)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range family.chunks {
+			_, err := io.Copy(e.writer, io.NewSectionReader(e.spool, c.offset, c.size))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = e.writer.Write(family.samples.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprint(e.writer, "# EOF\n")
+	return err
+}
+
+// openMetricsEscape escapes label value.
+func openMetricsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// influxExporter writes stats in InfluxDB line protocol. Every view is a measurement, labels are tags and
+// numeric values are fields. Field keys are named the same way as OpenMetrics metrics.
+type influxExporter struct {
+	writer io.Writer
+}
+
+// newInfluxExporter creates new Influx exporter.
+func newInfluxExporter(w io.Writer) exporter {
+	return &influxExporter{writer: w}
+}
+
+// add writes lines of passed rows.
+func (e *influxExporter) add(ts time.Time, view string, rows []exportRow) error {
+	measurement := "pgcenter_" + exportName(view)
+
+	for _, row := range rows {
+		var b strings.Builder
+		b.WriteString(measurement)
+		for _, l := range row.labels {
+			b.WriteString("," + l.name + "=" + influxEscape(l.value))
+		}
+
+		for i, f := range row.fields {
+			sep := ","
+			if i == 0 {
+				sep = " "
+			}
+			b.WriteString(sep + exportName(f.column) + "=" + strconv.FormatFloat(f.value, 'f', -1, 64))
+		}
+
+		_, err := fmt.Fprintf(e.writer, "%s %d\n", b.String(), ts.UnixNano())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flush does nothing, all lines are written immediately.
+func (e *influxExporter) flush() error {
+	return nil
+}
+
+// influxEscape escapes tag values.
+func influxEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`).Replace(s)
+}
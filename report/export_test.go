@@ -0,0 +1,207 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_exportName(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{in: "seq_scan", want: "seq_scan"},
+		{in: "all,ms", want: "all_ms"},
+		{in: "read,KiB/s", want: "read_kib_s"},
+		{in: "%all", want: "pct_all"},
+		{in: "n_tup_hot_upd,%", want: "n_tup_hot_upd_pct"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, exportName(tc.in))
+	}
+}
+
+func Test_exportRows(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 5, Nrows: 2,
+		Cols: []string{"datname", "queryid", "calls", "mean,ms", "query"},
+		Values: [][]sql.NullString{
+			{{String: "pgbench", Valid: true}, {String: "1234", Valid: true}, {String: "10", Valid: true}, {String: "1.5", Valid: true}, {String: "SELECT 1", Valid: true}},
+			{{String: "pgbench", Valid: true}, {String: "5678", Valid: true}, {String: "", Valid: false}, {String: "n/a", Valid: true}, {String: "SELECT 2", Valid: true}},
+		},
+	}
+
+	got := exportRows(view.View{Name: "custom", DiffIntvl: [2]int{2, 2}, UniqueKey: 1}, res)
+	assert.Equal(t, []exportRow{
+		{
+			labels: []exportLabel{{name: "datname", value: "pgbench"}, {name: "queryid", value: "1234"}},
+			fields: []exportField{{column: "calls", value: 10, counter: true}, {column: "mean,ms", value: 1.5}},
+		},
+	}, got)
+
+	// Process ids are not exported, neither as labels nor as values.
+	res = stat.PGresult{
+		Valid: true, Ncols: 3, Nrows: 1,
+		Cols:   []string{"pid", "datname", "calls"},
+		Values: [][]sql.NullString{{{String: "1234", Valid: true}, {String: "pgbench", Valid: true}, {String: "10", Valid: true}}},
+	}
+	got = exportRows(view.View{Name: "activity", UniqueKey: 0}, res)
+	assert.Equal(t, []exportRow{
+		{labels: []exportLabel{{name: "datname", value: "pgbench"}}, fields: []exportField{{column: "calls", value: 10}}},
+	}, got)
+}
+
+func Test_exportStats(t *testing.T) {
+	metaRes := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1,
+		Cols:   []string{"version", "version_num"},
+		Values: [][]sql.NullString{{{String: "17.1", Valid: true}, {String: "170001", Valid: true}}},
+	}
+
+	cols := []string{
+		"source", "ckpt_timed", "ckpt_req", "rstpt_timed", "rstpt_req", "rstpt_done",
+		"ckpt_write,ms", "ckpt_sync,ms", "buf_ckpt", "buf_clean", "maxwritten", "buf_alloc", "stats_age",
+	}
+	mkRes := func(bufCkpt string) stat.PGresult {
+		vals := []string{"Bgwriter", "11", "3", "5", "2", "4", "150.5", "30.5", bufCkpt, "700", "8", "3000", "02:00:00"}
+		row := make([]sql.NullString, len(vals))
+		for i, v := range vals {
+			row[i] = sql.NullString{String: v, Valid: true}
+		}
+		return stat.PGresult{Valid: true, Ncols: len(cols), Nrows: 1, Cols: cols, Values: [][]sql.NullString{row}}
+	}
+
+	mkTar := func() *tar.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		writeEntry := func(name string, v interface{}) {
+			payload, err := json.Marshal(v)
+			assert.NoError(t, err)
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(payload)), Mode: 0644}))
+			_, err = tw.Write(payload)
+			assert.NoError(t, err)
+		}
+
+		// Stats are written before metadata in the first tick.
+		writeEntry("bgwriter.20260519T100000.000.json", mkRes("1000"))
+		writeEntry("meta.20260519T100000.000.json", metaRes)
+		writeEntry("sysinfo.20260519T100000.000.json", stat.SysInfo{Ticks: 100, CPUCount: 4})
+		writeEntry("meta.20260519T100001.500.json", metaRes)
+		writeEntry("bgwriter.20260519T100001.500.json", mkRes("1500"))
+		writeEntry("sysinfo.20260519T100001.500.json", stat.SysInfo{Ticks: 100, CPUCount: 4})
+		assert.NoError(t, tw.Close())
+		return tar.NewReader(&buf)
+	}
+
+	loc := time.Now().Location()
+	ts1 := time.Date(2026, 5, 19, 10, 0, 0, 0, loc)
+	ts2 := ts1.Add(1500 * time.Millisecond)
+	config := Config{
+		ReportType: "bgwriter",
+		TsStart:    time.Date(2026, 5, 19, 0, 0, 0, 0, loc),
+		TsEnd:      time.Date(2026, 5, 19, 23, 59, 59, 0, loc),
+	}
+
+	// OpenMetrics, samples of every metric family are grouped together.
+	config.Export = ExportOpenMetrics
+	var buf bytes.Buffer
+	assert.NoError(t, exportStats(&buf, mkTar(), config))
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE pgcenter_bgwriter_ckpt_timed gauge\n"+
+		"pgcenter_bgwriter_ckpt_timed{source=\"Bgwriter\"} 11 "+formatUnixMilli(ts1)+"\n"+
+		"pgcenter_bgwriter_ckpt_timed{source=\"Bgwriter\"} 11 "+formatUnixMilli(ts2)+"\n")
+	assert.Contains(t, out, "# TYPE pgcenter_bgwriter_buf_ckpt counter\n"+
+		"pgcenter_bgwriter_buf_ckpt_total{source=\"Bgwriter\"} 1000 "+formatUnixMilli(ts1)+"\n"+
+		"pgcenter_bgwriter_buf_ckpt_total{source=\"Bgwriter\"} 1500 "+formatUnixMilli(ts2)+"\n")
+	assert.NotContains(t, out, "stats_age")
+	assert.Regexp(t, "# EOF\n$", out)
+
+	// Samples spilled into spool file are written in the same order.
+	chunkSize := openMetricsChunkSize
+	openMetricsChunkSize = 1
+	buf.Reset()
+	assert.NoError(t, exportStats(&buf, mkTar(), config))
+	openMetricsChunkSize = chunkSize
+	assert.Equal(t, out, buf.String())
+
+	// Influx line protocol.
+	config.Export = ExportInflux
+	buf.Reset()
+	assert.NoError(t, exportStats(&buf, mkTar(), config))
+	want := "pgcenter_bgwriter,source=Bgwriter ckpt_timed=11,ckpt_req=3,rstpt_timed=5,rstpt_req=2,rstpt_done=4," +
+		"ckpt_write_ms=150.5,ckpt_sync_ms=30.5,buf_ckpt=%s,buf_clean=700,maxwritten=8,buf_alloc=3000 %d\n"
+	assert.Equal(t, fmt.Sprintf(want, "1000", ts1.UnixNano())+fmt.Sprintf(want, "1500", ts2.UnixNano()), buf.String())
+
+	// Unknown format.
+	config.Export = "csv"
+	assert.Error(t, exportStats(&buf, mkTar(), config))
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	views := view.Views{
+		"custom": {Name: "custom", DiffIntvl: [2]int{1, 1}, UniqueKey: 0},
+		"single": {Name: "single", UniqueKey: -1},
+	}
+
+	stats := map[string]stat.PGresult{
+		"custom": {
+			Valid: true, Ncols: 3, Nrows: 2, Cols: []string{"name", "calls", "size"},
+			Values: [][]sql.NullString{
+				{{String: "q1", Valid: true}, {String: "10", Valid: true}, {String: "100", Valid: true}},
+				{{String: "q2", Valid: true}, {String: "20", Valid: true}, {String: "200", Valid: true}},
+			},
+		},
+		"single": {
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"load1", "load5"},
+			Values: [][]sql.NullString{{{String: "0.5", Valid: true}, {String: "0.25", Valid: true}}},
+		},
+		"unknown": {
+			Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"value"},
+			Values: [][]sql.NullString{{{String: "1", Valid: true}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteOpenMetrics(&buf, views, stats))
+	assert.Equal(t, "# TYPE pgcenter_custom_calls counter\n"+
+		"pgcenter_custom_calls_total{name=\"q1\"} 10\n"+
+		"pgcenter_custom_calls_total{name=\"q2\"} 20\n"+
+		"# TYPE pgcenter_custom_size gauge\n"+
+		"pgcenter_custom_size{name=\"q1\"} 100\n"+
+		"pgcenter_custom_size{name=\"q2\"} 200\n"+
+		"# TYPE pgcenter_single_load1 gauge\n"+
+		"pgcenter_single_load1 0.5\n"+
+		"# TYPE pgcenter_single_load5 gauge\n"+
+		"pgcenter_single_load5 0.25\n"+
+		"# EOF\n", buf.String())
+
+	// Rows which are not distinguished by labels would produce duplicate samples.
+	stats = map[string]stat.PGresult{
+		"custom": {
+			Valid: true, Ncols: 3, Nrows: 2, Cols: []string{"name", "calls", "size"},
+			Values: [][]sql.NullString{
+				{{String: "q1", Valid: true}, {String: "10", Valid: true}, {String: "100", Valid: true}},
+				{{String: "q1", Valid: true}, {String: "30", Valid: true}, {String: "300", Valid: true}},
+			},
+		},
+	}
+
+	buf.Reset()
+	err := WriteOpenMetrics(&buf, views, stats)
+	assert.EqualError(t, err, "duplicate samples of pgcenter_custom_calls{name=\"q1\"} in 'custom' stats: rows are not distinguished by labels")
+}
+
+// formatUnixMilli formats timestamp as OpenMetrics timestamp with milliseconds.
+func formatUnixMilli(ts time.Time) string {
+	return fmt.Sprintf("%d.%03d", ts.Unix(), ts.Nanosecond()/int(time.Millisecond))
+}
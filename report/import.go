@@ -0,0 +1,640 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportConfig defines settings of importing stats into Postgres.
+type ImportConfig struct {
+	InputFile string
+	Schema    string          // Schema where tables are created
+	Host      string          // Value of 'host' column, column is not created if empty
+	Key       stat.ArchiveKey // Key file or passphrase used for decrypting encrypted files
+}
+
+const (
+	// importBatchRows defines number of rows loaded by single COPY.
+	importBatchRows = 10000
+	// importTablePrefix defines prefix of tables names.
+	importTablePrefix = "pgcenter_"
+	// importRateSuffix defines suffix of columns with diffed values.
+	importRateSuffix = "_rate"
+	// importNull defines representation of NULL values in COPY data.
+	importNull = `\N`
+)
+
+// RunImport is the main entry point for 'pgcenter import' sub-command. Stats of all views are read from the
+// archive in a single pass.
+func RunImport(dbConfig postgres.Config, c ImportConfig) error {
+	f, err := os.Open(c.InputFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	// The key is checked before importing anything.
+	r, err := stat.DecryptArchive(f, c.Key)
+	if err != nil {
+		return err
+	}
+
+	db, err := postgres.Connect(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{c.Schema}.Sanitize()))
+	if err != nil {
+		return err
+	}
+
+	importers := map[string]*importer{}
+	err = readImportArchive(tar.NewReader(r), func(s snapshot) error {
+		imp, ok := importers[s.view.Name]
+		if !ok {
+			imp = newImporter(db, s.view.Name, c)
+			importers[s.view.Name] = imp
+		}
+
+		err := imp.load(s)
+		if err != nil {
+			return fmt.Errorf("import %s failed: %w", s.view.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(importers) == 0 {
+		return fmt.Errorf("no stats found in %s", c.InputFile)
+	}
+
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		imp := importers[name]
+		err := imp.flush()
+		if err != nil {
+			return fmt.Errorf("import %s failed: %w", name, err)
+		}
+
+		fmt.Printf("INFO: imported %d rows into %s", imp.total, imp.table)
+		if imp.skipped > 0 {
+			fmt.Printf(", %d rows already imported are skipped", imp.skipped)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// snapshot defines stats snapshot of single view read from the archive.
+type snapshot struct {
+	ts      time.Time
+	view    view.View     // view configured for the version of Postgres, described by archive manifest if any
+	res     stat.PGresult // stats of the view
+	version int           // version of Postgres
+	session int           // number of recording session, it is changed by every manifest written into the archive
+}
+
+// readImportArchive reads stats of all views from the archive in a single pass and passes snapshots to load
+// in order of their timestamps. Views are described by the archive manifest, hence custom views are read too;
+// views built into pgcenter are used for archives recorded without manifest.
+func readImportArchive(r *tar.Reader, load func(snapshot) error) error {
+	// All stats recorded at once have the same timestamp in their names. Collect them until timestamp is
+	// changed, because metadata required for configuring views might be stored after stats.
+	var tick time.Time
+	var manifest view.Manifest
+	var views view.Views
+	var session int
+	version := -1
+	samples := map[string]stat.PGresult{}
+	texts := map[string]string{} // queries texts recorded separately from statements snapshots
+
+	flush := func() error {
+		meta, ok := samples["meta"]
+		if !ok {
+			return nil
+		}
+
+		m, err := readMeta(meta)
+		if err != nil {
+			return err
+		}
+
+		if m.version != version {
+			views, err = configureArchiveViews(m.version, manifest)
+			if err != nil {
+				return err
+			}
+			version = m.version
+		}
+
+		names := make([]string, 0, len(samples))
+		for name := range samples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			v, ok := views[name]
+			if !ok {
+				continue
+			}
+
+			err := load(snapshot{ts: tick, view: v, res: samples[name], version: version, session: session})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("advance read position failed: %w", err)
+		}
+
+		name := strings.Split(hdr.Name, ".")[0]
+		switch name {
+		case "manifest":
+			err := flush()
+			if err != nil {
+				return err
+			}
+			tick, samples, version = time.Time{}, map[string]stat.PGresult{}, -1
+			session++
+
+			manifest, err = readArchiveManifest(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			continue
+		case stat.QueryTextsName:
+			res, err := stat.NewPGresultFile(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			stat.UpdateQueryTexts(texts, res)
+			continue
+		case "sysinfo", stat.AnnotationName, stat.PlansName, stat.ProcPidRawName:
+			// Annotations, plans and raw per-process stats are not imported.
+			continue
+		}
+
+		ts, err := isFilenameTimestampOK(hdr.Name, time.Time{}, time.Unix(math.MaxInt32, 0))
+		if err != nil {
+			continue
+		}
+
+		if !ts.Equal(tick) {
+			err := flush()
+			if err != nil {
+				return err
+			}
+			tick, samples = ts, map[string]stat.PGresult{}
+		}
+
+		res, err := stat.NewPGresultFile(r, hdr.Size)
+		if err != nil {
+			return err
+		}
+		samples[name] = stat.ResolveQueryTexts(res, texts)
+	}
+
+	return flush()
+}
+
+// importer loads stats snapshots of single view into table.
+type importer struct {
+	db      *postgres.DB
+	table   string   // sanitized name of the table
+	index   string   // sanitized name of the unique index of the table
+	stage   string   // sanitized name of temporary table used for loading data
+	host    string   // value of 'host' column
+	cols    []string // source columns of the view
+	numeric []bool   // source columns which have numeric type
+	diffed  []int    // indexes of diffed source columns
+	ukey    int      // index of the unique key column
+	view    view.View
+	prev    snapshot
+	buf     bytes.Buffer
+	writer  *csv.Writer
+	nrows   int // number of rows in the buffer
+	total   int // number of loaded rows
+	skipped int // number of rows skipped because they have been already loaded
+}
+
+// newImporter creates importer of the view's stats.
+func newImporter(db *postgres.DB, name string, c ImportConfig) *importer {
+	index := importTablePrefix + name + "_key"
+	if c.Host != "" {
+		index = importTablePrefix + name + "_host_key"
+	}
+
+	return &importer{
+		db:    db,
+		table: pgx.Identifier{c.Schema, importTablePrefix + name}.Sanitize(),
+		index: pgx.Identifier{index}.Sanitize(),
+		stage: pgx.Identifier{"pg_temp", importTablePrefix + "import_" + name}.Sanitize(),
+		host:  c.Host,
+	}
+}
+
+// load loads stats snapshot with diffed values.
+func (imp *importer) load(s snapshot) error {
+	// At startup or when version of stats is changed, configure table, and load raw values only.
+	if !imp.prev.res.Valid || imp.prev.version != s.version || imp.prev.session != s.session {
+		imp.view = s.view
+
+		err := imp.setup(s.view, s.res)
+		if err != nil {
+			return err
+		}
+
+		imp.prev = s
+		return imp.add(s.ts, s.res, nil)
+	}
+
+	itv, _ := rateInterval(s.ts.Sub(imp.prev.ts))
+	diff, err := countDiff(s.res, imp.prev.res, itv, imp.view)
+	if err != nil {
+		return err
+	}
+
+	err = imp.add(s.ts, s.res, diffRows(diff, imp.prev.res, imp.view))
+	if err != nil {
+		return err
+	}
+
+	imp.prev = s
+	return nil
+}
+
+// diffRows returns diffed rows by their unique key. Rows which are not present in the previous snapshot are not
+// diffed, and they are not returned.
+func diffRows(diff, prev stat.PGresult, v view.View) map[string][]sql.NullString {
+	if v.DiffIntvl == [2]int{0, 0} {
+		return nil
+	}
+
+	keys := make(map[string]bool, len(prev.Values))
+	for _, row := range prev.Values {
+		if v.UniqueKey < len(row) {
+			keys[row[v.UniqueKey].String] = true
+		}
+	}
+
+	rows := make(map[string][]sql.NullString, len(diff.Values))
+	for _, row := range diff.Values {
+		if v.UniqueKey < len(row) && keys[row[v.UniqueKey].String] {
+			rows[row[v.UniqueKey].String] = row
+		}
+	}
+
+	return rows
+}
+
+// setup creates table or adds missing columns using columns of the stats snapshot. Types of columns are taken
+// from the table, because the table might be created by earlier imports.
+func (imp *importer) setup(v view.View, res stat.PGresult) error {
+	err := imp.flush()
+	if err != nil {
+		return err
+	}
+
+	imp.cols = res.Cols
+	imp.ukey = v.UniqueKey
+	imp.diffed = imp.diffed[:0]
+	if v.DiffIntvl != [2]int{0, 0} {
+		for i := v.DiffIntvl[0]; i <= v.DiffIntvl[1] && i < len(res.Cols); i++ {
+			imp.diffed = append(imp.diffed, i)
+		}
+	}
+
+	for _, q := range importTableQueries(imp.table, imp.host != "", imp.cols, inferNumericColumns(res), imp.diffed) {
+		_, err := imp.db.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rows are identified by timestamp, host and unique key, hence importing the same stats again doesn't
+	// produce duplicates.
+	if imp.ukey >= 0 && imp.ukey < len(imp.cols) {
+		_, err = imp.db.Exec(importIndexQuery(imp.index, imp.table, imp.host != "", imp.cols[imp.ukey]))
+		if err != nil {
+			return fmt.Errorf("create unique index on %s failed, the table might contain duplicate rows of earlier imports: %w", imp.table, err)
+		}
+	}
+
+	types, err := imp.columnTypes()
+	if err != nil {
+		return err
+	}
+
+	imp.numeric = make([]bool, len(imp.cols))
+	for i, col := range imp.cols {
+		imp.numeric[i] = types[col] == "numeric"
+	}
+
+	return imp.createStage()
+}
+
+// columnTypes returns types of the table's columns.
+func (imp *importer) columnTypes() (map[string]string, error) {
+	rows, err := imp.db.Query("SELECT attname, format_type(atttypid, atttypmod) FROM pg_attribute "+
+		"WHERE attrelid = $1::text::regclass AND attnum > 0 AND NOT attisdropped", imp.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		var name, typ string
+		err := rows.Scan(&name, &typ)
+		if err != nil {
+			return nil, err
+		}
+		types[name] = typ
+	}
+
+	return types, rows.Err()
+}
+
+// createStage creates temporary table which data are loaded into before they are inserted into the table.
+// Structure of the temporary table follows the table, hence it is recreated when the table is changed.
+func (imp *importer) createStage() error {
+	_, err := imp.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", imp.stage))
+	if err != nil {
+		return err
+	}
+
+	_, err = imp.db.Exec(fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s)", imp.stage, imp.table))
+	return err
+}
+
+// widen changes type of numeric columns to text if the stats snapshot contains non-numeric values in these
+// columns, hence values are not lost when types inferred from earlier snapshots turn out to be wrong.
+func (imp *importer) widen(res stat.PGresult) error {
+	var cols []int
+	for i, numeric := range imp.numeric {
+		if numeric && hasTextValues(res, i) {
+			cols = append(cols, i)
+		}
+	}
+
+	if len(cols) == 0 {
+		return nil
+	}
+
+	err := imp.flush()
+	if err != nil {
+		return err
+	}
+
+	for _, i := range cols {
+		_, err := imp.db.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE text", imp.table, pgx.Identifier{imp.cols[i]}.Sanitize()))
+		if err != nil {
+			return err
+		}
+		imp.numeric[i] = false
+	}
+
+	return imp.createStage()
+}
+
+// add writes raw and diffed values of stats snapshot into the buffer, the buffer is loaded when it's full.
+func (imp *importer) add(ts time.Time, res stat.PGresult, diff map[string][]sql.NullString) error {
+	err := imp.widen(res)
+	if err != nil {
+		return err
+	}
+
+	if imp.writer == nil {
+		imp.writer = csv.NewWriter(&imp.buf)
+	}
+
+	for _, row := range res.Values {
+		var diffRow []sql.NullString
+		if imp.ukey < len(row) {
+			diffRow = diff[row[imp.ukey].String]
+		}
+
+		err := imp.writer.Write(importRecord(ts, imp.host, row, diffRow, imp.numeric, imp.diffed))
+		if err != nil {
+			return err
+		}
+		imp.nrows++
+	}
+
+	if imp.nrows >= importBatchRows {
+		return imp.flush()
+	}
+
+	return nil
+}
+
+// flush loads buffered rows into the temporary table and inserts them into the table. Rows which have been
+// already imported are skipped.
+func (imp *importer) flush() error {
+	if imp.nrows == 0 {
+		return nil
+	}
+
+	imp.writer.Flush()
+	err := imp.writer.Error()
+	if err != nil {
+		return err
+	}
+
+	_, err = imp.db.CopyFrom(&imp.buf, importCopyQuery(imp.stage, imp.host != "", imp.cols, imp.diffed))
+	if err != nil {
+		return err
+	}
+
+	// Both statements are executed in a single transaction.
+	res, err := imp.db.ExecRaw(importInsertQuery(imp.table, imp.stage, imp.host != "", imp.cols, imp.diffed) +
+		fmt.Sprintf("; TRUNCATE %s", imp.stage))
+	if err != nil {
+		return err
+	}
+
+	inserted := int(res[0].CommandTag.RowsAffected())
+	imp.total += inserted
+	imp.skipped += imp.nrows - inserted
+	imp.nrows = 0
+	imp.buf.Reset()
+
+	return nil
+}
+
+// inferNumericColumns returns which columns of the stats snapshot contain numeric values only. Columns with no
+// values are considered as text.
+func inferNumericColumns(res stat.PGresult) []bool {
+	numeric := make([]bool, len(res.Cols))
+
+	for i := range res.Cols {
+		var found bool
+		for _, row := range res.Values {
+			if i < len(row) && row[i].Valid && row[i].String != "" {
+				found = true
+				break
+			}
+		}
+
+		numeric[i] = found && !hasTextValues(res, i)
+	}
+
+	return numeric
+}
+
+// hasTextValues returns true if the column of the stats snapshot contains values which are not numbers. Empty
+// values are not considered.
+func hasTextValues(res stat.PGresult, i int) bool {
+	for _, row := range res.Values {
+		if i >= len(row) || !row[i].Valid || row[i].String == "" {
+			continue
+		}
+
+		if _, err := strconv.ParseFloat(row[i].String, 64); err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importTableQueries returns queries which create the table and add missing columns. Raw values are stored in
+// columns named after source columns, diffed values are stored in columns with '_rate' suffix.
+func importTableQueries(table string, host bool, cols []string, numeric []bool, diffed []int) []string {
+	queries := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (ts timestamptz NOT NULL)", table),
+	}
+
+	addColumn := func(name, typ string) {
+		queries = append(queries, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, pgx.Identifier{name}.Sanitize(), typ))
+	}
+
+	if host {
+		addColumn("host", "text")
+	}
+
+	for i, col := range cols {
+		typ := "text"
+		if numeric[i] {
+			typ = "numeric"
+		}
+		addColumn(col, typ)
+	}
+
+	for _, i := range diffed {
+		addColumn(cols[i]+importRateSuffix, "numeric")
+	}
+
+	return queries
+}
+
+// importIndexQuery returns query which creates unique index identifying rows by timestamp, host and unique
+// key of the view.
+func importIndexQuery(index string, table string, host bool, key string) string {
+	names := []string{"ts"}
+	if host {
+		names = append(names, "host")
+	}
+	names = append(names, pgx.Identifier{key}.Sanitize())
+
+	return fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)", index, table, strings.Join(names, ", "))
+}
+
+// importColumns returns sanitized names of columns which data are loaded into.
+func importColumns(host bool, cols []string, diffed []int) string {
+	names := []string{"ts"}
+	if host {
+		names = append(names, "host")
+	}
+	for _, col := range cols {
+		names = append(names, pgx.Identifier{col}.Sanitize())
+	}
+	for _, i := range diffed {
+		names = append(names, pgx.Identifier{cols[i] + importRateSuffix}.Sanitize())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// importCopyQuery returns COPY query for loading data into the table.
+func importCopyQuery(table string, host bool, cols []string, diffed []int) string {
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN (FORMAT csv, NULL '%s')", table, importColumns(host, cols, diffed), importNull)
+}
+
+// importInsertQuery returns query which inserts loaded data into the table, rows which already exist are skipped.
+func importInsertQuery(table string, stage string, host bool, cols []string, diffed []int) string {
+	names := importColumns(host, cols, diffed)
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING", table, names, names, stage)
+}
+
+// importRecord returns COPY record of the stats row. Values which are not numbers are loaded into numeric
+// columns as NULLs. Diffed values are NULLs if the row is not diffed.
+func importRecord(ts time.Time, host string, row, diff []sql.NullString, numeric []bool, diffed []int) []string {
+	value := func(v sql.NullString, numeric bool) string {
+		if !v.Valid {
+			return importNull
+		}
+		if numeric {
+			if _, err := strconv.ParseFloat(v.String, 64); err != nil {
+				return importNull
+			}
+		}
+		return v.String
+	}
+
+	record := []string{ts.Format(time.RFC3339Nano)}
+	if host != "" {
+		record = append(record, host)
+	}
+
+	for i := range numeric {
+		if i < len(row) {
+			record = append(record, value(row[i], numeric[i]))
+		} else {
+			record = append(record, importNull)
+		}
+	}
+
+	for _, i := range diffed {
+		if i < len(diff) {
+			record = append(record, value(diff[i], true))
+		} else {
+			record = append(record, importNull)
+		}
+	}
+
+	return record
+}
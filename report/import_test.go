@@ -0,0 +1,197 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readImportArchive(t *testing.T) {
+	mkRes := func(cols ...string) stat.PGresult {
+		row := make([]sql.NullString, len(cols))
+		for i := range cols {
+			row[i] = sql.NullString{String: "1", Valid: true}
+		}
+		return stat.PGresult{Valid: true, Ncols: len(cols), Nrows: 1, Cols: cols, Values: [][]sql.NullString{row}}
+	}
+	metaRes := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1,
+		Cols:   []string{"version", "version_num"},
+		Values: [][]sql.NullString{{{String: "17.1", Valid: true}, {String: "170001", Valid: true}}},
+	}
+	manifest := view.Manifest{Version: "test", Views: map[string]view.ViewManifest{
+		"queue": {Cols: []string{"name", "calls"}, DiffIntvl: [2]int{1, 1}},
+	}}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	writeEntry := func(name string, v interface{}) {
+		payload, err := json.Marshal(v)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(payload)), Mode: 0644}))
+		_, err = tw.Write(payload)
+		assert.NoError(t, err)
+	}
+
+	// Stats are written before metadata in the first tick.
+	writeEntry("manifest.20260519T100000.000.json", manifest)
+	writeEntry("queue.20260519T100000.000.json", mkRes("name", "calls"))
+	writeEntry("tables.20260519T100000.000.json", mkRes("relation", "seq_scan"))
+	writeEntry("meta.20260519T100000.000.json", metaRes)
+	writeEntry("sysinfo.20260519T100000.000.json", stat.SysInfo{Ticks: 100, CPUCount: 4})
+	writeEntry("annotation.20260519T100000.500.json", stat.Annotation{Text: "note"})
+	writeEntry("meta.20260519T100001.000.json", metaRes)
+	writeEntry("queue.20260519T100001.000.json", mkRes("name", "calls"))
+	writeEntry("unknown.20260519T100001.000.json", mkRes("value"))
+	// The next recording session.
+	writeEntry("manifest.20260519T100002.000.json", manifest)
+	writeEntry("meta.20260519T100002.000.json", metaRes)
+	writeEntry("queue.20260519T100002.000.json", mkRes("name", "calls"))
+	assert.NoError(t, tw.Close())
+
+	type call struct {
+		ts      string
+		view    string
+		session int
+	}
+	var got []call
+	err := readImportArchive(tar.NewReader(&buf), func(s snapshot) error {
+		got = append(got, call{ts: s.ts.Format("15:04:05"), view: s.view.Name, session: s.session})
+		assert.Equal(t, 170001, s.version)
+		if s.view.Name == "queue" {
+			assert.Equal(t, [2]int{1, 1}, s.view.DiffIntvl)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []call{
+		{ts: "10:00:00", view: "queue", session: 1},
+		{ts: "10:00:00", view: "tables", session: 1},
+		{ts: "10:00:01", view: "queue", session: 1},
+		{ts: "10:00:02", view: "queue", session: 2},
+	}, got)
+}
+
+func Test_inferNumericColumns(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 4, Nrows: 2,
+		Cols: []string{"relation", "seq_scan", "mean,ms", "empty"},
+		Values: [][]sql.NullString{
+			{{String: "public.t1", Valid: true}, {String: "10", Valid: true}, {String: "1.5", Valid: true}, {String: "", Valid: true}},
+			{{String: "public.t2", Valid: true}, {String: "", Valid: false}, {String: "2", Valid: true}, {String: "", Valid: false}},
+		},
+	}
+
+	assert.Equal(t, []bool{false, true, true, false}, inferNumericColumns(res))
+}
+
+func Test_importTableQueries(t *testing.T) {
+	got := importTableQueries(`"public"."pgcenter_tables"`, true, []string{"relation", "seq_scan", "all,ms"}, []bool{false, true, true}, []int{1, 2})
+	assert.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."pgcenter_tables" (ts timestamptz NOT NULL)`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "host" text`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "relation" text`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "seq_scan" numeric`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "all,ms" numeric`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "seq_scan_rate" numeric`,
+		`ALTER TABLE "public"."pgcenter_tables" ADD COLUMN IF NOT EXISTS "all,ms_rate" numeric`,
+	}, got)
+
+	got = importTableQueries(`"public"."pgcenter_activity"`, false, []string{"pid"}, []bool{true}, nil)
+	assert.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."pgcenter_activity" (ts timestamptz NOT NULL)`,
+		`ALTER TABLE "public"."pgcenter_activity" ADD COLUMN IF NOT EXISTS "pid" numeric`,
+	}, got)
+}
+
+func Test_importIndexQuery(t *testing.T) {
+	assert.Equal(t,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "pgcenter_tables_host_key" ON "public"."pgcenter_tables" (ts, host, "relation")`,
+		importIndexQuery(`"pgcenter_tables_host_key"`, `"public"."pgcenter_tables"`, true, "relation"),
+	)
+	assert.Equal(t,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "pgcenter_activity_key" ON "public"."pgcenter_activity" (ts, "pid")`,
+		importIndexQuery(`"pgcenter_activity_key"`, `"public"."pgcenter_activity"`, false, "pid"),
+	)
+}
+
+func Test_importInsertQuery(t *testing.T) {
+	assert.Equal(t,
+		`INSERT INTO "public"."pgcenter_tables" (ts, host, "relation", "seq_scan", "seq_scan_rate") `+
+			`SELECT ts, host, "relation", "seq_scan", "seq_scan_rate" FROM "pg_temp"."pgcenter_import_tables" ON CONFLICT DO NOTHING`,
+		importInsertQuery(`"public"."pgcenter_tables"`, `"pg_temp"."pgcenter_import_tables"`, true, []string{"relation", "seq_scan"}, []int{1}),
+	)
+}
+
+func Test_hasTextValues(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 2,
+		Cols: []string{"a", "b"},
+		Values: [][]sql.NullString{
+			{{String: "10", Valid: true}, {String: "", Valid: false}},
+			{{String: "idle", Valid: true}, {String: "", Valid: true}},
+		},
+	}
+
+	assert.True(t, hasTextValues(res, 0))
+	assert.False(t, hasTextValues(res, 1))
+}
+
+func Test_importCopyQuery(t *testing.T) {
+	assert.Equal(t,
+		`COPY "public"."pgcenter_tables" (ts, host, "relation", "seq_scan", "seq_scan_rate") FROM STDIN (FORMAT csv, NULL '\N')`,
+		importCopyQuery(`"public"."pgcenter_tables"`, true, []string{"relation", "seq_scan"}, []int{1}),
+	)
+	assert.Equal(t,
+		`COPY "public"."pgcenter_activity" (ts, "pid") FROM STDIN (FORMAT csv, NULL '\N')`,
+		importCopyQuery(`"public"."pgcenter_activity"`, false, []string{"pid"}, nil),
+	)
+}
+
+func Test_importRecord(t *testing.T) {
+	ts := time.Date(2021, 01, 01, 10, 00, 00, 0, time.UTC)
+	row := []sql.NullString{{String: "public.t1", Valid: true}, {String: "10", Valid: true}, {String: "n/a", Valid: true}, {String: "", Valid: false}}
+	diff := []sql.NullString{{String: "public.t1", Valid: true}, {String: "5", Valid: true}, {String: "n/a", Valid: true}, {String: "", Valid: false}}
+	numeric := []bool{false, true, true, false}
+
+	assert.Equal(t,
+		[]string{"2021-01-01T10:00:00Z", "db1", "public.t1", "10", `\N`, `\N`, "5", `\N`},
+		importRecord(ts, "db1", row, diff, numeric, []int{1, 2}),
+	)
+	assert.Equal(t,
+		[]string{"2021-01-01T10:00:00Z", "public.t1", "10", `\N`, `\N`, `\N`},
+		importRecord(ts, "", row, nil, numeric, []int{1}),
+	)
+}
+
+func Test_diffRows(t *testing.T) {
+	mkRes := func(rows ...[]string) stat.PGresult {
+		res := stat.PGresult{Valid: true, Ncols: 2, Nrows: len(rows), Cols: []string{"relation", "seq_scan"}}
+		for _, r := range rows {
+			res.Values = append(res.Values, []sql.NullString{{String: r[0], Valid: true}, {String: r[1], Valid: true}})
+		}
+		return res
+	}
+
+	v := view.View{DiffIntvl: [2]int{1, 1}}
+	prev := mkRes([]string{"t1", "10"})
+	curr := mkRes([]string{"t1", "15"}, []string{"t2", "100"})
+
+	diff, err := countDiff(curr, prev, 1, v)
+	assert.NoError(t, err)
+
+	// Row t2 is absent in previous snapshot, it is not diffed.
+	got := diffRows(diff, prev, v)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "5", got["t1"][1].String)
+
+	// Views with no diffed columns.
+	assert.Nil(t, diffRows(diff, prev, view.View{}))
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+)
+
+// archiveReader returns reader of the archive. If the archive has an index, only entries accepted by wanted are read.
+func archiveReader(f *os.File, filename string, wanted func(name string) bool) io.Reader {
+	if r := indexedReader(f, filename, wanted); r != nil {
+		return r
+	}
+
+	return f
+}
+
+// indexedReader returns tar stream which contains only archive entries accepted by wanted. Entries are located
+// using archive's index. Returns nil if the archive has no index, or the index doesn't match the archive (e.g.
+// the archive has been appended by a pgcenter version which doesn't maintain index). In this case the whole
+// archive has to be read.
+func indexedReader(f *os.File, filename string, wanted func(name string) bool) io.Reader {
+	idx, err := os.Open(filepath.Clean(stat.IndexFilename(filename)))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = idx.Close() }()
+
+	entries, err := stat.ReadIndex(idx)
+	if err != nil || len(entries) == 0 || entries[0].Offset != 0 {
+		return nil
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+	size := st.Size()
+
+	if !isIndexEntryOK(f, entries[0], size, false) || !isIndexEntryOK(f, entries[len(entries)-1], size, true) {
+		return nil
+	}
+
+	var readers []io.Reader
+	for i, e := range entries {
+		// Header of encrypted archive is always required for decrypting other entries.
+		if !wanted(e.Name) && !strings.HasPrefix(e.Name, stat.EncryptionName+".") {
+			continue
+		}
+
+		end := size
+		if i+1 < len(entries) {
+			end = entries[i+1].Offset
+		}
+
+		readers = append(readers, io.NewSectionReader(f, e.Offset, end-e.Offset))
+	}
+
+	return io.MultiReader(readers...)
+}
+
+// isIndexEntryOK checks the archive has the entry at the indexed offset. If last is true, it also checks the
+// entry is the last one in the archive.
+func isIndexEntryOK(f *os.File, e stat.IndexEntry, size int64, last bool) bool {
+	if e.Offset >= size {
+		return false
+	}
+
+	r := tar.NewReader(io.NewSectionReader(f, e.Offset, size-e.Offset))
+	hdr, err := r.Next()
+	if err != nil || hdr.Name != e.Name {
+		return false
+	}
+
+	if last {
+		_, err = r.Next()
+		return err == io.EOF
+	}
+
+	return true
+}
+
+// reportEntryWanted returns function which accepts archive entries required for building the report.
+func reportEntryWanted(c Config) func(name string) bool {
+	report := reportEntryName(c)
+
+	return func(name string) bool {
+		if isFilenameOK(name, report) != nil {
+			return false
+		}
+
+		if strings.HasPrefix(name, "manifest.") || strings.HasPrefix(name, stat.QueryTextsName+".") {
+			return true
+		}
+
+		_, err := isFilenameTimestampOK(name, c.TsStart, c.TsEnd)
+		return err == nil
+	}
+}
+
+// exportEntryWanted returns function which accepts archive entries required for exporting stats.
+func exportEntryWanted(c Config) func(name string) bool {
+	return func(name string) bool {
+		if strings.HasPrefix(name, "manifest.") {
+			return true
+		}
+
+		if strings.HasPrefix(name, "sysinfo.") || strings.HasPrefix(name, stat.AnnotationName+".") || (c.ReportType != "" && isFilenameOK(name, c.ReportType) != nil) {
+			return false
+		}
+
+		_, err := isFilenameTimestampOK(name, c.TsStart, c.TsEnd)
+		return err == nil
+	}
+}
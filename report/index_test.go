@@ -0,0 +1,110 @@
+package report
+
+import (
+	"archive/tar"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeIndexedArchive writes archive with stats of 'custom' view recorded every second, and its index.
+func writeIndexedArchive(t *testing.T, filename string, ticks int) {
+	metaRes := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1,
+		Cols:   []string{"version", "version_num"},
+		Values: [][]sql.NullString{{{String: "17.1", Valid: true}, {String: "170001", Valid: true}}},
+	}
+
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+	idx, err := os.Create(stat.IndexFilename(filename))
+	assert.NoError(t, err)
+
+	tw := tar.NewWriter(f)
+	writeEntry := func(name string, v interface{}) {
+		payload, err := json.Marshal(v)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.Flush())
+		offset, err := f.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(payload)), Mode: 0644}))
+		_, err = tw.Write(payload)
+		assert.NoError(t, err)
+		_, err = idx.WriteString(stat.FormatIndexEntry(stat.IndexEntry{Offset: offset, Name: name}))
+		assert.NoError(t, err)
+	}
+
+	ts := time.Date(2026, 5, 19, 10, 0, 0, 0, time.Now().Location())
+	for i := 0; i < ticks; i++ {
+		suffix := ts.Add(time.Duration(i)*time.Second).Format("20060102T150405.000") + ".json"
+		writeEntry("meta."+suffix, metaRes)
+		writeEntry("other."+suffix, metaRes)
+		writeEntry("custom."+suffix, stat.PGresult{
+			Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"calls"},
+			Values: [][]sql.NullString{{{String: "1", Valid: true}}},
+		})
+	}
+
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+	assert.NoError(t, idx.Close())
+}
+
+func Test_indexedReader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stats.tar")
+	writeIndexedArchive(t, filename, 10)
+
+	loc := time.Now().Location()
+	config := Config{
+		ReportType: "custom",
+		TsStart:    time.Date(2026, 5, 19, 10, 0, 3, 0, loc),
+		TsEnd:      time.Date(2026, 5, 19, 10, 0, 5, 0, loc),
+	}
+
+	readNames := func() []string {
+		f, err := os.Open(filename)
+		assert.NoError(t, err)
+		defer func() { _ = f.Close() }()
+
+		var names []string
+		tr := tar.NewReader(archiveReader(f, filename, reportEntryWanted(config)))
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			names = append(names, hdr.Name)
+		}
+		return names
+	}
+
+	// Only entries of the requested report and interval are read.
+	assert.Equal(t, []string{
+		"meta.20260519T100003.000.json", "custom.20260519T100003.000.json",
+		"meta.20260519T100004.000.json", "custom.20260519T100004.000.json",
+		"meta.20260519T100005.000.json", "custom.20260519T100005.000.json",
+	}, readNames())
+
+	// Index doesn't cover entries appended after it, the whole archive is read.
+	f, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	assert.NoError(t, err)
+	_, err = f.Seek(-1024, io.SeekEnd)
+	assert.NoError(t, err)
+	tw := tar.NewWriter(f)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "custom.20260519T100011.000.json", Size: 0, Mode: 0644}))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+	assert.Len(t, readNames(), 31)
+
+	// No index, the whole archive is read.
+	assert.NoError(t, os.Remove(stat.IndexFilename(filename)))
+	assert.Len(t, readNames(), 31)
+}
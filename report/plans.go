@@ -0,0 +1,99 @@
+package report
+
+import (
+	"archive/tar"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"strings"
+)
+
+// printPlans reads plans captured for the statement with specified queryid and prints them.
+func printPlans(w io.Writer, r *tar.Reader, c Config) error {
+	texts := map[string]string{}
+	var printed int
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("advance read position failed: %w", err)
+		}
+
+		name := strings.Split(hdr.Name, ".")[0]
+		if name != stat.PlansName && name != stat.QueryTextsName {
+			continue
+		}
+
+		// Query texts are used for printing the text of the statement, read them regardless of requested interval.
+		if name == stat.QueryTextsName {
+			res, err := stat.NewPGresultFile(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			stat.UpdateQueryTexts(texts, res)
+			continue
+		}
+
+		ts, err := isFilenameTimestampOK(hdr.Name, c.TsStart, c.TsEnd)
+		if err != nil {
+			continue
+		}
+
+		res, err := stat.NewPGresultFile(r, hdr.Size)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range res.Values {
+			if len(row) < 4 || row[0].String != c.Plans {
+				continue
+			}
+
+			if printed == 0 {
+				if text, ok := texts[c.Plans]; ok {
+					if c.Redact {
+						text = query.Normalize(text)
+					}
+					_, err = fmt.Fprintf(w, "query: %s\n", text)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			_, err = fmt.Fprintf(w, "%s, database: %s, exec time: %s ms\n%s\n",
+				ts.Format("2006/01/02 15:04:05"), row[1].String, row[2].String, row[3].String,
+			)
+			if err != nil {
+				return err
+			}
+			printed++
+		}
+	}
+
+	if printed == 0 {
+		_, err := fmt.Fprintf(w, "INFO: no plans captured for queryid %s\n", c.Plans)
+		return err
+	}
+
+	return nil
+}
+
+// plansEntryWanted returns function which accepts archive entries required for printing plans.
+func plansEntryWanted(c Config) func(name string) bool {
+	return func(name string) bool {
+		if strings.HasPrefix(name, stat.QueryTextsName+".") {
+			return true
+		}
+
+		if !strings.HasPrefix(name, stat.PlansName+".") {
+			return false
+		}
+
+		_, err := isFilenameTimestampOK(name, c.TsStart, c.TsEnd)
+		return err == nil
+	}
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_printPlans(t *testing.T) {
+	mkPlans := func(queryid, plan string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: 4, Nrows: 1, Cols: []string{"queryid", "database", "exec_time", "plan"},
+			Values: [][]sql.NullString{{{String: queryid, Valid: true}, {String: "pgbench", Valid: true}, {String: "1500.00", Valid: true}, {String: plan, Valid: true}}},
+		}
+	}
+
+	mkTar := func() *tar.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		writeEntry := func(name string, v interface{}) {
+			payload, err := json.Marshal(v)
+			assert.NoError(t, err)
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(payload)), Mode: 0644}))
+			_, err = tw.Write(payload)
+			assert.NoError(t, err)
+		}
+
+		writeEntry("query_texts.20260519T100000.000.json", stat.NewQueryTexts([][]sql.NullString{
+			{{String: "q1", Valid: true}, {String: "SELECT * FROM t WHERE id = $1", Valid: true}},
+		}))
+		writeEntry("plans.20260519T100000.000.json", mkPlans("q1", "Seq Scan on t"))
+		writeEntry("plans.20260519T101000.000.json", mkPlans("q2", "Result"))
+		writeEntry("plans.20260519T102000.000.json", mkPlans("q1", "Index Scan using t_pkey on t"))
+		assert.NoError(t, tw.Close())
+		return tar.NewReader(&buf)
+	}
+
+	loc := time.Now().Location()
+	config := Config{
+		Plans:   "q1",
+		TsStart: time.Date(2026, 5, 19, 10, 5, 0, 0, loc),
+		TsEnd:   time.Date(2026, 5, 19, 23, 59, 59, 0, loc),
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printPlans(&buf, mkTar(), config))
+	assert.Equal(t, "query: SELECT * FROM t WHERE id = $1\n"+
+		"2026/05/19 10:20:00, database: pgbench, exec time: 1500.00 ms\n"+
+		"Index Scan using t_pkey on t\n", buf.String())
+
+	// No plans for the statement.
+	config.Plans = "q3"
+	buf.Reset()
+	assert.NoError(t, printPlans(&buf, mkTar(), config))
+	assert.Equal(t, "INFO: no plans captured for queryid q3\n", buf.String())
+}
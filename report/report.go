@@ -2,6 +2,7 @@ package report
 
 import (
 	"archive/tar"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"github.com/lesovsky/pgcenter/internal/align"
@@ -30,6 +31,14 @@ type Config struct {
 	FilterRE      *regexp.Regexp
 	RowLimit      int
 	TruncLimit    int
+	Columns       []string        // Columns to print and their order, all columns are printed if empty
+	Derive        []Derive        // Columns computed from other numeric columns
+	RateWindow    time.Duration   // Minimal interval between diffed samples, adjacent samples are diffed if zero
+	Export        string          // Format of exported stats, report is printed if empty
+	Plans         string          // Queryid of statement which captured plans are printed
+	Redact        bool            // Replace literals in query texts with placeholders
+	Key           stat.ArchiveKey // Key file or passphrase used for decrypting encrypted files
+	CustomViews   view.Views      // Views defined by user in configuration file
 }
 
 const (
@@ -43,9 +52,17 @@ func RunMain(c Config) error {
 
 	// Print report description if requested.
 	if c.Describe {
+		if app.view.Custom {
+			return describeCustomView(app.writer, app.view)
+		}
 		return describeReport(app.writer, c.ReportType)
 	}
 
+	// Rates over window could be calculated only using cumulative values.
+	if c.RateWindow > 0 && app.view.DiffIntvl == [2]int{0, 0} && c.ReportType != "procpidstat" {
+		return fmt.Errorf("report %s has no cumulative values, rate window is not supported", c.ReportType)
+	}
+
 	// Open file with statistics.
 	f, err := os.Open(c.InputFile)
 	if err != nil {
@@ -59,17 +76,39 @@ func RunMain(c Config) error {
 		}
 	}()
 
+	// Print captured plans of the statement instead of printing report if requested.
+	if c.Plans != "" {
+		r, err := stat.DecryptArchive(archiveReader(f, c.InputFile, plansEntryWanted(c)), c.Key)
+		if err != nil {
+			return err
+		}
+		return printPlans(app.writer, tar.NewReader(r), c)
+	}
+
+	// Export stats instead of printing report if requested.
+	if c.Export != "" {
+		r, err := stat.DecryptArchive(archiveReader(f, c.InputFile, exportEntryWanted(c)), c.Key)
+		if err != nil {
+			return err
+		}
+		return exportStats(app.writer, tar.NewReader(r), c)
+	}
+
+	// Initialize reader, read only required entries if the archive has an index. Decrypt entries if the archive
+	// is encrypted.
+	r, err := stat.DecryptArchive(archiveReader(f, c.InputFile, reportEntryWanted(c)), c.Key)
+	if err != nil {
+		return err
+	}
+
 	// Print report header.
 	err = printReportHeader(app.writer, app.config)
 	if err != nil {
 		return err
 	}
 
-	// Initialize tar reader.
-	tr := tar.NewReader(f)
-
 	// Start printing report.
-	return app.doReport(tr)
+	return app.doReport(tar.NewReader(r))
 }
 
 // app defines application container with runtime dependencies.
@@ -82,6 +121,9 @@ type app struct {
 // newApp creates new 'pgcenter record' app.
 func newApp(config Config) *app {
 	views := view.New()
+	for name, v := range config.CustomViews {
+		views[name] = v
+	}
 	v := views[config.ReportType]
 
 	return &app{
@@ -93,16 +135,24 @@ func newApp(config Config) *app {
 
 // metadata defines metadata of stats snapshot
 type metadata struct {
-	version  int     // version reflects Postgres version
-	ticks    float64 // CLK_TCK captured at recording time (sourced from sysinfo.* tar entry); informational under Option B
-	cpuCount int     // local CPU count captured at recording time (sourced from sysinfo.* tar entry); informational under Option B
+	version  int                // version reflects Postgres version
+	ticks    float64            // CLK_TCK captured at recording time (sourced from sysinfo.* tar entry); informational under Option B
+	cpuCount int                // local CPU count captured at recording time (sourced from sysinfo.* tar entry); informational under Option B
+	manifest *view.ViewManifest // description of the view recorded in the archive (sourced from manifest.* tar entry), if any
 }
 
 // data defines unit of stats portion transmitted through channel from stats reader to stats processor.
 type data struct {
+	ts          time.Time
+	res         stat.PGresult // stats snapshot, it's not valid if data contains annotations only
+	meta        metadata
+	annotations []annotation // annotations made before the stats snapshot
+}
+
+// annotation defines operator's annotation read from stats file.
+type annotation struct {
 	ts   time.Time
-	res  stat.PGresult
-	meta metadata
+	text string
 }
 
 // Read statistics file and create a report based on report settings
@@ -113,6 +163,7 @@ func (app *app) doReport(r *tar.Reader) error {
 	dataCh := make(chan data)
 	doneCh := make(chan struct{})
 	var wg sync.WaitGroup
+	var processErr error
 
 	wg.Add(1)
 	go func() {
@@ -125,15 +176,27 @@ func (app *app) doReport(r *tar.Reader) error {
 
 	wg.Add(1)
 	go func() {
-		err := processData(app, v, c, dataCh, doneCh)
-		if err != nil {
-			fmt.Println(err)
+		processErr = processData(app, v, c, dataCh, doneCh)
+		if processErr != nil {
+			// Drain the rest of the data, otherwise the reader is blocked on sending it.
+			drainData(dataCh, doneCh)
 		}
 		wg.Done()
 	}()
 
 	wg.Wait()
-	return nil
+	return processErr
+}
+
+// drainData receives and discards data until the reader is done.
+func drainData(dataCh chan data, doneCh chan struct{}) {
+	for {
+		select {
+		case <-dataCh:
+		case <-doneCh:
+			return
+		}
+	}
 }
 
 // readTar reads stats and metadata from tar stream and send it to data channel.
@@ -141,9 +204,13 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 	var metaOK, statOK bool
 	var meta metadata
 	var res stat.PGresult
+	texts := map[string]string{} // queries texts recorded separately from statements snapshots
+	var annotations []annotation // annotations which are not sent yet
 
 	defer func() { doneCh <- struct{}{} }()
 
+	name := reportEntryName(config)
+
 	for {
 		hdr, err := r.Next()
 		if err == io.EOF {
@@ -153,11 +220,30 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 		}
 
 		// Check filename - it has valid format and corresponds to requested report type.
-		err = isFilenameOK(hdr.Name, config.ReportType)
+		err = isFilenameOK(hdr.Name, name)
 		if err != nil {
 			continue
 		}
 
+		// Manifest is written once per recording session, read it regardless of requested report interval.
+		if strings.HasPrefix(hdr.Name, "manifest.") {
+			meta.manifest, err = readManifest(r, hdr.Size, config.ReportType)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Queries texts are recorded once per queryid, read them regardless of requested report interval.
+		if strings.HasPrefix(hdr.Name, stat.QueryTextsName+".") {
+			res, err := stat.NewPGresultFile(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			stat.UpdateQueryTexts(texts, res)
+			continue
+		}
+
 		// Check timestamp in filename, is it correct and is in requested report interval.
 		ts, err := isFilenameTimestampOK(hdr.Name, config.TsStart, config.TsEnd)
 		if err != nil {
@@ -166,6 +252,13 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 
 		// Read metadata from file.
 		switch {
+		case strings.HasPrefix(hdr.Name, stat.AnnotationName+"."):
+			a, err := readAnnotation(r, hdr.Size)
+			if err != nil {
+				return err
+			}
+			annotations = append(annotations, annotation{ts: ts, text: a.Text})
+			continue
 		case strings.HasPrefix(hdr.Name, "meta."):
 			res, err := stat.NewPGresultFile(r, hdr.Size)
 			if err != nil {
@@ -181,6 +274,7 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 			// meta (sysinfo entry may have been read before meta in the tar).
 			m.ticks = meta.ticks
 			m.cpuCount = meta.cpuCount
+			m.manifest = meta.manifest
 
 			metaOK, meta = true, m
 		case strings.HasPrefix(hdr.Name, "sysinfo."):
@@ -212,6 +306,10 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 			if err != nil {
 				return err
 			}
+			res = stat.ResolveQueryTexts(res, texts)
+			if config.Redact {
+				res = stat.RedactQueries(res)
+			}
 			statOK = true
 		}
 
@@ -220,12 +318,49 @@ func readTar(r *tar.Reader, config Config, dataCh chan data, doneCh chan struct{
 		}
 
 		// Send stats and meta, and reset flags.
-		dataCh <- data{ts: ts, res: res, meta: meta}
+		dataCh <- data{ts: ts, res: res, meta: meta, annotations: annotations}
 
-		metaOK, statOK = false, false
+		metaOK, statOK, annotations = false, false, nil
 
 	} // end for
 
+	// Send annotations made after the last stats snapshot.
+	if len(annotations) > 0 {
+		dataCh <- data{annotations: annotations}
+	}
+
+	return nil
+}
+
+// readAnnotation reads operator's annotation.
+func readAnnotation(r io.Reader, size int64) (stat.Annotation, error) {
+	if size < 0 || size > stat.MaxResultFileSize {
+		return stat.Annotation{}, fmt.Errorf("result file size %d exceeds limit %d bytes", size, stat.MaxResultFileSize)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return stat.Annotation{}, fmt.Errorf("read annotation failed: %w", err)
+	}
+
+	var a stat.Annotation
+	err = json.Unmarshal(buf, &a)
+	if err != nil {
+		return stat.Annotation{}, fmt.Errorf("decode annotation failed: %w", err)
+	}
+
+	return a, nil
+}
+
+// printAnnotations prints operator's annotations.
+func printAnnotations(w io.Writer, annotations []annotation) error {
+	for _, a := range annotations {
+		_, err := fmt.Fprintf(w, "%s, annotation: %s\n", a.ts.Format("2006/01/02 15:04:05"), a.text)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -234,8 +369,10 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 	var prevMeta metadata
 	var prevStat stat.PGresult
 	var prevTs time.Time
+	var window []data                 // samples kept for calculating rates over the rate window
 	linesPrinted := repeatHeaderAfter // initial value means print header at the beginning of all output
 	orderConfigured := false          // flag tells about order is not configured.
+	orderDerived := -1                // index of derived column used for order, if any
 	warningChecked := false           // one-shot guard for procpidstat IO/iodelay availability warnings
 	anyDataPrinted := false           // tracks whether at least one data row was printed; used to emit no-data INFO for procpidstat
 
@@ -243,14 +380,24 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 	for {
 		select {
 		case d := <-dataCh:
+			// Annotations are printed in place where they were made.
+			err := printAnnotations(app.writer, d.annotations)
+			if err != nil {
+				return err
+			}
+			if !d.res.Valid {
+				continue
+			}
+
 			// If previous stats snapshot is not defined, copy current to previous.
 			// Usually this occurs when reading first stat sample at startup.
 
 			// Also checking version of stats in metadata, if it's different also discard previous.
-			if !prevStat.Valid || prevMeta.version != d.meta.version {
+			if !prevStat.Valid || prevMeta.version != d.meta.version || prevMeta.manifest != d.meta.manifest {
 				prevMeta = d.meta
 				prevStat = d.res
 				prevTs = d.ts
+				window = []data{d}
 
 				views := view.Views{
 					config.ReportType: v,
@@ -264,9 +411,36 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 
 				v = views[config.ReportType]
 
+				// Prefer view description stored in the archive, it corresponds to the recorded stats. Columns
+				// might be different, so order should be configured again.
+				if d.meta.manifest != nil {
+					v = d.meta.manifest.Apply(v)
+					v.Aligned = false
+					orderConfigured, orderDerived = false, -1
+				}
+
+				// List of columns is known since the first sample, validate requested and derived columns before
+				// skipping it. Otherwise misspelled columns are not reported when there is single sample only.
+				err = validateColumns(d.res.Cols, config.Derive, config.Columns)
+				if err != nil {
+					return err
+				}
+
 				continue
 			}
 
+			// When rate window is specified, use the latest sample which is at least window older than current
+			// as previous. Skip current sample if there is no such sample yet.
+			if config.RateWindow > 0 {
+				window = append(window, d)
+				i := windowStart(window, config.RateWindow)
+				if i < 0 {
+					continue
+				}
+				window = window[i:]
+				prevStat, prevTs = window[0].res, window[0].ts
+			}
+
 			// One-shot WARNING check for procpidstat: inspect raw current
 			// result (not the computed diff) for empty IO / iodelay columns.
 			// The "" sentinel is the only signal that the recorder dropped
@@ -279,18 +453,8 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 			}
 
 			// Calculate interval and rate.
-			// Interval defines number of seconds used for calculating rate values.
-			// Rate is not used in calculations and printed on info header.
 			interval := d.ts.Sub(prevTs)
-			var itv int
-			var rate time.Duration
-			if interval < time.Second {
-				itv = 1
-				rate = interval
-			} else {
-				itv = int(interval / time.Second)
-				rate = time.Second
-			}
+			itv, rate := rateInterval(interval)
 
 			// When first data read, list of columns is known and it is possible to set up order.
 			if config.OrderColName != "" && !orderConfigured {
@@ -298,15 +462,43 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 					v.OrderKey = idx
 					v.OrderDesc = config.OrderDesc
 					orderConfigured = true
+				} else {
+					for i, dc := range config.Derive {
+						if dc.Name == config.OrderColName {
+							orderDerived = len(d.res.Cols) + i
+							orderConfigured = true
+						}
+					}
 				}
 			}
 
+			// Raw procpidstat values have to be converted to rates before, procpidstat view itself is not diffed.
+			curr := d.res
+			if config.RateWindow > 0 && config.ReportType == "procpidstat" {
+				curr = stat.CompareProcPidResultRaw(d.res, prevStat, interval.Seconds(), d.meta.ticks, d.meta.cpuCount)
+			}
+
 			// Calculate delta between current and previous stats snapshots.
-			diffStat, err := countDiff(d.res, prevStat, itv, v)
+			diffStat, err := countDiff(curr, prevStat, itv, v)
 			if err != nil {
 				return err
 			}
 
+			// Calculate derived columns, they are calculated using diffed values.
+			if len(config.Derive) > 0 {
+				appendDerived(&diffStat, config.Derive)
+				if orderDerived >= 0 {
+					diffStat.Sort(orderDerived, config.OrderDesc)
+				}
+			}
+
+			// Filter rows before projection, filter column might be not requested for printing.
+			filterStatSample(&diffStat, config)
+
+			if len(config.Columns) > 0 {
+				diffStat = projectColumns(diffStat, config.Columns)
+			}
+
 			// Format the stat
 			formatStatSample(&diffStat, &v, config)
 
@@ -337,7 +529,11 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 			// is valid but carries no procpidstat data. linesPrinted cannot
 			// be used here — it is seeded with repeatHeaderAfter (20), not 0.
 			if !anyDataPrinted && config.ReportType == "procpidstat" {
-				if _, err := fmt.Fprint(app.writer, "INFO: no procpidstat data in this archive\n"); err != nil {
+				msg := "INFO: no procpidstat data in this archive\n"
+				if config.RateWindow > 0 {
+					msg = "INFO: no raw procpidstat data in this archive (recorded with --raw-procstat) or it is shorter than rate window\n"
+				}
+				if _, err := fmt.Fprint(app.writer, msg); err != nil {
 					return err
 				}
 			}
@@ -346,6 +542,26 @@ func processData(app *app, v view.View, config Config, dataCh chan data, doneCh
 	}
 }
 
+// rateInterval returns number of seconds used for calculating rate values, and rate printed on info header.
+func rateInterval(interval time.Duration) (int, time.Duration) {
+	if interval < time.Second {
+		return 1, interval
+	}
+	return int(interval / time.Second), time.Second
+}
+
+// windowStart returns index of the latest sample which is at least window older than the last sample. Returns -1
+// if there is no such sample.
+func windowStart(samples []data, window time.Duration) int {
+	last := samples[len(samples)-1].ts
+	for i := len(samples) - 2; i >= 0; i-- {
+		if last.Sub(samples[i].ts) >= window {
+			return i
+		}
+	}
+	return -1
+}
+
 // emitProcPidStatAvailabilityWarnings inspects the first procpidstat result
 // for empty IO / iodelay columns and writes a WARNING line per affected
 // column group. A column is considered "unavailable" when every row's value
@@ -387,6 +603,41 @@ func emitProcPidStatAvailabilityWarnings(w io.Writer, res stat.PGresult) error {
 	return nil
 }
 
+// readManifest reads archive manifest and returns description of the specified view. Returns nil if the view is not
+// described in the manifest.
+func readManifest(r io.Reader, size int64, name string) (*view.ViewManifest, error) {
+	m, err := readArchiveManifest(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := m.Views[name]; ok {
+		return &v, nil
+	}
+
+	return nil, nil
+}
+
+// readArchiveManifest reads and decodes archive manifest.
+func readArchiveManifest(r io.Reader, size int64) (view.Manifest, error) {
+	if size < 0 || size > stat.MaxResultFileSize {
+		return view.Manifest{}, fmt.Errorf("result file size %d exceeds limit %d bytes", size, stat.MaxResultFileSize)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return view.Manifest{}, fmt.Errorf("read manifest failed: %w", err)
+	}
+
+	var m view.Manifest
+	err = json.Unmarshal(buf, &m)
+	if err != nil {
+		return view.Manifest{}, fmt.Errorf("decode manifest failed: %w", err)
+	}
+
+	return m, nil
+}
+
 // readMeta creates metadata object from stat.PGresult.
 // The metadata query SelectCommonProperties had 7 columns before cbfa0a4 (without
 // shared_preload_libraries) and 8 columns after. Accept any result with at least 2
@@ -404,6 +655,16 @@ func readMeta(res stat.PGresult) (metadata, error) {
 	return metadata{version: int(version)}, nil
 }
 
+// reportEntryName returns name of archive entries which contain stats of the report.
+func reportEntryName(config Config) string {
+	// Rates of procpidstat over window are calculated using raw values stored in separate entries.
+	if config.RateWindow > 0 && config.ReportType == "procpidstat" {
+		return stat.ProcPidRawName
+	}
+
+	return config.ReportType
+}
+
 // isFilenameOK checks filename format.
 func isFilenameOK(name string, report string) error {
 	s := strings.Split(name, ".")
@@ -416,7 +677,7 @@ func isFilenameOK(name string, report string) error {
 	// Check the filename corresponds to user-requested report or metadata.
 	// "sysinfo" is treated as supplementary metadata under Option B and is
 	// merged into the metadata struct alongside the meta.* version.
-	if s[0] != report && s[0] != "meta" && s[0] != "sysinfo" {
+	if s[0] != report && s[0] != "meta" && s[0] != "sysinfo" && s[0] != "manifest" && s[0] != stat.QueryTextsName && s[0] != stat.AnnotationName {
 		return fmt.Errorf("skip sample")
 	}
 
@@ -485,6 +746,28 @@ func formatStatSample(d *stat.PGresult, view *view.View, c Config) {
 	view.Aligned = true
 }
 
+// filterStatSample removes rows which values in the filter column don't match the filter pattern.
+func filterStatSample(res *stat.PGresult, c Config) {
+	// if filtering (grep) is not enabled, nothing to do
+	if c.FilterColName == "" {
+		return
+	}
+
+	// if filter enabled, use pessimistic approach and considering the value will not match
+	values := make([][]sql.NullString, 0, len(res.Values))
+	for _, row := range res.Values {
+		for idx, colname := range res.Cols {
+			if colname == c.FilterColName && c.FilterRE.MatchString(row[idx].String) {
+				values = append(values, row) // value matched, so keep the whole row
+				break
+			}
+		}
+	}
+
+	res.Values = values
+	res.Nrows = len(values)
+}
+
 // printReportHeader prints report header.
 func printReportHeader(w io.Writer, c Config) error {
 	tmpl := "INFO: reading from %s\n" +
@@ -531,77 +814,66 @@ func printStatSample(w io.Writer, res *stat.PGresult, view view.View, c Config,
 	var linesPrinted int  // count lines printed per snapshot (for limiting purposes)
 	var printedNum int
 
-	// loop through the rows, check for filtered values and print if values are satisfied
+	// loop through the rows and print them, rows are already filtered by filterStatSample
 	for colnum, rownum := 0, 0; rownum < res.Nrows; rownum, colnum = rownum+1, 0 {
-		var doPrint = true // assume the filtering is disabled by default and row should be printed
-
-		// if filtering (grep) is enabled, a target column should be found and check values
-		// if value doesn't match, skip it and proceed to next row
-		if c.FilterColName != "" {
-			// if filter enabled, use pessimistic approach and considering the value will not match
-			doPrint = false
-			for idx, colname := range res.Cols {
-				if colname == c.FilterColName {
-					if c.FilterRE.MatchString(res.Values[rownum][idx].String) {
-						doPrint = true // value matched, so print the whole row
-						break
-					}
-				}
+		header := fmt.Sprintf("%s, rate: %s\n", ts.Format("2006/01/02 15:04:05"), interval.String())
+		if c.RateWindow > 0 {
+			header = fmt.Sprintf("%s, rate: %s, window: %s\n", ts.Format("2006/01/02 15:04:05"), interval.String(), c.RateWindow.String())
+		}
+		if printFirst {
+			_, err := fmt.Fprint(w, header)
+			if err != nil {
+				return 0, err
 			}
+			printFirst = false
 		}
 
-		// print the row
-		if doPrint {
-			header := fmt.Sprintf("%s, rate: %s\n", ts.Format("2006/01/02 15:04:05"), interval.String())
-			if printFirst {
-				_, err := fmt.Fprint(w, header)
-				if err != nil {
-					return 0, err
-				}
-				printFirst = false
+		for i := range res.Cols {
+			// truncate values that longer than column width
+			valuelen := len(res.Values[rownum][colnum].String)
+			if valuelen > view.ColsWidth[i] {
+				width := view.ColsWidth[i]
+				// truncate value up to column width and replace last character with '~' symbol
+				res.Values[rownum][colnum].String = res.Values[rownum][colnum].String[:width-1] + "~"
 			}
 
-			for i := range res.Cols {
-				// truncate values that longer than column width
-				valuelen := len(res.Values[rownum][colnum].String)
-				if valuelen > view.ColsWidth[i] {
-					width := view.ColsWidth[i]
-					// truncate value up to column width and replace last character with '~' symbol
-					res.Values[rownum][colnum].String = res.Values[rownum][colnum].String[:width-1] + "~"
+			// last col with no truncation of not specified otherwise
+			if i != len(res.Cols)-1 {
+				_, err := fmt.Fprintf(w, "%-*s", view.ColsWidth[i]+2, res.Values[rownum][colnum].String)
+				if err != nil {
+					return 0, err
 				}
-
-				// last col with no truncation of not specified otherwise
-				if i != len(res.Cols)-1 {
-					_, err := fmt.Fprintf(w, "%-*s", view.ColsWidth[i]+2, res.Values[rownum][colnum].String)
-					if err != nil {
-						return 0, err
-					}
-				} else {
-					_, err := fmt.Fprintf(w, "%s", res.Values[rownum][colnum].String)
-					if err != nil {
-						return 0, err
-					}
+			} else {
+				_, err := fmt.Fprintf(w, "%s", res.Values[rownum][colnum].String)
+				if err != nil {
+					return 0, err
 				}
-
-				colnum++
 			}
 
-			_, err := fmt.Fprintf(w, "\n")
-			if err != nil {
-				return 0, err
-			}
-			printedNum++
+			colnum++
+		}
 
-			// check number of printed lines, if limit is reached skip remaining rows and proceed to a next stats file
-			if linesPrinted++; c.RowLimit > 0 && linesPrinted >= c.RowLimit {
-				break
-			}
-		} // end if
+		_, err := fmt.Fprintf(w, "\n")
+		if err != nil {
+			return 0, err
+		}
+		printedNum++
+
+		// check number of printed lines, if limit is reached skip remaining rows and proceed to a next stats file
+		if linesPrinted++; c.RowLimit > 0 && linesPrinted >= c.RowLimit {
+			break
+		}
 	} // end for
 
 	return printedNum, nil
 }
 
+// describeCustomView shows description of the custom view defined by user.
+func describeCustomView(w io.Writer, v view.View) error {
+	_, err := fmt.Fprintf(w, "Custom view %s: %s\n\nQuery template:\n%s\n", v.Name, v.Msg, strings.TrimSpace(v.QueryTmpl))
+	return err
+}
+
 // doDescribe shows detailed description of the requested stats
 func describeReport(w io.Writer, report string) error {
 	m := map[string]string{
@@ -14,6 +14,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +22,52 @@ import (
 
 var update = flag.Bool("update", false, "update golden files")
 
+// testMeta returns metadata of stats as recorded by 'pgcenter record'.
+func testMeta() stat.PGresult {
+	return stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1,
+		Cols:   []string{"version", "version_num"},
+		Values: [][]sql.NullString{{{String: "17.1", Valid: true}, {String: "170001", Valid: true}}},
+	}
+}
+
+// archiveEntry defines entry of the test archive, value is encoded into JSON.
+type archiveEntry struct {
+	name  string
+	value interface{}
+}
+
+// newTestArchive returns tar archive with the entries. Entries are encrypted if cipher is specified, except the
+// encryption header.
+func newTestArchive(t *testing.T, c *stat.ArchiveCipher, entries ...archiveEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, e := range entries {
+		payload, err := json.Marshal(e.value)
+		assert.NoError(t, err)
+		if c != nil && !strings.HasPrefix(e.name, stat.EncryptionName+".") {
+			payload = c.Seal(e.name, payload)
+		}
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Size: int64(len(payload)), Mode: 0644}))
+		_, err = tw.Write(payload)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	return &buf
+}
+
+// testReportConfig returns config of the report of the view over the whole day of test archives.
+func testReportConfig(name string) Config {
+	return Config{
+		ReportType: name,
+		TruncLimit: 32,
+		TsStart:    time.Date(2026, 5, 19, 0, 0, 0, 0, time.Now().Location()),
+		TsEnd:      time.Date(2026, 5, 19, 23, 59, 59, 0, time.Now().Location()),
+	}
+}
+
 func Test_app_doReport(t *testing.T) {
 	testcases := []struct {
 		start    string
@@ -303,6 +350,50 @@ func Test_processData(t *testing.T) {
 	assert.Equal(t, string(want), buf.String())
 }
 
+func Test_app_doReport_singleSample(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"name", "calls"},
+		Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: "10", Valid: true}}},
+	}
+	derive, err := ParseDerive("per_call=calls/unknown")
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		columns []string
+		derive  []Derive
+		wantErr string
+	}{
+		{columns: []string{"name", "calls"}},
+		{columns: []string{"name", "cals"}, wantErr: "unknown column 'cals', available columns: name, calls"},
+		{derive: []Derive{derive}, wantErr: "derived column 'per_call': unknown column 'unknown', available columns: name, calls"},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			archive := newTestArchive(t, nil,
+				archiveEntry{name: "meta.20260519T100000.000.json", value: testMeta()},
+				archiveEntry{name: "custom.20260519T100000.000.json", value: res},
+			)
+
+			config := testReportConfig("custom")
+			config.Columns, config.Derive = tc.columns, tc.derive
+
+			app := newApp(config)
+			app.view = view.View{Name: "custom", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+			var buf bytes.Buffer
+			app.writer = &buf
+
+			// Columns are validated even if the archive has no stats to print.
+			err := app.doReport(tar.NewReader(archive))
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func Test_readMeta(t *testing.T) {
 	testcases := []struct {
 		valid bool
@@ -1210,3 +1301,359 @@ func Test_describeReport(t *testing.T) {
 	}
 
 }
+
+func Test_windowStart(t *testing.T) {
+	ts := time.Date(2021, 01, 01, 00, 00, 00, 0, time.UTC)
+	mkSamples := func(offsets ...int) []data {
+		samples := make([]data, len(offsets))
+		for i, o := range offsets {
+			samples[i] = data{ts: ts.Add(time.Duration(o) * time.Second)}
+		}
+		return samples
+	}
+
+	testcases := []struct {
+		samples []data
+		window  time.Duration
+		want    int
+	}{
+		{samples: mkSamples(0), window: time.Minute, want: -1},
+		{samples: mkSamples(0, 10, 20), window: time.Minute, want: -1},
+		{samples: mkSamples(0, 10, 60), window: time.Minute, want: 0},
+		{samples: mkSamples(0, 10, 20, 75), window: time.Minute, want: 1},
+		{samples: mkSamples(0, 10, 20, 75), window: time.Second, want: 2},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, windowStart(tc.samples, tc.window))
+	}
+}
+
+func Test_processData_rateWindow(t *testing.T) {
+	cols := []string{"queryid", "calls", "query"}
+	mkRes := func(calls string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: len(cols), Nrows: 1, Cols: cols,
+			Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: calls, Valid: true}, {String: "SELECT 1", Valid: true}}},
+		}
+	}
+
+	v := view.View{Name: "custom", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+	config := Config{ReportType: "custom", TruncLimit: 32, RateWindow: 20 * time.Second}
+
+	app := newApp(config)
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	dataCh := make(chan data)
+	doneCh := make(chan struct{})
+	go func() {
+		ts := time.Date(2021, 01, 01, 00, 00, 00, 0, time.UTC)
+		for i, calls := range []string{"0", "100", "1000", "1100", "1200"} {
+			dataCh <- data{ts: ts.Add(time.Duration(i*10) * time.Second), res: mkRes(calls), meta: metadata{version: 140000}}
+		}
+		doneCh <- struct{}{}
+	}()
+
+	assert.NoError(t, processData(app, v, config, dataCh, doneCh))
+
+	// Samples at 00:00:20, 00:00:30 and 00:00:40 are diffed with samples taken 20 seconds before.
+	assert.Equal(t, "queryid   calls     query     \n"+
+		"2021/01/01 00:00:20, rate: 1s, window: 20s\n"+
+		"q1        50        SELECT 1\n"+
+		"2021/01/01 00:00:30, rate: 1s, window: 20s\n"+
+		"q1        50        SELECT 1\n"+
+		"2021/01/01 00:00:40, rate: 1s, window: 20s\n"+
+		"q1        10        SELECT 1\n", stripANSI(buf.String()))
+}
+
+func Test_app_doReport_procpidstatRateWindow(t *testing.T) {
+	activity := stat.PGresult{
+		Valid: true, Ncols: 7, Nrows: 1,
+		Cols:   []string{"pid", "datname", "usename", "state", "wait_etype", "wait_event", "query"},
+		Values: [][]sql.NullString{{{String: "100", Valid: true}, {String: "postgres", Valid: true}, {String: "alice", Valid: true}, {String: "active", Valid: true}, {String: "", Valid: true}, {String: "", Valid: true}, {String: "SELECT 1", Valid: true}}},
+	}
+
+	// Every 10 seconds process consumes 200 jiffies (2 seconds) of CPU time.
+	var entries []archiveEntry
+	var prevStats map[int]stat.ProcPidStat
+	for i, ts := range []string{"100000", "100010", "100020", "100030"} {
+		currStats := map[int]stat.ProcPidStat{100: {Utime: float64(i * 200)}}
+		raw := stat.BuildProcPidResultRaw(activity, prevStats, currStats, nil, nil, false, false, 100, 10, 4)
+		prevStats = currStats
+
+		entries = append(entries,
+			archiveEntry{name: "meta.20260519T" + ts + ".000.json", value: testMeta()},
+			archiveEntry{name: "procpidstat.20260519T" + ts + ".000.json", value: stat.FormatProcPidResultForDisplay(raw, 100)},
+			archiveEntry{name: "procpidstat_raw.20260519T" + ts + ".000.json", value: raw},
+			archiveEntry{name: "sysinfo.20260519T" + ts + ".000.json", value: stat.SysInfo{Ticks: 100, CPUCount: 4}},
+		)
+	}
+
+	config := testReportConfig("procpidstat")
+	config.RateWindow = 20 * time.Second
+
+	app := newApp(config)
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	assert.NoError(t, app.doReport(tar.NewReader(newTestArchive(t, nil, entries...))))
+
+	// 400 jiffies within 20 seconds of 4 CPUs gives 5% of CPU usage.
+	out := stripANSI(buf.String())
+	assert.Equal(t, 2, strings.Count(out, ", rate: 1s, window: 20s\n"))
+
+	var rows int
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "100 ") {
+			fields := strings.Fields(line)
+			assert.Equal(t, []string{"5.00", "5.00", "0.00", "SELECT", "1"}, fields[len(fields)-5:])
+			rows++
+		}
+	}
+	assert.Equal(t, 2, rows)
+}
+
+func Test_RunMain_rateWindowNotSupported(t *testing.T) {
+	err := RunMain(Config{ReportType: "activity", RateWindow: time.Minute})
+	assert.EqualError(t, err, "report activity has no cumulative values, rate window is not supported")
+}
+
+func Test_readManifest(t *testing.T) {
+	payload, err := json.Marshal(view.Manifest{
+		Version: "v0.0.0",
+		Views:   map[string]view.ViewManifest{"custom": {Cols: []string{"a"}, DiffIntvl: [2]int{1, 1}}},
+	})
+	assert.NoError(t, err)
+
+	got, err := readManifest(bytes.NewReader(payload), int64(len(payload)), "custom")
+	assert.NoError(t, err)
+	assert.Equal(t, &view.ViewManifest{Cols: []string{"a"}, DiffIntvl: [2]int{1, 1}}, got)
+
+	// View is not described in manifest.
+	got, err = readManifest(bytes.NewReader(payload), int64(len(payload)), "tables")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// Invalid manifest.
+	_, err = readManifest(bytes.NewReader([]byte("{")), 1, "custom")
+	assert.Error(t, err)
+	_, err = readManifest(bytes.NewReader(payload), stat.MaxResultFileSize+1, "custom")
+	assert.Error(t, err)
+}
+
+func Test_app_doReport_manifest(t *testing.T) {
+	cols := []string{"queryid", "calls", "query"}
+	mkRes := func(calls string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: len(cols), Nrows: 1, Cols: cols,
+			Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: calls, Valid: true}, {String: "SELECT 1", Valid: true}}},
+		}
+	}
+
+	// Manifest is written before the requested interval, but it still has to be used.
+	entries := []archiveEntry{{name: "manifest.20260519T095959.000.json", value: view.Manifest{
+		Version: "v0.0.0",
+		Views:   map[string]view.ViewManifest{"custom": {Cols: cols, DiffIntvl: [2]int{1, 1}}},
+	}}}
+	for i, ts := range []string{"100000", "100001", "100002"} {
+		entries = append(entries,
+			archiveEntry{name: "meta.20260519T" + ts + ".000.json", value: testMeta()},
+			archiveEntry{name: "custom.20260519T" + ts + ".000.json", value: mkRes([]string{"100", "150", "250"}[i])},
+		)
+	}
+
+	config := testReportConfig("custom")
+	config.TsStart = time.Date(2026, 5, 19, 10, 0, 0, 0, time.Now().Location())
+
+	// View known to the binary has no diffed columns, diffing is defined by the manifest.
+	app := newApp(config)
+	app.view = view.View{Name: "custom", ColsWidth: map[int]int{}}
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	assert.NoError(t, app.doReport(tar.NewReader(newTestArchive(t, nil, entries...))))
+	assert.Equal(t, "queryid   calls     query     \n"+
+		"2026/05/19 10:00:01, rate: 1s\n"+
+		"q1        50        SELECT 1\n"+
+		"2026/05/19 10:00:02, rate: 1s\n"+
+		"q1        100       SELECT 1\n", stripANSI(buf.String()))
+}
+
+func Test_readTar_queryTexts(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 3, Nrows: 2, Cols: []string{"calls", "queryid", "query"},
+		Values: [][]sql.NullString{
+			{{String: "10", Valid: true}, {String: "q1", Valid: true}, {}},
+			{{String: "5", Valid: true}, {String: "q2", Valid: true}, {}},
+		},
+	}
+
+	// Texts are recorded when first seen, even before the requested interval.
+	archive := newTestArchive(t, nil,
+		archiveEntry{name: "query_texts.20260519T095959.000.json", value: stat.NewQueryTexts([][]sql.NullString{
+			{{String: "q1", Valid: true}, {String: "SELECT 1", Valid: true}},
+		})},
+		archiveEntry{name: "meta.20260519T100000.000.json", value: testMeta()},
+		archiveEntry{name: "query_texts.20260519T100000.000.json", value: stat.NewQueryTexts([][]sql.NullString{
+			{{String: "q2", Valid: true}, {String: "SELECT 2", Valid: true}},
+		})},
+		archiveEntry{name: "custom.20260519T100000.000.json", value: res},
+	).Bytes()
+
+	config := testReportConfig("custom")
+	config.TsStart = time.Date(2026, 5, 19, 10, 0, 0, 0, time.Now().Location())
+
+	dataCh := make(chan data)
+	doneCh := make(chan struct{})
+	go func() { assert.NoError(t, readTar(tar.NewReader(bytes.NewReader(archive)), config, dataCh, doneCh)) }()
+
+	d := <-dataCh
+	assert.Equal(t, "SELECT 1", d.res.Values[0][2].String)
+	assert.Equal(t, "SELECT 2", d.res.Values[1][2].String)
+	<-doneCh
+
+	// Resolved texts are redacted when requested.
+	config.Redact = true
+	dataCh = make(chan data)
+	doneCh = make(chan struct{})
+	go func() { assert.NoError(t, readTar(tar.NewReader(bytes.NewReader(archive)), config, dataCh, doneCh)) }()
+
+	d = <-dataCh
+	assert.Equal(t, "SELECT $1", d.res.Values[0][2].String)
+	assert.Equal(t, "SELECT $1", d.res.Values[1][2].String)
+	<-doneCh
+}
+
+func Test_app_doReport_annotations(t *testing.T) {
+	mkRes := func(calls string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"name", "calls"},
+			Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: calls, Valid: true}}},
+		}
+	}
+
+	archive := newTestArchive(t, nil,
+		archiveEntry{name: "meta.20260519T100000.000.json", value: testMeta()},
+		archiveEntry{name: "custom.20260519T100000.000.json", value: mkRes("10")},
+		archiveEntry{name: "annotation.20260519T100000.500.json", value: stat.Annotation{Text: "killed pid 1234"}},
+		archiveEntry{name: "meta.20260519T100001.000.json", value: testMeta()},
+		archiveEntry{name: "custom.20260519T100001.000.json", value: mkRes("15")},
+		archiveEntry{name: "annotation.20260519T100002.000.json", value: stat.Annotation{Text: "failover started"}},
+	)
+
+	app := newApp(testReportConfig("custom"))
+	app.view = view.View{Name: "custom", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	assert.NoError(t, app.doReport(tar.NewReader(archive)))
+	assert.Equal(t, "2026/05/19 10:00:00, annotation: killed pid 1234\n"+
+		"name      calls     \n"+
+		"2026/05/19 10:00:01, rate: 1s\n"+
+		"q1        5\n"+
+		"2026/05/19 10:00:02, annotation: failover started\n", stripANSI(buf.String()))
+}
+
+func Test_app_doReport_encrypted(t *testing.T) {
+	key := stat.ArchiveKey{Passphrase: "secret"}
+	c, err := stat.NewArchiveCipher(key)
+	assert.NoError(t, err)
+
+	mkRes := func(calls string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"name", "calls"},
+			Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: calls, Valid: true}}},
+		}
+	}
+
+	archive := newTestArchive(t, c,
+		archiveEntry{name: "encryption.20260519T100000.000.json", value: c.Header()},
+		archiveEntry{name: "meta.20260519T100000.000.json", value: testMeta()},
+		archiveEntry{name: "custom.20260519T100000.000.json", value: mkRes("10")},
+		archiveEntry{name: "meta.20260519T100001.000.json", value: testMeta()},
+		archiveEntry{name: "custom.20260519T100001.000.json", value: mkRes("15")},
+	).Bytes()
+
+	// Key is required for reading encrypted archive.
+	_, err = stat.DecryptArchive(bytes.NewReader(archive), stat.ArchiveKey{})
+	assert.Error(t, err)
+	_, err = stat.DecryptArchive(bytes.NewReader(archive), stat.ArchiveKey{Passphrase: "wrong"})
+	assert.Error(t, err)
+
+	r, err := stat.DecryptArchive(bytes.NewReader(archive), key)
+	assert.NoError(t, err)
+
+	app := newApp(testReportConfig("custom"))
+	app.view = view.View{Name: "custom", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	assert.NoError(t, app.doReport(tar.NewReader(r)))
+	assert.Equal(t, "name      calls     \n"+
+		"2026/05/19 10:00:01, rate: 1s\n"+
+		"q1        5\n", stripANSI(buf.String()))
+}
+
+// newCustomArchive returns archive with stats of 'queue' custom view recorded along with manifest.
+func newCustomArchive(t *testing.T) *bytes.Buffer {
+	cols := []string{"queue", "processed"}
+	mkRes := func(processed string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: len(cols), Nrows: 1, Cols: cols,
+			Values: [][]sql.NullString{{{String: "emails", Valid: true}, {String: processed, Valid: true}}},
+		}
+	}
+
+	entries := []archiveEntry{{name: "manifest.20260519T100000.000.json", value: view.Manifest{
+		Version: "v0.0.0",
+		Views:   map[string]view.ViewManifest{"queue": {Cols: cols, DiffIntvl: [2]int{1, 1}, OrderKey: 1, OrderDesc: true}},
+	}}}
+	for i, ts := range []string{"100000", "100001", "100002"} {
+		entries = append(entries,
+			archiveEntry{name: "meta.20260519T" + ts + ".000.json", value: testMeta()},
+			archiveEntry{name: "queue.20260519T" + ts + ".000.json", value: mkRes([]string{"10", "40", "100"}[i])},
+		)
+	}
+
+	return newTestArchive(t, nil, entries...)
+}
+
+func Test_app_doReport_custom(t *testing.T) {
+	custom, err := view.NewCustomViews(map[string]view.Custom{
+		"queue": {Description: "Show queue processing", Query: "SELECT queue, processed FROM queue_stats", DiffInterval: []int{1, 1}},
+	})
+	assert.NoError(t, err)
+
+	config := testReportConfig("queue")
+	config.CustomViews = custom
+
+	app := newApp(config)
+	assert.Equal(t, "queue", app.view.Name)
+	assert.True(t, app.view.Custom)
+
+	var buf bytes.Buffer
+	app.writer = &buf
+
+	assert.NoError(t, app.doReport(tar.NewReader(newCustomArchive(t))))
+	assert.Equal(t, "queue     processed  \n"+
+		"2026/05/19 10:00:01, rate: 1s\n"+
+		"emails    30\n"+
+		"2026/05/19 10:00:02, rate: 1s\n"+
+		"emails    60\n", stripANSI(buf.String()))
+
+	// Description of custom view.
+	buf.Reset()
+	assert.NoError(t, describeCustomView(&buf, app.view))
+	assert.Equal(t, "Custom view queue: Show queue processing\n\nQuery template:\nSELECT queue, processed FROM queue_stats\n", buf.String())
+}
+
+func Test_exportStats_custom(t *testing.T) {
+	config := testReportConfig("")
+	config.Export = ExportInflux
+
+	// Views unknown to the binary are exported using manifest.
+	var buf bytes.Buffer
+	assert.NoError(t, exportStats(&buf, tar.NewReader(newCustomArchive(t)), config))
+	assert.Contains(t, buf.String(), "pgcenter_queue,queue=emails processed=100 ")
+}
@@ -0,0 +1,425 @@
+package serve
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// viewInfo describes single view available for requesting.
+type viewInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// table defines stats in JSON: names of columns and rows of values, NULL values are represented by nulls.
+type table struct {
+	Columns []string    `json:"columns"`
+	Rows    [][]*string `json:"rows"`
+}
+
+// viewResponse defines stats of the view.
+type viewResponse struct {
+	View string    `json:"view"`
+	Time time.Time `json:"time"`
+	// Interval defines number of seconds since the previous snapshot requested by the client. Values of
+	// cumulative columns are rates per second over this interval. Zero means values are returned as is.
+	Interval float64 `json:"interval"`
+	table
+}
+
+// systemResponse defines system stats: load average, CPU, memory, disks, network interfaces and filesystems.
+type systemResponse struct {
+	Time  time.Time        `json:"time"`
+	Stats map[string]table `json:"stats"`
+}
+
+// errorResponse defines error returned to client.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// filter defines filter of view's rows: value of the column should match the pattern.
+type filter struct {
+	column string
+	re     *regexp.Regexp
+}
+
+// viewOptions defines options of requested view stats.
+type viewOptions struct {
+	order   string // name of column used for ordering rows, view's default order is used if empty
+	desc    *bool  // ordering direction, view's default is used if not specified
+	filters []filter
+	limit   int // maximum number of returned rows, zero means no limit
+}
+
+// handler returns HTTP handler which serves API requests.
+func (app *app) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /views", app.handleViews)
+	mux.HandleFunc("GET /views/{name}", app.handleView)
+	mux.HandleFunc("GET /system", app.handleSystem)
+	mux.HandleFunc("GET /events", app.handleEvents)
+
+	if app.config.Dashboard {
+		app.dashboardHandler(mux)
+	}
+
+	return mux
+}
+
+// handleViews returns list of available views.
+func (app *app) handleViews(w http.ResponseWriter, _ *http.Request) {
+	views := make([]viewInfo, 0, len(app.views))
+	for name, v := range app.views {
+		views = append(views, viewInfo{Name: name, Description: v.Msg})
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+
+	writeJSON(w, http.StatusOK, views)
+}
+
+// handleView returns stats of the view. Values of cumulative columns are rates calculated since the previous
+// request of the same client.
+func (app *app) handleView(w http.ResponseWriter, r *http.Request) {
+	v, ok := app.views[r.PathValue("name")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown view '%s'", r.PathValue("name"))})
+		return
+	}
+
+	opts, err := parseViewOptions(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	app.mu.Lock()
+	now := time.Now()
+	resp, err := app.viewStats(app.session(clientID(r), now), v, opts, now)
+	app.mu.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleSystem returns system stats. Usage stats are calculated since the previous request of the same client.
+func (app *app) handleSystem(w http.ResponseWriter, r *http.Request) {
+	app.mu.Lock()
+	now := time.Now()
+	resp, err := app.systemStats(app.session(clientID(r), now), now)
+	app.mu.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleEvents streams stats of requested views and system stats as server-sent events. Every stream has its
+// own state, rates are calculated over the stream's interval.
+func (app *app) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var views []view.View
+	for _, name := range q["view"] {
+		v, ok := app.views[name]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown view '%s'", name)})
+			return
+		}
+		views = append(views, v)
+	}
+
+	system := q.Get("system") == "true"
+	if len(views) == 0 && !system {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "no views or system stats requested"})
+		return
+	}
+
+	interval := app.config.Interval
+	if s := q.Get("interval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < time.Second {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid interval '%s', must be at least 1s", s)})
+			return
+		}
+		interval = d
+	}
+
+	opts, err := parseViewOptions(q)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming is not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	s := newSession()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		app.mu.Lock()
+		now := time.Now()
+		events := make([]interface{}, 0, len(views)+1)
+		for _, v := range views {
+			resp, err := app.viewStats(s, v, opts, now)
+			if err != nil {
+				events = append(events, errorResponse{Error: fmt.Sprintf("%s: %s", v.Name, err)})
+				continue
+			}
+			events = append(events, resp)
+		}
+		if system {
+			resp, err := app.systemStats(s, now)
+			if err != nil {
+				events = append(events, errorResponse{Error: fmt.Sprintf("system: %s", err)})
+			} else {
+				events = append(events, resp)
+			}
+		}
+		app.mu.Unlock()
+
+		for _, e := range events {
+			var err error
+			switch e.(type) {
+			case viewResponse:
+				err = send("view", e)
+			case systemResponse:
+				err = send("system", e)
+			default:
+				err = send("error", e)
+			}
+			if err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-app.ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// viewStats queries stats of the view and calculates delta using the client's previous snapshot. Must be called
+// with app.mu held.
+func (app *app) viewStats(s *session, v view.View, opts viewOptions, now time.Time) (viewResponse, error) {
+	curr, err := app.query(v)
+	if err != nil {
+		return viewResponse{}, err
+	}
+
+	res, interval, err := s.diff(v, curr, now)
+	if err != nil {
+		return viewResponse{}, err
+	}
+
+	res, err = app.prepare(v, res, opts)
+	if err != nil {
+		return viewResponse{}, err
+	}
+
+	return viewResponse{View: v.Name, Time: now, Interval: interval.Seconds(), table: newTable(res)}, nil
+}
+
+// prepare redacts query texts if requested, then filters, orders and limits rows of view's stats. Texts are
+// redacted first, otherwise hidden literals could be guessed using filters or order.
+func (app *app) prepare(v view.View, res stat.PGresult, opts viewOptions) (stat.PGresult, error) {
+	if app.config.Redact {
+		res = stat.RedactQueries(res)
+	}
+
+	return opts.apply(v, res)
+}
+
+// systemStats collects system stats using the client's collector. Must be called with app.mu held.
+func (app *app) systemStats(s *session, now time.Time) (systemResponse, error) {
+	sys, err := app.collectSystem(s)
+	if err != nil {
+		return systemResponse{}, err
+	}
+
+	resp := systemResponse{Time: now, Stats: map[string]table{}}
+	for name, res := range sys.Results() {
+		resp.Stats[name] = newTable(res)
+	}
+
+	return resp, nil
+}
+
+// parseViewOptions parses request parameters: order=COLUMN, desc=true|false, filter=COLUMN:PATTERN (could be
+// specified several times), limit=N.
+func parseViewOptions(q url.Values) (viewOptions, error) {
+	opts := viewOptions{order: q.Get("order")}
+
+	if s := q.Get("desc"); s != "" {
+		desc, err := strconv.ParseBool(s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid desc '%s'", s)
+		}
+		opts.desc = &desc
+	}
+
+	for _, s := range q["filter"] {
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return opts, fmt.Errorf("invalid filter '%s', must be in format COLUMN:PATTERN", s)
+		}
+		re, err := regexp.Compile(parts[1])
+		if err != nil {
+			return opts, fmt.Errorf("invalid filter pattern '%s': %w", parts[1], err)
+		}
+		opts.filters = append(opts.filters, filter{column: parts[0], re: re})
+	}
+
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return opts, fmt.Errorf("invalid limit '%s'", s)
+		}
+		opts.limit = limit
+	}
+
+	return opts, nil
+}
+
+// apply filters, orders and limits rows of view's stats.
+func (o viewOptions) apply(v view.View, res stat.PGresult) (stat.PGresult, error) {
+	column := func(name string) (int, error) {
+		for i, c := range res.Cols {
+			if c == name {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("unknown column '%s'", name)
+	}
+
+	if len(o.filters) > 0 {
+		idx := make([]int, len(o.filters))
+		for i, f := range o.filters {
+			n, err := column(f.column)
+			if err != nil {
+				return res, err
+			}
+			idx[i] = n
+		}
+
+		values := make([][]sql.NullString, 0, len(res.Values))
+		for _, row := range res.Values {
+			matched := true
+			for i, f := range o.filters {
+				if !f.re.MatchString(row[idx[i]].String) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				values = append(values, row)
+			}
+		}
+		res.Values, res.Nrows = values, len(values)
+	}
+
+	if o.order != "" || o.desc != nil {
+		key, desc := v.OrderKey, v.OrderDesc
+		if o.order != "" {
+			n, err := column(o.order)
+			if err != nil {
+				return res, err
+			}
+			key, desc = n, true
+		}
+		if o.desc != nil {
+			desc = *o.desc
+		}
+		res.Sort(key, desc)
+	}
+
+	if o.limit > 0 && len(res.Values) > o.limit {
+		res.Values, res.Nrows = res.Values[:o.limit], o.limit
+	}
+
+	return res, nil
+}
+
+// newTable converts stats into JSON representation.
+func newTable(res stat.PGresult) table {
+	t := table{Columns: res.Cols, Rows: make([][]*string, len(res.Values))}
+	if t.Columns == nil {
+		t.Columns = []string{}
+	}
+
+	for i, row := range res.Values {
+		t.Rows[i] = make([]*string, len(row))
+		for j, v := range row {
+			if v.Valid {
+				s := v.String
+				t.Rows[i][j] = &s
+			}
+		}
+	}
+
+	return t
+}
+
+// clientID returns identifier of the client: value of 'client' parameter, or client's address.
+func clientID(r *http.Request) string {
+	if id := r.URL.Query().Get("client"); id != "" {
+		return id
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// writeJSON writes response encoded in JSON.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,196 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestApp creates app which returns synthetic stats. Every query increases calls counters.
+func newTestApp(config Config) *app {
+	app := newApp(config, postgres.Config{})
+	app.views = view.Views{
+		"custom": {Name: "custom", DiffIntvl: [2]int{2, 2}, OrderKey: 2, OrderDesc: true, Msg: "Show custom stats"},
+	}
+
+	var n int
+	app.query = func(v view.View) (stat.PGresult, error) {
+		n++
+		return stat.PGresult{
+			Valid: true, Ncols: 4, Nrows: 3, Cols: []string{"name", "datname", "calls", "query"},
+			Values: [][]sql.NullString{
+				{{String: "q1", Valid: true}, {String: "db1", Valid: true}, {String: fmt.Sprint(10 * n), Valid: true}, {String: "SELECT 1", Valid: true}},
+				{{String: "q2", Valid: true}, {String: "db2", Valid: true}, {String: fmt.Sprint(20 * n), Valid: true}, {String: "SELECT 'secret'", Valid: true}},
+				{{String: "q3", Valid: true}, {String: "db1", Valid: true}, {String: fmt.Sprint(30 * n), Valid: true}, {String: "", Valid: false}},
+			},
+		}, nil
+	}
+	app.collectSystem = func(s *session) (stat.System, error) {
+		return stat.System{LoadAvg: stat.LoadAvg{One: 0.5}}, nil
+	}
+
+	return app
+}
+
+func getJSON(t *testing.T, u string, v interface{}) int {
+	resp, err := http.Get(u)
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func Test_app_handleViews(t *testing.T) {
+	server := httptest.NewServer(newTestApp(Config{}).handler())
+	defer server.Close()
+
+	var views []viewInfo
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/views", &views))
+	assert.Equal(t, []viewInfo{{Name: "custom", Description: "Show custom stats"}}, views)
+}
+
+func Test_app_handleView(t *testing.T) {
+	server := httptest.NewServer(newTestApp(Config{Redact: true}).handler())
+	defer server.Close()
+
+	// The first request returns values as is.
+	var resp viewResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/views/custom?client=a", &resp))
+	assert.Equal(t, "custom", resp.View)
+	assert.Equal(t, float64(0), resp.Interval)
+	assert.Equal(t, []string{"name", "datname", "calls", "query"}, resp.Columns)
+	assert.Len(t, resp.Rows, 3)
+	assert.Equal(t, "30", *resp.Rows[0][2])
+	assert.Nil(t, resp.Rows[0][3])
+	assert.Equal(t, "SELECT $1", *resp.Rows[1][3])
+
+	// The next request of the same client returns rates, filtered and ordered as requested.
+	q := url.Values{"client": {"a"}, "filter": {"datname:db1"}, "order": {"name"}, "desc": {"false"}, "limit": {"1"}}
+	resp = viewResponse{}
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/views/custom?"+q.Encode(), &resp))
+	assert.Greater(t, resp.Interval, float64(0))
+	assert.Len(t, resp.Rows, 1)
+	assert.Equal(t, "q1", *resp.Rows[0][0])
+	assert.Equal(t, "10", *resp.Rows[0][2])
+
+	// Literals hidden by redaction could not be matched by filters.
+	q = url.Values{"client": {"a"}, "filter": {"query:secret"}}
+	resp = viewResponse{}
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/views/custom?"+q.Encode(), &resp))
+	assert.Len(t, resp.Rows, 0)
+
+	// Errors.
+	testcases := []struct {
+		path string
+		code int
+	}{
+		{path: "/views/unknown", code: http.StatusNotFound},
+		{path: "/views/custom?limit=x", code: http.StatusBadRequest},
+		{path: "/views/custom?desc=x", code: http.StatusBadRequest},
+		{path: "/views/custom?filter=datname", code: http.StatusBadRequest},
+		{path: "/views/custom?filter=datname:(", code: http.StatusBadRequest},
+		{path: "/views/custom?order=unknown", code: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testcases {
+		var e errorResponse
+		assert.Equal(t, tc.code, getJSON(t, server.URL+tc.path, &e), tc.path)
+		assert.NotEmpty(t, e.Error)
+	}
+}
+
+func Test_app_handleSystem(t *testing.T) {
+	server := httptest.NewServer(newTestApp(Config{}).handler())
+	defer server.Close()
+
+	var resp systemResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/system", &resp))
+	assert.Len(t, resp.Stats, len(stat.SystemResultNames))
+	assert.Equal(t, "0.5", *resp.Stats["loadavg"].Rows[0][0])
+}
+
+func Test_app_handleEvents(t *testing.T) {
+	app := newTestApp(Config{Interval: time.Second})
+	server := httptest.NewServer(app.handler())
+	defer server.Close()
+
+	var e errorResponse
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server.URL+"/events", &e))
+	assert.Equal(t, http.StatusNotFound, getJSON(t, server.URL+"/events?view=unknown", &e))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server.URL+"/events?view=custom&interval=1ms", &e))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events?view=custom&system=true&interval=1s", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Read two updates of the view and system stats.
+	r := bufio.NewReader(resp.Body)
+	var events []string
+	var views []viewResponse
+	for len(events) < 4 {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		if strings.HasPrefix(line, "event: ") {
+			events = append(events, strings.TrimSpace(strings.TrimPrefix(line, "event: ")))
+		}
+		if strings.HasPrefix(line, "data: ") && events[len(events)-1] == "view" {
+			var v viewResponse
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &v))
+			views = append(views, v)
+		}
+	}
+
+	assert.Equal(t, []string{"view", "system", "view", "system"}, events)
+	assert.Len(t, views, 2)
+	assert.Equal(t, float64(0), views[0].Interval)
+	assert.Greater(t, views[1].Interval, float64(0))
+	assert.Equal(t, "30", *views[1].Rows[0][2])
+}
+
+func Test_app_setup(t *testing.T) {
+	dbConfig, err := postgres.NewTestConfig()
+	assert.NoError(t, err)
+
+	db, err := postgres.Connect(dbConfig)
+	if err != nil {
+		t.Skipf("postgres is not available: %s", err)
+	}
+	db.Close()
+
+	app := newApp(Config{}, dbConfig)
+	app.out = io.Discard
+	assert.NoError(t, app.setup())
+	defer app.db.Close()
+
+	assert.Contains(t, app.views, "databases_general")
+	assert.NotContains(t, app.views, "procpidstat")
+
+	resp, err := app.viewStats(newSession(), app.views["databases_general"], viewOptions{}, time.Now())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Rows)
+}
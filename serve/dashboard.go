@@ -0,0 +1,366 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// static contains assets of the dashboard, they are served without any external dependencies.
+//
+//go:embed static
+var static embed.FS
+
+// dashboardSystemNames defines system stats shown in summary panels of the dashboard, as in 'pgcenter top'.
+var dashboardSystemNames = []string{"loadavg", "cpu", "memory"}
+
+// activity defines Postgres activity summary shown in summary panels of the dashboard.
+type activity struct {
+	State        string  `json:"state"`
+	Uptime       string  `json:"uptime"`
+	Recovery     string  `json:"recovery"`
+	ConnTotal    int     `json:"conn_total"`
+	ConnIdle     int     `json:"conn_idle"`
+	ConnIdleXact int     `json:"conn_idle_xact"`
+	ConnActive   int     `json:"conn_active"`
+	ConnWaiting  int     `json:"conn_waiting"`
+	ConnOthers   int     `json:"conn_others"`
+	ConnPrepared int     `json:"conn_prepared"`
+	AVWorkers    int     `json:"av_workers"`
+	AVAntiwrap   int     `json:"av_antiwrap"`
+	AVUser       int     `json:"av_user"`
+	XactMaxTime  string  `json:"xact_max_time"`
+	PrepMaxTime  string  `json:"prep_max_time"`
+	AVMaxTime    string  `json:"av_max_time"`
+	StmtAvgTime  float32 `json:"stmt_avg_time"`
+	CallsRate    int     `json:"calls_rate"`
+}
+
+// dashboardResponse defines single update of the dashboard: summary and stats of the view.
+type dashboardResponse struct {
+	View     string           `json:"view"`
+	Time     time.Time        `json:"time"`
+	Interval float64          `json:"interval"`
+	Actions  bool             `json:"actions"`          // cancel, terminate and reload are enabled
+	System   map[string]table `json:"system,omitempty"` // omitted if system stats are not available
+	Activity activity         `json:"activity"`
+	table
+}
+
+// tokenHeader defines header which dashboard uses for sending the token with requests of actions.
+const tokenHeader = "X-Pgcenter-Token"
+
+// tokenPlaceholder defines element of the dashboard page where the token is placed when the page is served.
+const tokenPlaceholder = `<meta name="pgcenter-token" content="">`
+
+// actionResponse defines result of the action.
+type actionResponse struct {
+	Message string `json:"message"`
+}
+
+// dashboardHandler registers handlers of the dashboard: static assets, stream of updates and actions.
+func (app *app) dashboardHandler(mux *http.ServeMux) {
+	assets, _ := fs.Sub(static, "static")
+	mux.Handle("GET /", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("GET /{$}", app.handleDashboardPage)
+	mux.HandleFunc("GET /dashboard/events", app.handleDashboardEvents)
+	mux.HandleFunc("POST /actions/{action}", app.handleAction)
+}
+
+// handleDashboardPage serves the dashboard page with the token required for performing actions.
+func (app *app) handleDashboardPage(w http.ResponseWriter, r *http.Request) {
+	// Page contains the token, don't give it to pages of other sites which host names resolve to this server.
+	if app.config.Actions && !app.allowedHost(r.Host) {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: fmt.Sprintf("host '%s' is not allowed", r.Host)})
+		return
+	}
+
+	data, err := static.ReadFile("static/index.html")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	page := strings.Replace(string(data), tokenPlaceholder, fmt.Sprintf(`<meta name="pgcenter-token" content="%s">`, app.token), 1)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = io.WriteString(w, page)
+}
+
+// handleDashboardEvents streams summary and stats of the view as server-sent events. Stats are collected by
+// the same collector which is used by 'pgcenter top', every stream has its own collector.
+func (app *app) handleDashboardEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	v, ok := app.views[q.Get("view")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown view '%s'", q.Get("view"))})
+		return
+	}
+
+	interval := app.config.Interval
+	if s := q.Get("interval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < time.Second {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid interval '%s', must be at least 1s", s)})
+			return
+		}
+		interval = d
+	}
+
+	opts, err := parseViewOptions(q)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming is not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	s := newSession()
+
+	// Run first update to prefill "previous" snapshot, as 'pgcenter top' does.
+	app.mu.Lock()
+	_, err = app.collect(s, v, interval)
+	app.mu.Unlock()
+	if err != nil {
+		_ = send("error", errorResponse{Error: err.Error()})
+	}
+
+	// Don't wait for the whole interval before the first update.
+	wait := time.NewTimer(100 * time.Millisecond)
+	defer wait.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-app.ctx.Done():
+			return
+		case <-wait.C:
+		}
+
+		app.mu.Lock()
+		resp, err := app.dashboardStats(s, v, opts, interval, time.Now())
+		app.mu.Unlock()
+
+		if err != nil {
+			err = send("error", errorResponse{Error: err.Error()})
+		} else {
+			err = send("dashboard", resp)
+		}
+		if err != nil {
+			return
+		}
+
+		wait.Reset(interval)
+	}
+}
+
+// dashboardStats collects summary and stats of the view using the stream's collector. Must be called with
+// app.mu held.
+func (app *app) dashboardStats(s *session, v view.View, opts viewOptions, interval time.Duration, now time.Time) (dashboardResponse, error) {
+	st, err := app.collect(s, v, interval)
+	if err != nil {
+		return dashboardResponse{}, err
+	}
+
+	res, err := app.prepare(v, st.Pgstat.Result, opts)
+	if err != nil {
+		return dashboardResponse{}, err
+	}
+
+	a := st.Pgstat.Activity
+	resp := dashboardResponse{
+		View:     v.Name,
+		Time:     now,
+		Interval: interval.Seconds(),
+		Actions:  app.config.Actions,
+		Activity: activity{
+			State: a.State, Uptime: a.Uptime, Recovery: a.Recovery,
+			ConnTotal: a.ConnTotal, ConnIdle: a.ConnIdle, ConnIdleXact: a.ConnIdleXact, ConnActive: a.ConnActive,
+			ConnWaiting: a.ConnWaiting, ConnOthers: a.ConnOthers, ConnPrepared: a.ConnPrepared,
+			AVWorkers: a.AVWorkers, AVAntiwrap: a.AVAntiwrap, AVUser: a.AVUser,
+			XactMaxTime: a.XactMaxTime, PrepMaxTime: a.PrepMaxTime, AVMaxTime: a.AVMaxTime,
+			StmtAvgTime: a.StmtAvgTime, CallsRate: a.CallsRate,
+		},
+		table: newTable(res),
+	}
+
+	if app.system {
+		results := st.System.Results()
+		resp.System = map[string]table{}
+		for _, name := range dashboardSystemNames {
+			resp.System[name] = newTable(results[name])
+		}
+	}
+
+	return resp, nil
+}
+
+// collectStat collects stats using the same collector which is used by 'pgcenter top'. Collector is created
+// at first call.
+func (app *app) collectStat(s *session, v view.View, interval time.Duration) (stat.Stat, error) {
+	if s.collector == nil {
+		c, err := stat.NewCollector(app.db)
+		if err != nil {
+			return stat.Stat{}, err
+		}
+		s.collector = c
+	}
+
+	return s.collector.Update(app.db, v, interval)
+}
+
+// handleAction performs the action requested from the dashboard: 'cancel' or 'terminate' the backend specified
+// by 'pid' parameter, or 'reload' Postgres configuration. Actions are allowed only if they are explicitly enabled.
+func (app *app) handleAction(w http.ResponseWriter, r *http.Request) {
+	if !app.config.Actions {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "actions are disabled"})
+		return
+	}
+
+	// Requests from pages of other sites are not allowed to perform actions, including pages which host names
+	// resolve to this server (DNS rebinding). Browsers always send Origin with POST requests made by fetch().
+	if !app.allowedHost(r.Host) {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: fmt.Sprintf("host '%s' is not allowed", r.Host)})
+		return
+	}
+
+	u, err := url.Parse(r.Header.Get("Origin"))
+	if err != nil || u.Host == "" || u.Host != r.Host {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "cross-origin requests are not allowed"})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(tokenHeader)), []byte(app.token)) != 1 {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "invalid token"})
+		return
+	}
+
+	action := r.PathValue("action")
+
+	var pid int
+	switch action {
+	case "cancel", "terminate":
+		n, err := strconv.Atoi(r.URL.Query().Get("pid"))
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid pid '%s'", r.URL.Query().Get("pid"))})
+			return
+		}
+		pid = n
+	case "reload":
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown action '%s'", action)})
+		return
+	}
+
+	app.mu.Lock()
+	msg, err := app.act(action, pid)
+	app.mu.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	_, _ = fmt.Fprintf(app.out, "INFO: %s requested by %s\n", msg, r.RemoteAddr)
+
+	writeJSON(w, http.StatusOK, actionResponse{Message: msg})
+}
+
+// execAction sends cancel or terminate signal to the backend, or reloads Postgres configuration.
+func (app *app) execAction(action string, pid int) (string, error) {
+	var q string
+	switch action {
+	case "cancel":
+		q = query.ExecCancelQuery
+	case "terminate":
+		q = query.ExecTerminateBackend
+	case "reload":
+		var status sql.NullBool
+		err := app.db.QueryRow(query.ExecReloadConf).Scan(&status)
+		if err != nil {
+			return "", fmt.Errorf("reload failed: %w", err)
+		}
+		return "reload configuration", nil
+	default:
+		return "", fmt.Errorf("unknown action '%s'", action)
+	}
+
+	var ok sql.NullBool
+	err := app.db.QueryRow(q, pid).Scan(&ok)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", action, err)
+	}
+	if !ok.Bool {
+		return "", fmt.Errorf("%s failed: backend with pid %d not found", action, pid)
+	}
+
+	return fmt.Sprintf("%s backend %d", action, pid), nil
+}
+
+// allowedHost returns true if the host from the request is the host of listen address or loopback host. Other
+// names are not allowed, they might be names of attacker's site which resolve to this server.
+func (app *app) allowedHost(host string) bool {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+
+	if name == "" {
+		return false
+	}
+
+	if strings.EqualFold(name, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(name); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	listen, _, err := net.SplitHostPort(app.config.Listen)
+	if err != nil || listen == "" {
+		return false
+	}
+	if ip := net.ParseIP(listen); ip != nil && ip.IsUnspecified() {
+		return false
+	}
+
+	return strings.EqualFold(name, listen)
+}
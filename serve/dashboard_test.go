@@ -0,0 +1,271 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestDashboardApp creates app which returns synthetic stats collected by the session's collector.
+func newTestDashboardApp(config Config) *app {
+	config.Dashboard = true
+	app := newTestApp(config)
+	app.out = io.Discard
+	app.system = true
+
+	app.collect = func(s *session, v view.View, interval time.Duration) (stat.Stat, error) {
+		res, err := app.query(v)
+		if err != nil {
+			return stat.Stat{}, err
+		}
+		return stat.Stat{
+			System: stat.System{LoadAvg: stat.LoadAvg{One: 0.5}},
+			Pgstat: stat.Pgstat{Activity: stat.Activity{State: "up", ConnTotal: 3}, Result: res},
+		}, nil
+	}
+
+	return app
+}
+
+func Test_app_dashboardStatic(t *testing.T) {
+	app := newTestDashboardApp(Config{})
+	server := httptest.NewServer(app.handler())
+	defer server.Close()
+
+	testcases := []struct {
+		path     string
+		contains string
+	}{
+		{path: "/", contains: `<script src="app.js"></script>`},
+		{path: "/", contains: `<meta name="pgcenter-token" content="` + app.token + `">`},
+		{path: "/app.js", contains: "EventSource"},
+		{path: "/style.css", contains: "table"},
+	}
+
+	for _, tc := range testcases {
+		resp, err := http.Get(server.URL + tc.path)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, tc.path)
+		assert.Contains(t, string(data), tc.contains, tc.path)
+	}
+
+	// Page with the token is not served to other hosts when actions are enabled.
+	app.config.Actions = true
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	assert.NoError(t, err)
+	req.Host = "attacker.example.org"
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.NotContains(t, string(data), app.token)
+
+	// Dashboard is not served by 'pgcenter serve'.
+	server = httptest.NewServer(newTestApp(Config{}).handler())
+	defer server.Close()
+
+	resp, err = http.Get(server.URL + "/")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_app_handleDashboardEvents(t *testing.T) {
+	app := newTestDashboardApp(Config{Interval: time.Second, Redact: true})
+	server := httptest.NewServer(app.handler())
+	defer server.Close()
+
+	var e errorResponse
+	assert.Equal(t, http.StatusNotFound, getJSON(t, server.URL+"/dashboard/events?view=unknown", &e))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server.URL+"/dashboard/events?view=custom&interval=1ms", &e))
+	assert.Equal(t, http.StatusBadRequest, getJSON(t, server.URL+"/dashboard/events?view=custom&limit=x", &e))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/dashboard/events?view=custom&filter=datname:db2", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+	var event string
+	var d dashboardResponse
+	for {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		}
+		if strings.HasPrefix(line, "data: ") {
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &d))
+			break
+		}
+	}
+
+	assert.Equal(t, "dashboard", event)
+	assert.Equal(t, "custom", d.View)
+	assert.Equal(t, float64(1), d.Interval)
+	assert.False(t, d.Actions)
+	assert.Equal(t, "up", d.Activity.State)
+	assert.Equal(t, 3, d.Activity.ConnTotal)
+	assert.Len(t, d.System, len(dashboardSystemNames))
+	assert.Equal(t, "0.5", *d.System["loadavg"].Rows[0][0])
+	assert.Len(t, d.Rows, 1)
+	assert.Equal(t, "q2", *d.Rows[0][0])
+	assert.Equal(t, "SELECT $1", *d.Rows[0][3])
+}
+
+func Test_app_dashboardStats(t *testing.T) {
+	app := newTestDashboardApp(Config{Actions: true})
+	app.system = false
+
+	resp, err := app.dashboardStats(newSession(), app.views["custom"], viewOptions{limit: 2}, time.Second, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, resp.Actions)
+	assert.Nil(t, resp.System)
+	assert.Len(t, resp.Rows, 2)
+
+	// Literals hidden by redaction could not be matched by filters.
+	app.config.Redact = true
+	resp, err = app.dashboardStats(newSession(), app.views["custom"], viewOptions{filters: []filter{{column: "query", re: regexp.MustCompile("secret")}}}, time.Second, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, resp.Rows, 0)
+
+	app.collect = func(s *session, v view.View, interval time.Duration) (stat.Stat, error) {
+		return stat.Stat{}, fmt.Errorf("failed")
+	}
+	_, err = app.dashboardStats(newSession(), app.views["custom"], viewOptions{}, time.Second, time.Now())
+	assert.Error(t, err)
+}
+
+func Test_app_handleAction(t *testing.T) {
+	post := func(u string, host string, origin string, token string) (int, map[string]string) {
+		req, err := http.NewRequest(http.MethodPost, u, nil)
+		assert.NoError(t, err)
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if token != "" {
+			req.Header.Set(tokenHeader, token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		var v map[string]string
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+		return resp.StatusCode, v
+	}
+
+	// Actions are disabled by default.
+	app := newTestDashboardApp(Config{})
+	var performed []string
+	app.act = func(action string, pid int) (string, error) {
+		performed = append(performed, fmt.Sprintf("%s %d", action, pid))
+		return fmt.Sprintf("%s done", action), nil
+	}
+	server := httptest.NewServer(app.handler())
+
+	code, _ := post(server.URL+"/actions/reload", "", server.URL, app.token)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Empty(t, performed)
+	server.Close()
+
+	// Actions are enabled explicitly.
+	app.config.Actions = true
+	server = httptest.NewServer(app.handler())
+	defer server.Close()
+
+	attacker := "attacker.example.org:" + strings.TrimPrefix(server.URL, "http://127.0.0.1:")
+
+	testcases := []struct {
+		path   string
+		host   string
+		origin string
+		token  string
+		code   int
+		want   string
+	}{
+		{path: "/actions/cancel?pid=123", origin: server.URL, token: app.token, code: http.StatusOK, want: "cancel 123"},
+		{path: "/actions/terminate?pid=456", origin: server.URL, token: app.token, code: http.StatusOK, want: "terminate 456"},
+		{path: "/actions/reload", origin: server.URL, token: app.token, code: http.StatusOK, want: "reload 0"},
+		{path: "/actions/cancel?pid=x", origin: server.URL, token: app.token, code: http.StatusBadRequest},
+		{path: "/actions/terminate", origin: server.URL, token: app.token, code: http.StatusBadRequest},
+		{path: "/actions/unknown", origin: server.URL, token: app.token, code: http.StatusNotFound},
+		{path: "/actions/reload", origin: "http://example.org", token: app.token, code: http.StatusForbidden},
+		{path: "/actions/reload", token: app.token, code: http.StatusForbidden},                                               // no origin
+		{path: "/actions/reload", origin: server.URL, code: http.StatusForbidden},                                             // no token
+		{path: "/actions/reload", origin: server.URL, token: "invalid", code: http.StatusForbidden},                           // wrong token
+		{path: "/actions/reload", host: attacker, origin: "http://" + attacker, token: app.token, code: http.StatusForbidden}, // DNS rebinding
+	}
+
+	for _, tc := range testcases {
+		performed = nil
+		code, resp := post(server.URL+tc.path, tc.host, tc.origin, tc.token)
+		assert.Equal(t, tc.code, code, tc.path)
+		if tc.want != "" {
+			assert.Equal(t, []string{tc.want}, performed, tc.path)
+			assert.NotEmpty(t, resp["message"])
+		} else {
+			assert.Empty(t, performed, tc.path)
+			assert.NotEmpty(t, resp["error"])
+		}
+	}
+
+	// Actions are not available via GET.
+	performed = nil
+	resp, err := http.Get(server.URL + "/actions/reload")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, performed)
+}
+
+func Test_app_allowedHost(t *testing.T) {
+	testcases := []struct {
+		listen string
+		host   string
+		want   bool
+	}{
+		{listen: "127.0.0.1:8080", host: "127.0.0.1:8080", want: true},
+		{listen: "127.0.0.1:8080", host: "localhost:8080", want: true},
+		{listen: "127.0.0.1:8080", host: "[::1]:8080", want: true},
+		{listen: "127.0.0.1:8080", host: "localhost", want: true},
+		{listen: "127.0.0.1:8080", host: "attacker.example.org:8080", want: false},
+		{listen: ":8080", host: "db1.example.org:8080", want: false},
+		{listen: "0.0.0.0:8080", host: "0.0.0.0:8080", want: false},
+		{listen: "db1.example.org:8080", host: "db1.example.org:8080", want: true},
+		{listen: "10.0.0.1:8080", host: "10.0.0.1:8080", want: true},
+		{listen: "10.0.0.1:8080", host: "", want: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			app := newTestApp(Config{Listen: tc.listen})
+			assert.Equal(t, tc.want, app.allowedHost(tc.host))
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// 'pgcenter serve' - serves live Postgres and system stats over HTTP JSON API.
+
+package serve
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// sessionTTL defines time after which state of inactive client is forgotten.
+const sessionTTL = 10 * time.Minute
+
+// Config defines config container for configuring 'pgcenter serve'.
+type Config struct {
+	Listen      string        // Address to listen for API requests
+	Interval    time.Duration // Default interval of updates sent to events streams
+	StringLimit int           // Limit of the length, to which query should be trimmed
+	Redact      bool          // Replace literals in query texts with placeholders
+	Dashboard   bool          // Serve web dashboard in addition to API
+	Actions     bool          // Allow cancelling and terminating backends, and reloading configuration from dashboard
+}
+
+// RunMain is the 'pgcenter serve' main entry point.
+func RunMain(dbConfig postgres.Config, config Config) error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", config.Interval)
+	}
+
+	app := newApp(config, dbConfig)
+
+	err := app.setup()
+	if err != nil {
+		return err
+	}
+	defer app.db.Close()
+
+	server := &http.Server{
+		Addr:              config.Listen,
+		Handler:           app.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// In case of SIGINT stop program gracefully
+	doQuit := make(chan os.Signal, 1)
+	signal.Notify(doQuit, os.Interrupt)
+
+	go func() {
+		<-doQuit
+		// Events streams are never finished by clients, close them when server is stopped.
+		app.cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	_, _ = fmt.Fprintf(app.out, "INFO: serving %d views on %s\n", len(app.views), config.Listen)
+	if config.Dashboard {
+		_, _ = fmt.Fprintf(app.out, "INFO: serving dashboard on %s\n", config.Listen)
+		if config.Actions {
+			_, _ = fmt.Fprintln(app.out, "INFO: cancelling and terminating backends, and reloading configuration are enabled")
+		}
+	}
+
+	err = server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// app defines 'pgcenter serve' runtime dependencies.
+type app struct {
+	config   Config
+	dbConfig postgres.Config
+	views    view.Views
+	db       *postgres.DB
+	system   bool      // system stats are available
+	out      io.Writer // where informational messages are printed
+	// ctx is cancelled when server is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mu serializes access to database connection and clients sessions
+	mu       sync.Mutex
+	sessions map[string]*session
+	// query returns stats of the view, collectSystem returns system stats using the client's collector
+	query         func(v view.View) (stat.PGresult, error)
+	collectSystem func(s *session) (stat.System, error)
+	// collect returns stats collected by the session's collector, act performs dashboard's action
+	collect func(s *session, v view.View, interval time.Duration) (stat.Stat, error)
+	act     func(action string, pid int) (string, error)
+	// token is passed to the dashboard page and required in requests of actions
+	token string
+}
+
+// newApp creates new 'pgcenter serve' app.
+func newApp(config Config, dbConfig postgres.Config) *app {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app := &app{
+		config:   config,
+		dbConfig: dbConfig,
+		out:      os.Stdout,
+		ctx:      ctx,
+		cancel:   cancel,
+		sessions: map[string]*session{},
+		token:    newToken(),
+	}
+	app.query = app.queryView
+	app.collectSystem = app.querySystem
+	app.collect = app.collectStat
+	app.act = app.execAction
+
+	return app
+}
+
+// newToken returns random token used for authorizing dashboard's actions.
+func newToken() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(fmt.Sprintf("generate token failed: %s", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// setup connects to Postgres and configures views depending on Postgres version.
+func (app *app) setup() error {
+	db, err := postgres.Connect(app.dbConfig)
+	if err != nil {
+		return err
+	}
+
+	props, err := stat.GetPostgresProperties(db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, app.config.StringLimit, props.ExtPGSSSchema)
+
+	// Per-process stats are collected from procfs of Postgres backends, they are not served.
+	views := view.New()
+	delete(views, "procpidstat")
+
+	views = views.Supported(props.VersionNum, props.ExtPGSSSchema)
+	if props.ExtPGSSSchema == "" {
+		_, _ = fmt.Fprintln(app.out, "INFO: pg_stat_statements not found, skip serving it")
+	}
+
+	err = views.Configure(opts)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	// System stats are available for local Postgres, or remote Postgres with installed pgcenter schema.
+	app.system = db.Local || props.SchemaPgcenterAvail
+	if !app.system {
+		_, _ = fmt.Fprintln(app.out, "INFO: pgcenter schema not found, skip serving system stats")
+	}
+
+	app.db = db
+	app.views = views
+
+	return nil
+}
+
+// queryView queries stats of the view.
+func (app *app) queryView(v view.View) (stat.PGresult, error) {
+	err := app.db.PQstatus()
+	if err != nil {
+		err = postgres.Reconnect(app.db)
+		if err != nil {
+			return stat.PGresult{}, err
+		}
+	}
+
+	return stat.NewPGresultQuery(app.db, v.Query)
+}
+
+// querySystem collects system stats, usage stats are calculated since the previous request of the client.
+func (app *app) querySystem(s *session) (stat.System, error) {
+	if !app.system {
+		return stat.System{}, fmt.Errorf("system stats are not available")
+	}
+
+	if s.collector == nil {
+		c, err := stat.NewCollector(app.db)
+		if err != nil {
+			return stat.System{}, err
+		}
+		s.collector = c
+	}
+
+	return s.collector.UpdateSystem(app.db)
+}
+
+// session returns state of the client, new state is created for unknown clients. State of inactive clients is
+// forgotten. Must be called with app.mu held.
+func (app *app) session(id string, now time.Time) *session {
+	for k, s := range app.sessions {
+		if now.Sub(s.lastSeen) > sessionTTL {
+			delete(app.sessions, k)
+		}
+	}
+
+	s, ok := app.sessions[id]
+	if !ok {
+		s = newSession()
+		app.sessions[id] = s
+	}
+	s.lastSeen = now
+
+	return s
+}
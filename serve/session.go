@@ -0,0 +1,68 @@
+package serve
+
+import (
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"time"
+)
+
+// session defines state of single client: previous snapshots of requested views used for calculating deltas,
+// and collector of system stats.
+type session struct {
+	views     map[string]*viewState
+	collector *stat.Collector
+	lastSeen  time.Time
+}
+
+// viewState defines previous snapshot of the view.
+type viewState struct {
+	prev     stat.PGresult
+	prevTime time.Time
+}
+
+// newSession creates new client session.
+func newSession() *session {
+	return &session{views: map[string]*viewState{}}
+}
+
+// diff calculates delta between the current snapshot of the view and the previous snapshot requested by the
+// client, and remembers the current snapshot. Returns interval between snapshots, zero interval means there is no
+// previous snapshot and values are returned as is.
+func (s *session) diff(v view.View, curr stat.PGresult, now time.Time) (stat.PGresult, time.Duration, error) {
+	state, ok := s.views[v.Name]
+	if !ok {
+		state = &viewState{}
+		s.views[v.Name] = state
+	}
+
+	prev, prevTime := state.prev, state.prevTime
+
+	// Columns might be changed, e.g. after Postgres upgrade; start from scratch.
+	if prev.Valid && prev.Ncols != curr.Ncols {
+		prev = stat.PGresult{}
+	}
+
+	// Rates are calculated per second, as in 'pgcenter top'.
+	var interval time.Duration
+	itv := 1
+	if prev.Valid {
+		interval = now.Sub(prevTime)
+		if n := int(interval.Round(time.Second) / time.Second); n > 1 {
+			itv = n
+		}
+	}
+
+	res, err := stat.Compare(curr, prev, itv, v.DiffIntvl, v.OrderKey, v.OrderDesc, v.UniqueKey)
+	if err != nil {
+		return stat.PGresult{}, 0, err
+	}
+
+	// Snapshots without previous one are not ordered by comparing.
+	if !prev.Valid {
+		res.Sort(v.OrderKey, v.OrderDesc)
+	}
+
+	state.prev, state.prevTime = curr, now
+
+	return res, interval, nil
+}
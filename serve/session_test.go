@@ -0,0 +1,48 @@
+package serve
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_session_diff(t *testing.T) {
+	v := view.View{Name: "custom", DiffIntvl: [2]int{1, 1}, OrderKey: 1, OrderDesc: true}
+	mkRes := func(q1, q2 string) stat.PGresult {
+		return stat.PGresult{
+			Valid: true, Ncols: 2, Nrows: 2, Cols: []string{"name", "calls"},
+			Values: [][]sql.NullString{
+				{{String: "q1", Valid: true}, {String: q1, Valid: true}},
+				{{String: "q2", Valid: true}, {String: q2, Valid: true}},
+			},
+		}
+	}
+
+	s := newSession()
+	ts := time.Date(2026, 5, 19, 10, 0, 0, 0, time.UTC)
+
+	// The first snapshot is returned as is, ordered by view's order key.
+	res, interval, err := s.diff(v, mkRes("10", "20"), ts)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), interval)
+	assert.Equal(t, "20", res.Values[0][1].String)
+
+	// Rates are calculated over interval since the previous snapshot.
+	res, interval, err = s.diff(v, mkRes("50", "30"), ts.Add(2*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, interval)
+	assert.Equal(t, [][]sql.NullString{
+		{{String: "q1", Valid: true}, {String: "20", Valid: true}},
+		{{String: "q2", Valid: true}, {String: "5", Valid: true}},
+	}, res.Values)
+
+	// Another client has its own state.
+	res, interval, err = newSession().diff(v, mkRes("50", "30"), ts.Add(2*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), interval)
+	assert.Equal(t, "50", res.Values[0][1].String)
+}
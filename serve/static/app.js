@@ -0,0 +1,290 @@
+// pgcenter web dashboard: renders summary panels and stats of the selected view, received from the stream of
+// server-sent events. Sorting and filtering are applied in the browser to the latest received update.
+(function () {
+  "use strict";
+
+  const el = {
+    view: document.getElementById("view"),
+    interval: document.getElementById("interval"),
+    filter: document.getElementById("filter"),
+    reload: document.getElementById("reload"),
+    status: document.getElementById("status"),
+    description: document.getElementById("description"),
+    thead: document.querySelector("#stats thead"),
+    tbody: document.querySelector("#stats tbody"),
+    postgres: document.querySelector("#panel-postgres dl"),
+    activity: document.querySelector("#panel-activity dl"),
+    system: document.querySelector("#panel-system dl"),
+  };
+
+  const params = new URLSearchParams(window.location.search);
+  // token is placed into the page by the server, it is required for performing actions.
+  const token = document.querySelector('meta[name="pgcenter-token"]').content;
+  const state = {
+    views: {},
+    source: null,
+    last: null,
+    sortColumn: null, // null means order of the view
+    sortDesc: true,
+  };
+
+  function setStatus(text, error) {
+    el.status.textContent = text;
+    el.status.className = error ? "error" : "";
+  }
+
+  // fillList renders list of name/value pairs.
+  function fillList(dl, items) {
+    dl.replaceChildren();
+    for (const [name, value] of items) {
+      const dt = document.createElement("dt");
+      dt.textContent = name;
+      const dd = document.createElement("dd");
+      dd.textContent = value;
+      dl.append(dt, dd);
+    }
+  }
+
+  // tableValue returns value of the column in the first row of the table.
+  function tableValue(t, column) {
+    if (!t || t.rows.length === 0) {
+      return "";
+    }
+    const i = t.columns.indexOf(column);
+    return i < 0 || t.rows[0][i] === null ? "" : t.rows[0][i];
+  }
+
+  function renderSummary(d) {
+    const a = d.activity;
+    fillList(el.postgres, [
+      ["state", a.state],
+      ["uptime", a.uptime],
+      ["recovery", a.recovery],
+      ["calls/s", a.calls_rate],
+      ["stmt avg, ms", a.stmt_avg_time.toFixed(3)],
+    ]);
+    fillList(el.activity, [
+      ["conns", `${a.conn_total} total, ${a.conn_idle} idle, ${a.conn_idle_xact} idle_xact, ${a.conn_active} active, ` +
+        `${a.conn_waiting} waiting, ${a.conn_others} others, ${a.conn_prepared} prepared`],
+      ["autovacuum", `${a.av_workers} workers, ${a.av_antiwrap} antiwrap, ${a.av_user} manual`],
+      ["xact max", a.xact_max_time],
+      ["prep max", a.prep_max_time],
+      ["vacuum max", a.av_max_time],
+    ]);
+
+    if (!d.system) {
+      el.system.parentElement.hidden = true;
+      return;
+    }
+    el.system.parentElement.hidden = false;
+
+    const s = d.system;
+    const cpu = ["user", "system", "iowait", "idle"].map((c) => `${tableValue(s.cpu, c + ",%")} ${c}`);
+    fillList(el.system, [
+      ["load avg", ["load1", "load5", "load15"].map((c) => tableValue(s.loadavg, c)).join(", ")],
+      ["cpu, %", cpu.join(", ")],
+      ["mem, MiB", `${tableValue(s.memory, "total,MiB")} total, ${tableValue(s.memory, "used,MiB")} used, ` +
+        `${tableValue(s.memory, "cached,MiB")} cached, ${tableValue(s.memory, "free,MiB")} free`],
+      ["swap, MiB", `${tableValue(s.memory, "swap_total,MiB")} total, ${tableValue(s.memory, "swap_used,MiB")} used`],
+    ]);
+  }
+
+  // parseFilter parses text of filter: 'column:regexp' filters by values of the column, otherwise rows which
+  // contain the text in any column are shown.
+  function parseFilter(text, columns) {
+    text = text.trim();
+    if (text === "") {
+      return () => true;
+    }
+
+    const sep = text.indexOf(":");
+    if (sep > 0 && columns.includes(text.slice(0, sep))) {
+      const i = columns.indexOf(text.slice(0, sep));
+      let re;
+      try {
+        re = new RegExp(text.slice(sep + 1));
+      } catch (e) {
+        return () => true;
+      }
+      return (row) => re.test(row[i] === null ? "" : row[i]);
+    }
+
+    const needle = text.toLowerCase();
+    return (row) => row.some((v) => v !== null && v.toLowerCase().includes(needle));
+  }
+
+  function compare(a, b) {
+    if (a === b) {
+      return 0;
+    }
+    if (a === null) {
+      return -1;
+    }
+    if (b === null) {
+      return 1;
+    }
+    const x = Number(a);
+    const y = Number(b);
+    if (a !== "" && b !== "" && !isNaN(x) && !isNaN(y)) {
+      return x - y;
+    }
+    return a.localeCompare(b);
+  }
+
+  function renderTable() {
+    const d = state.last;
+    if (!d) {
+      return;
+    }
+
+    // Header.
+    const tr = document.createElement("tr");
+    d.columns.forEach((name) => {
+      const th = document.createElement("th");
+      th.textContent = name;
+      if (name === state.sortColumn) {
+        th.className = "sorted " + (state.sortDesc ? "desc" : "asc");
+      }
+      th.addEventListener("click", () => {
+        if (state.sortColumn === name) {
+          state.sortDesc = !state.sortDesc;
+        } else {
+          state.sortColumn = name;
+          state.sortDesc = true;
+        }
+        renderTable();
+      });
+      tr.append(th);
+    });
+
+    const pidIdx = d.columns.indexOf("pid");
+    const actions = d.actions && pidIdx >= 0;
+    if (actions) {
+      const th = document.createElement("th");
+      th.textContent = "actions";
+      tr.append(th);
+    }
+    el.thead.replaceChildren(tr);
+
+    // Rows.
+    let rows = d.rows.filter(parseFilter(el.filter.value, d.columns));
+    const sortIdx = d.columns.indexOf(state.sortColumn);
+    if (sortIdx >= 0) {
+      rows = rows.slice().sort((a, b) => compare(a[sortIdx], b[sortIdx]) * (state.sortDesc ? -1 : 1));
+    }
+
+    const body = document.createDocumentFragment();
+    for (const row of rows) {
+      const tr = document.createElement("tr");
+      for (const v of row) {
+        const td = document.createElement("td");
+        if (v === null) {
+          td.className = "null";
+        } else {
+          td.textContent = v;
+          td.title = v;
+        }
+        tr.append(td);
+      }
+      if (actions) {
+        const td = document.createElement("td");
+        td.className = "actions";
+        for (const action of ["cancel", "terminate"]) {
+          const b = document.createElement("button");
+          b.className = "action";
+          b.textContent = action;
+          b.addEventListener("click", () => act(action, row[pidIdx]));
+          td.append(b);
+        }
+        tr.append(td);
+      }
+      body.append(tr);
+    }
+    el.tbody.replaceChildren(body);
+  }
+
+  // act asks for confirmation and performs the action.
+  async function act(action, pid) {
+    const what = action === "reload" ? "reload Postgres configuration" : `${action} backend with pid ${pid}`;
+    if (!window.confirm(`Do you really want to ${what}?`)) {
+      return;
+    }
+
+    const u = action === "reload" ? "actions/reload" : `actions/${action}?pid=${encodeURIComponent(pid)}`;
+    try {
+      const resp = await fetch(u, { method: "POST", headers: { "X-Pgcenter-Token": token } });
+      const data = await resp.json();
+      setStatus(resp.ok ? data.message : data.error, !resp.ok);
+    } catch (e) {
+      setStatus(`${action} failed: ${e}`, true);
+    }
+  }
+
+  function connect() {
+    if (state.source) {
+      state.source.close();
+    }
+    state.last = null;
+    el.thead.replaceChildren();
+    el.tbody.replaceChildren();
+
+    const name = el.view.value;
+    el.description.textContent = state.views[name] || "";
+
+    // Interval of updates specified at start of 'pgcenter web' is used by default.
+    const q = new URLSearchParams({ view: name });
+    if (el.interval.value !== "") {
+      q.set("interval", el.interval.value);
+    }
+    history.replaceState(null, "", "?" + q.toString());
+
+    setStatus("connecting...");
+    const source = new EventSource("dashboard/events?" + q.toString());
+    source.addEventListener("dashboard", (e) => {
+      const d = JSON.parse(e.data);
+      state.last = d;
+      el.reload.hidden = !d.actions;
+      renderSummary(d);
+      renderTable();
+      setStatus(new Date(d.time).toLocaleTimeString());
+    });
+    source.addEventListener("error", (e) => {
+      if (e.data) {
+        setStatus(JSON.parse(e.data).error, true);
+      } else if (source.readyState !== EventSource.OPEN) {
+        setStatus("connection lost, reconnecting...", true);
+      }
+    });
+    state.source = source;
+  }
+
+  async function init() {
+    const resp = await fetch("views");
+    const views = await resp.json();
+    for (const v of views) {
+      state.views[v.name] = v.description;
+      const o = document.createElement("option");
+      o.value = v.name;
+      o.textContent = v.name;
+      el.view.append(o);
+    }
+
+    el.view.value = state.views[params.get("view")] !== undefined ? params.get("view") :
+      (state.views["activity"] !== undefined ? "activity" : views[0].name);
+    if (params.get("interval")) {
+      el.interval.value = params.get("interval");
+    }
+
+    el.view.addEventListener("change", () => {
+      state.sortColumn = null;
+      connect();
+    });
+    el.interval.addEventListener("change", connect);
+    el.filter.addEventListener("input", renderTable);
+    el.reload.addEventListener("click", () => act("reload"));
+
+    connect();
+  }
+
+  init().catch((e) => setStatus(`initialization failed: ${e}`, true));
+})();
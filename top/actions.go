@@ -0,0 +1,159 @@
+package top
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/profile"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// selectedPid returns pid of the backend in the row selected by the row cursor.
+func selectedPid(config *config) (int, error) {
+	if !config.cursor.active {
+		return 0, fmt.Errorf("no backend selected, press 'c' to show the row cursor")
+	}
+
+	value, ok := config.cursor.value("pid")
+	if !ok {
+		return 0, fmt.Errorf("no backends in %s view", config.view.Name)
+	}
+
+	pid, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid of the selected backend: %w", err)
+	}
+
+	return pid, nil
+}
+
+// selectedQueryID returns queryid of the statement in the row selected by the row cursor. Returns false if there
+// is no selected statement.
+func selectedQueryID(config *config) (string, bool) {
+	if !config.cursor.active || !strings.Contains(config.view.Name, "statements") {
+		return "", false
+	}
+
+	queryid, ok := config.cursor.value("queryid")
+	return queryid, ok && queryid != ""
+}
+
+// killSelected asks for confirmation and cancels query or terminates the backend selected by the row cursor. When
+// the row cursor is hidden, pid of the backend is asked.
+func killSelected(app *app, d dialogType) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if !app.config.cursor.active {
+			return dialogOpen(app, d)(g, v)
+		}
+
+		pid, err := selectedPid(app.config)
+		if err != nil {
+			printCmdline(g, "Signals: do nothing, %s", err)
+			return nil
+		}
+
+		// Remember pid, the selected row could be changed on refresh until action is confirmed.
+		app.config.selected = strconv.Itoa(pid)
+
+		if d == dialogTerminateBackend {
+			return dialogOpen(app, dialogTerminateSelected)(g, v)
+		}
+		return dialogOpen(app, dialogCancelSelected)(g, v)
+	}
+}
+
+// queryReport opens report about statement selected by the row cursor. When there is no selected statement,
+// queryid is asked.
+func queryReport(app *app) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		queryid, ok := selectedQueryID(app.config)
+		if !ok {
+			return dialogOpen(app, dialogQueryReport)(g, v)
+		}
+
+		printCmdline(g, "%s", showQueryReport(g, app, queryid))
+		return nil
+	}
+}
+
+// profileBackend starts wait events profiling of the backend selected by the row cursor. UI is closed during
+// profiling and restored after profiling is stopped.
+func profileBackend(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		pid, err := selectedPid(app.config)
+		if err != nil {
+			printCmdline(g, "Profile: %s", err)
+			return nil
+		}
+
+		// Exit from UI and stats loop... will restore it after profiling is stopped.
+		app.uiExit <- 1
+		g.Close()
+
+		return runProfile(app.db.Config, profile.Config{
+			Pid:       pid,
+			Frequency: 100 * time.Millisecond,
+			Strsize:   128,
+			Redact:    app.config.redact,
+		}, os.Stdin, os.Stdout)
+	}
+}
+
+// runProfile profiles the backend until Ctrl+C is pressed or the backend quits, and waits until user
+// presses Enter to return.
+func runProfile(dbConfig postgres.Config, config profile.Config, r io.Reader, w io.Writer) error {
+	// Use separate connection, the main connection is used by stats collector.
+	conn, err := postgres.Connect(dbConfig)
+	if err != nil {
+		return fmt.Errorf("profile failed: %w", err)
+	}
+	defer conn.Close()
+
+	doQuit := make(chan os.Signal, 1)
+	signal.Notify(doQuit, os.Interrupt)
+	defer signal.Stop(doQuit)
+
+	_, err = fmt.Fprintln(w, "Press Ctrl+C to stop profiling.")
+	if err != nil {
+		return err
+	}
+
+	err = profile.Profile(w, conn, config, doQuit)
+	if err != nil && !errors.Is(err, profile.ErrInterrupted) {
+		_, err = fmt.Fprintf(w, "Profile failed: %s\n", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprint(w, "Press Enter to return to top.")
+	if err != nil {
+		return err
+	}
+
+	_, err = bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// detailAction closes 'detail' view and runs the action on the selected row.
+func detailAction(handler func(g *gocui.Gui, v *gocui.View) error) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		err := closeDetail(g, v)
+		if err != nil {
+			return err
+		}
+
+		return handler(g, v)
+	}
+}
@@ -0,0 +1,86 @@
+package top
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/profile"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_selectedPid(t *testing.T) {
+	testcases := []struct {
+		cursor rowCursor
+		want   int
+		valid  bool
+	}{
+		{cursor: rowCursor{active: true, cols: []string{"pid"}, values: []sql.NullString{{String: "123", Valid: true}}}, want: 123, valid: true},
+		{cursor: rowCursor{active: false, cols: []string{"pid"}, values: []sql.NullString{{String: "123", Valid: true}}}, valid: false},
+		{cursor: rowCursor{active: true, cols: []string{"datname"}, values: []sql.NullString{{String: "postgres", Valid: true}}}, valid: false},
+		{cursor: rowCursor{active: true, cols: []string{"pid"}, values: []sql.NullString{{}}}, valid: false},
+		{cursor: rowCursor{active: true}, valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			config := newConfig()
+			config.view = config.views["activity"]
+			config.cursor = tc.cursor
+
+			got, err := selectedPid(config)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_selectedQueryID(t *testing.T) {
+	config := newConfig()
+	config.view = config.views["statements_timings"]
+	config.cursor = rowCursor{cols: []string{"queryid", "query"}, values: []sql.NullString{{String: "abcdef0123", Valid: true}, {}}}
+
+	// Row cursor is hidden.
+	_, ok := selectedQueryID(config)
+	assert.False(t, ok)
+
+	config.cursor.active = true
+	got, ok := selectedQueryID(config)
+	assert.True(t, ok)
+	assert.Equal(t, "abcdef0123", got)
+
+	// Views except statements have no query reports.
+	config.view = config.views["activity"]
+	_, ok = selectedQueryID(config)
+	assert.False(t, ok)
+}
+
+func Test_runProfile(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	}
+	defer db.Close()
+
+	var pid int
+	assert.NoError(t, db.QueryRow("SELECT pg_backend_pid()").Scan(&pid))
+
+	// Profiled backend quits, profiling is stopped and Enter is waited.
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		db.Close()
+	}()
+
+	var buf bytes.Buffer
+	config := profile.Config{Pid: pid, Frequency: 50 * time.Millisecond, Strsize: 64}
+	assert.NoError(t, runProfile(db.Config, config, strings.NewReader("\n"), &buf))
+	assert.Contains(t, buf.String(), fmt.Sprintf("LOG: Stop profiling, no process with pid %d", pid))
+	assert.True(t, strings.HasSuffix(buf.String(), "Press Enter to return to top."))
+}
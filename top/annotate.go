@@ -0,0 +1,37 @@
+package top
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/record"
+)
+
+// annotationFile returns stats file where annotations are written. File specified by user is preferred over
+// the file of recording started from 'top'.
+func (app *app) annotationFile() string {
+	if app.config.annotateFile != "" {
+		return app.config.annotateFile
+	}
+
+	return app.recording.filename()
+}
+
+// annotationKey returns key of the stats file where annotations are written. Recording started from 'top' is
+// not encrypted.
+func (app *app) annotationKey() stat.ArchiveKey {
+	if app.config.annotateFile != "" {
+		return app.config.annotateKey
+	}
+
+	return stat.ArchiveKey{}
+}
+
+// annotate writes annotation into stats file of an active recording.
+func annotate(answer string, filename string, key stat.ArchiveKey) string {
+	err := record.Annotate(filename, answer, key)
+	if err != nil {
+		return fmt.Sprintf("Annotate: failed, %s", err.Error())
+	}
+
+	return "Annotate: successful"
+}
@@ -0,0 +1,23 @@
+package top
+
+import (
+	"testing"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_annotate(t *testing.T) {
+	assert.Equal(t, "Annotate: failed, empty annotation", annotate("", "/nonexistent", stat.ArchiveKey{}))
+	assert.Contains(t, annotate("failover started", "/nonexistent", stat.ArchiveKey{}), "Annotate: failed")
+}
+
+func Test_app_annotationKey(t *testing.T) {
+	key := stat.ArchiveKey{Passphrase: "secret"}
+	app := newApp(nil, newConfig())
+	app.config.annotateKey = key
+	assert.Equal(t, stat.ArchiveKey{}, app.annotationKey())
+
+	app.config.annotateFile = "/tmp/stats.tar"
+	assert.Equal(t, key, app.annotationKey())
+}
@@ -0,0 +1,233 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"sort"
+)
+
+// columnLayout defines order and visibility of columns of the view chosen by user. Columns are referenced by names,
+// because their positions depend on Postgres version. Sorting, filtering and diffs use original column indexes,
+// layout affects printing only.
+type columnLayout struct {
+	order  []string        // Order of columns, columns missed in the list follow in their original order.
+	hidden map[string]bool // Hidden columns.
+}
+
+// isEmpty returns true if layout doesn't change original order and visibility of columns.
+func (l columnLayout) isEmpty() bool {
+	return len(l.order) == 0 && len(l.hidden) == 0
+}
+
+// columns returns indexes of all columns in the order defined by layout, hidden columns are included.
+func (l columnLayout) columns(cols []string) []int {
+	res := make([]int, 0, len(cols))
+	seen := make([]bool, len(cols))
+
+	for _, name := range l.order {
+		idx := columnIndex(cols, name)
+		if idx < 0 || seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		res = append(res, idx)
+	}
+
+	for idx := range cols {
+		if !seen[idx] {
+			res = append(res, idx)
+		}
+	}
+
+	return res
+}
+
+// visible returns indexes of visible columns in the order defined by layout. When all columns are hidden, all
+// columns are returned.
+func (l columnLayout) visible(cols []string) []int {
+	all := l.columns(cols)
+
+	res := make([]int, 0, len(all))
+	for _, idx := range all {
+		if !l.hidden[cols[idx]] {
+			res = append(res, idx)
+		}
+	}
+
+	if len(res) == 0 {
+		return all
+	}
+
+	return res
+}
+
+// hiddenNames returns sorted names of hidden columns.
+func (l columnLayout) hiddenNames() []string {
+	var names []string
+	for name, hidden := range l.hidden {
+		if hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// displayColumns returns indexes of columns of the current view which should be printed, in printing order.
+func displayColumns(config *config, cols []string) []int {
+	return config.layouts[config.view.Name].visible(cols)
+}
+
+// orderColumns returns indexes of columns which could be used for sorting, in printing order. Original order is
+// used when columns of the view are not known yet.
+func orderColumns(config *config) []int {
+	if len(config.view.Cols) != config.view.Ncols {
+		res := make([]int, config.view.Ncols)
+		for i := range res {
+			res[i] = i
+		}
+		return res
+	}
+
+	return displayColumns(config, config.view.Cols)
+}
+
+// columnsMenuItems returns items of columns menu: all columns of the current view in printing order with checkboxes
+// showing visibility of the columns.
+func columnsMenuItems(config *config) []string {
+	cols := config.view.Cols
+	layout := config.layouts[config.view.Name]
+
+	items := make([]string, 0, len(cols))
+	for _, idx := range layout.columns(cols) {
+		mark := "x"
+		if layout.hidden[cols[idx]] {
+			mark = " "
+		}
+		items = append(items, fmt.Sprintf(" [%s] %s", mark, cols[idx]))
+	}
+
+	return items
+}
+
+// toggleColumn shows or hides the column of the current view at the specified position of columns menu. The last
+// visible column can't be hidden.
+func toggleColumn(config *config, pos int) error {
+	cols := config.view.Cols
+	layout := config.layouts[config.view.Name]
+
+	all := layout.columns(cols)
+	if pos < 0 || pos >= len(all) {
+		return fmt.Errorf("no column at position %d", pos)
+	}
+	name := cols[all[pos]]
+
+	if !layout.hidden[name] && len(layout.visible(cols)) == 1 {
+		return fmt.Errorf("the last visible column can't be hidden")
+	}
+
+	hidden := map[string]bool{}
+	for k, v := range layout.hidden {
+		if v && k != name {
+			hidden[k] = true
+		}
+	}
+	if !layout.hidden[name] {
+		hidden[name] = true
+	}
+
+	layout.hidden = hidden
+	setLayout(config, layout)
+	return nil
+}
+
+// moveColumn moves the column of the current view at the specified position of columns menu by the specified
+// number of positions. Returns the new position of the column.
+func moveColumn(config *config, pos int, step int) (int, error) {
+	cols := config.view.Cols
+	layout := config.layouts[config.view.Name]
+
+	all := layout.columns(cols)
+	if pos < 0 || pos >= len(all) {
+		return pos, fmt.Errorf("no column at position %d", pos)
+	}
+
+	next := pos + step
+	if next < 0 || next >= len(all) {
+		return pos, nil
+	}
+
+	all[pos], all[next] = all[next], all[pos]
+
+	order := make([]string, len(all))
+	for i, idx := range all {
+		order[i] = cols[idx]
+	}
+
+	layout.order = order
+	setLayout(config, layout)
+	return next, nil
+}
+
+// setLayout remembers layout of columns of the current view, the empty layout is forgotten.
+func setLayout(config *config, layout columnLayout) {
+	if layout.isEmpty() {
+		delete(config.layouts, config.view.Name)
+		return
+	}
+	config.layouts[config.view.Name] = layout
+}
+
+// toggleColumnHandler shows or hides the column selected in columns menu.
+func toggleColumnHandler(config *config) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if config.menu.menuType != menuColumns {
+			return nil
+		}
+
+		err := toggleColumn(config, menuPosition(v))
+		if err != nil {
+			printCmdline(g, "Columns: %s", err)
+			return nil
+		}
+
+		return columnsMenuRedraw(g, v, config)
+	}
+}
+
+// moveColumnHandler moves the column selected in columns menu up or down.
+func moveColumnHandler(config *config, step int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if config.menu.menuType != menuColumns {
+			return nil
+		}
+
+		pos, err := moveColumn(config, menuPosition(v), step)
+		if err != nil {
+			printCmdline(g, "Columns: %s", err)
+			return nil
+		}
+
+		err = menuSetPosition(v, pos)
+		if err != nil {
+			return err
+		}
+
+		return columnsMenuRedraw(g, v, config)
+	}
+}
+
+// columnsMenuRedraw redraws columns menu and the stats table after layout of columns has been changed.
+func columnsMenuRedraw(g *gocui.Gui, v *gocui.View, config *config) error {
+	config.menu.items = columnsMenuItems(config)
+
+	if v != nil {
+		err := menuDraw(v, config.theme, config.menu.items)
+		if err != nil {
+			return err
+		}
+	}
+
+	return redrawDbstat(g, config)
+}
@@ -0,0 +1,136 @@
+package top
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_columnLayout(t *testing.T) {
+	cols := []string{"pid", "cl_port", "state", "backend_type", "query"}
+
+	testcases := []struct {
+		layout      columnLayout
+		wantColumns []int
+		wantVisible []int
+	}{
+		{layout: columnLayout{}, wantColumns: []int{0, 1, 2, 3, 4}, wantVisible: []int{0, 1, 2, 3, 4}},
+		{
+			layout:      columnLayout{order: []string{"pid", "state", "query"}},
+			wantColumns: []int{0, 2, 4, 1, 3}, wantVisible: []int{0, 2, 4, 1, 3},
+		},
+		{
+			layout:      columnLayout{hidden: map[string]bool{"cl_port": true, "backend_type": true}},
+			wantColumns: []int{0, 1, 2, 3, 4}, wantVisible: []int{0, 2, 4},
+		},
+		{
+			// Unknown and duplicate columns are skipped.
+			layout:      columnLayout{order: []string{"query", "unknown", "query", "pid"}, hidden: map[string]bool{"pid": true}},
+			wantColumns: []int{4, 0, 1, 2, 3}, wantVisible: []int{4, 1, 2, 3},
+		},
+		{
+			// All columns hidden.
+			layout: columnLayout{hidden: map[string]bool{
+				"pid": true, "cl_port": true, "state": true, "backend_type": true, "query": true,
+			}},
+			wantColumns: []int{0, 1, 2, 3, 4}, wantVisible: []int{0, 1, 2, 3, 4},
+		},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			assert.Equal(t, tc.wantColumns, tc.layout.columns(cols))
+			assert.Equal(t, tc.wantVisible, tc.layout.visible(cols))
+		})
+	}
+}
+
+func Test_columnsMenu(t *testing.T) {
+	config := newConfig()
+	config.view = config.views["activity"]
+	config.view.Cols = []string{"pid", "cl_port", "state", "query"}
+	config.view.Ncols = 4
+
+	assert.Equal(t, []string{" [x] pid", " [x] cl_port", " [x] state", " [x] query"}, columnsMenuItems(config))
+
+	// Hide column and show it again.
+	assert.NoError(t, toggleColumn(config, 1))
+	assert.Equal(t, []string{" [x] pid", " [ ] cl_port", " [x] state", " [x] query"}, columnsMenuItems(config))
+	assert.Equal(t, []int{0, 2, 3}, displayColumns(config, config.view.Cols))
+	assert.NoError(t, toggleColumn(config, 1))
+	assert.NotContains(t, config.layouts, "activity")
+	assert.Error(t, toggleColumn(config, 10))
+
+	// Move query column up, moving out of bounds does nothing.
+	pos, err := moveColumn(config, 3, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pos)
+	assert.Equal(t, []string{" [x] pid", " [x] cl_port", " [x] query", " [x] state"}, columnsMenuItems(config))
+	pos, err = moveColumn(config, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pos)
+	_, err = moveColumn(config, 10, 1)
+	assert.Error(t, err)
+
+	// The last visible column can't be hidden.
+	assert.NoError(t, toggleColumn(config, 0))
+	assert.NoError(t, toggleColumn(config, 1))
+	assert.NoError(t, toggleColumn(config, 2))
+	assert.Error(t, toggleColumn(config, 3))
+	assert.Equal(t, []int{2}, displayColumns(config, config.view.Cols))
+
+	// Layout is per view.
+	config.view = config.views["tables"]
+	config.view.Cols = []string{"pid", "cl_port", "state", "query"}
+	assert.Equal(t, []int{0, 1, 2, 3}, displayColumns(config, config.view.Cols))
+}
+
+func Test_nextOrderKey(t *testing.T) {
+	config := newConfig()
+	config.view = config.views["activity"]
+	config.view.Cols = []string{"pid", "cl_port", "state", "query"}
+	config.view.Ncols = 4
+	config.layouts["activity"] = columnLayout{order: []string{"query", "pid"}, hidden: map[string]bool{"cl_port": true}}
+
+	testcases := []struct {
+		orderKey int
+		step     int
+		want     int
+	}{
+		{orderKey: 3, step: 1, want: 0},
+		{orderKey: 0, step: 1, want: 2},
+		{orderKey: 2, step: 1, want: 3},
+		{orderKey: 3, step: -1, want: 2},
+		{orderKey: 0, step: -1, want: 3},
+		{orderKey: 1, step: 1, want: 3}, // hidden column is ordered
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			config.view.OrderKey = tc.orderKey
+			assert.Equal(t, tc.want, nextOrderKey(config, tc.step))
+		})
+	}
+}
+
+func Test_renderDbstat_layout(t *testing.T) {
+	cfg := makeRenderConfig(4, 5)
+	cfg.layouts = map[string]columnLayout{
+		"": {order: []string{"col3", "col0"}, hidden: map[string]bool{"col1": true}},
+	}
+	cfg.view.OrderKey = 1 // hidden column is still used for sorting
+	s := makeRenderResult(4, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderDbstat(&buf, cfg, s, 200))
+
+	lines := strings.Split(ansiRE.ReplaceAllString(buf.String(), ""), "\n")
+	assert.Equal(t, "col3   col0   col2", strings.TrimSpace(lines[0]))
+	assert.Equal(t, "r0-c3  r0-c0  r0-c2", strings.TrimSpace(lines[1]))
+	assert.NotContains(t, buf.String(), "col1")
+
+	// The first printed column is frozen.
+	assert.Contains(t, buf.String(), "\033[30;47;1mcol3")
+}
@@ -4,28 +4,58 @@ import (
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
+	"time"
 )
 
 // config defines 'top' program runtime configuration.
 type config struct {
-	view         view.View      // Current active view.
-	views        view.Views     // List of all available views.
-	queryOptions query.Options  // Queries' settings that might depend on Postgres version.
-	viewCh       chan view.View // Channel used for passing view settings to stats goroutine.
-	logtail      stat.Logfile   // Logfile used for working with Postgres log file.
-	dialog       dialogType     // Remember current user-started dialog, used for selecting needed dialog handler.
-	menu         menuStyle      // When working with menus, keep properties of the menu.
-	procMask     int            // Process mask used for selecting group of process.
-	scrollOffset int            // Horizontal scroll position: index into scrollable columns (1..Ncols-1); 0 means no scroll. Ephemeral, reset on view switch.
-	verbose      bool           // Verbose display mode for the top summary panels. Persistent: unlike scrollOffset, it is NOT reset on view switch (mirrored into every views entry).
+	view         view.View                 // Current active view.
+	views        view.Views                // List of all available views.
+	queryOptions query.Options             // Queries' settings that might depend on Postgres version.
+	viewCh       chan view.View            // Channel used for passing view settings to stats goroutine.
+	logtail      stat.Logfile              // Logfile used for working with Postgres log file.
+	dialog       dialogType                // Remember current user-started dialog, used for selecting needed dialog handler.
+	menu         menuStyle                 // When working with menus, keep properties of the menu.
+	procMask     int                       // Process mask used for selecting group of process.
+	scrollOffset int                       // Horizontal scroll position: index into scrollable columns (1..Ncols-1); 0 means no scroll. Ephemeral, reset on view switch.
+	rowOffset    int                       // Vertical scroll position: number of printed rows scrolled out of the screen. Ephemeral, reset on view switch.
+	window       columnWindow              // Columns window of the last printed stats table, used for finding the column under mouse pointer.
+	mouse        bool                      // Mouse support is enabled.
+	theme        theme                     // Colors of UI.
+	thresholds   thresholds                // Thresholds of highlighting values in stats tables.
+	blockSize    int                       // Size of Postgres block in bytes, used for calculating derived values.
+	annotateFile string                    // Stats file of an active recording where annotations are written.
+	annotateKey  stat.ArchiveKey           // Key of encrypted stats file where annotations are written.
+	redact       bool                      // Replace literals in query texts with placeholders.
+	verbose      bool                      // Verbose display mode for the top summary panels. Persistent: unlike scrollOffset, it is NOT reset on view switch (mirrored into every views entry).
+	refresh      time.Duration             // Current refresh interval.
+	configFile   string                    // Configuration file where settings are saved.
+	settings     userConfig                // Settings read from configuration file.
+	pending      map[string]viewSettings   // Per-view settings which refer columns, applied when columns of the view become known.
+	widths       map[string]map[string]int // Width of columns changed by user: key is the view name, then column name.
+	layouts      map[string]columnLayout   // Order and visibility of columns chosen by user: key is the view name.
+	cursor       rowCursor                 // Row selected in the stats table. Ephemeral, reset on view switch.
+	last         stat.Stat                 // Last stats printed in the stats table, used for redrawing it between refreshes.
+	selected     string                    // Value of the selected row which confirmed action is applied to.
+	history      statHistory               // Recent stats snapshots, used for pausing and rewinding the display.
 }
 
 // newConfig creates 'top' initial configuration.
 func newConfig() *config {
 	views := view.New()
 
+	// Built-in thresholds are always valid.
+	th, _ := newThresholds(nil)
+
 	return &config{
-		views:  views,
-		viewCh: make(chan view.View),
+		views:      views,
+		viewCh:     make(chan view.View),
+		refresh:    time.Second,
+		pending:    map[string]viewSettings{},
+		widths:     map[string]map[string]int{},
+		layouts:    map[string]columnLayout{},
+		theme:      themes[themeDefault],
+		thresholds: th,
+		blockSize:  8192,
 	}
 }
@@ -21,10 +21,7 @@ const (
 // orderKeyLeft switches sort order to left column.
 func orderKeyLeft(config *config) func(_ *gocui.Gui, _ *gocui.View) error {
 	return func(_ *gocui.Gui, _ *gocui.View) error {
-		config.view.OrderKey--
-		if config.view.OrderKey < 0 {
-			config.view.OrderKey = config.view.Ncols - 1
-		}
+		config.view.OrderKey = nextOrderKey(config, -1)
 
 		config.viewCh <- config.view
 		return nil
@@ -34,16 +31,30 @@ func orderKeyLeft(config *config) func(_ *gocui.Gui, _ *gocui.View) error {
 // orderKeyRight switches sort order to right column.
 func orderKeyRight(config *config) func(_ *gocui.Gui, _ *gocui.View) error {
 	return func(_ *gocui.Gui, _ *gocui.View) error {
-		config.view.OrderKey++
-		if config.view.OrderKey >= config.view.Ncols {
-			config.view.OrderKey = 0
-		}
+		config.view.OrderKey = nextOrderKey(config, 1)
 
 		config.viewCh <- config.view
 		return nil
 	}
 }
 
+// nextOrderKey returns index of the column printed next to the ordered column in the specified direction. Hidden
+// columns are skipped, the first printed column is used when the ordered column is hidden.
+func nextOrderKey(config *config, step int) int {
+	cols := orderColumns(config)
+	if len(cols) == 0 {
+		return config.view.OrderKey
+	}
+
+	for pos, idx := range cols {
+		if idx == config.view.OrderKey {
+			return cols[(pos+step+len(cols))%len(cols)]
+		}
+	}
+
+	return cols[0]
+}
+
 // scrollLeft scrolls the columns window one step to the left.
 // It decrements config.scrollOffset, clamped at the lower bound 0, and sends the
 // view on viewCh solely to trigger an immediate redraw — the view itself is not
@@ -82,6 +93,9 @@ func increaseWidth(config *config) func(_ *gocui.Gui, _ *gocui.View) error {
 
 		// Increase the width using current width. Clamp the value, it should not be greater than max allowed limit.
 		config.view.ColsWidth[idx] = math.Min(config.view.ColsWidth[idx]+colsWidthStep, colsWidthMax)
+		if idx < len(config.view.Cols) {
+			setWidth(config, config.view.Name, config.view.Cols[idx], config.view.ColsWidth[idx])
+		}
 
 		config.viewCh <- config.view
 		return nil
@@ -95,6 +109,7 @@ func decreaseWidth(config *config) func(_ *gocui.Gui, _ *gocui.View) error {
 
 		// Decrease the width using current width. Clamp the value, it should not be less than width of column's name.
 		config.view.ColsWidth[idx] = math.Max(config.view.ColsWidth[idx]-colsWidthStep, len(config.view.Cols[idx]))
+		setWidth(config, config.view.Name, config.view.Cols[idx], config.view.ColsWidth[idx])
 
 		config.viewCh <- config.view
 		return nil
@@ -241,6 +256,9 @@ func viewSwitchHandler(config *config, c string) {
 	config.views[config.view.Name] = config.view
 	config.view = config.views[c]
 	config.scrollOffset = 0 // horizontal scroll is ephemeral; reset on view switch
+	config.rowOffset = 0
+	config.cursor.reset()
+	config.history.resume(c) // display is resumed on view switch
 	config.viewCh <- config.view
 }
 
@@ -255,6 +273,9 @@ func switchViewToProcPidStat(app *app) func(g *gocui.Gui, _ *gocui.View) error {
 		// Horizontal scroll is ephemeral; reset it when entering the per-process
 		// screen. This path bypasses viewSwitchHandler, so the reset is done here.
 		app.config.scrollOffset = 0
+		app.config.rowOffset = 0
+		app.config.cursor.reset()
+		app.config.history.resume("procpidstat")
 
 		if !app.db.Local {
 			printCmdline(g, "Per-process stats available in local mode only")
@@ -451,7 +472,8 @@ func changeRefresh(answer string, config *config) string {
 
 	// Set refresh interval, send it to stats channel and reset interval in the view.
 	// Refresh interval should not be saved as a per-view setting. It's used as a setting for stats goroutine.
-	config.view.Refresh = time.Duration(interval) * time.Second
+	config.refresh = time.Duration(interval) * time.Second
+	config.view.Refresh = config.refresh
 	config.viewCh <- config.view
 	config.view.Refresh = 0
 
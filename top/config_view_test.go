@@ -674,6 +674,7 @@ func Test_changeRefresh(t *testing.T) {
 
 		assert.Equal(t, "Refresh: ok", changeRefresh("5", config))
 		wg.Wait()
+		assert.Equal(t, 5*time.Second, config.refresh)
 		close(config.viewCh)
 	})
 
@@ -0,0 +1,190 @@
+package top
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"time"
+)
+
+// rowHistorySize defines number of recent samples of the selected row kept for the row details.
+const rowHistorySize = 10
+
+// rowCursor defines the row selected in the stats table. The selected row is followed across refreshes
+// using the value of unique key column of the view.
+type rowCursor struct {
+	active  bool             // Cursor is shown, Up/Down and j/k keys move it.
+	row     int              // Position of the selected row among printed rows.
+	key     string           // Value of unique key column of the selected row.
+	keys    []string         // Values of unique key column of printed rows.
+	cols    []string         // Names of columns of the selected row.
+	values  []sql.NullString // Full values of the selected row.
+	history []rowSample      // Recent samples of diffed columns of the selected row, the oldest first.
+}
+
+// rowSample defines values of diffed columns of the selected row received at the specified time.
+type rowSample struct {
+	ts     time.Time
+	values []string
+}
+
+// reset resets the selected row, but keeps the cursor shown or hidden.
+func (c *rowCursor) reset() {
+	*c = rowCursor{active: c.active}
+}
+
+// sync finds the selected row in the result and remembers its values. When the row is not found (e.g. the backend
+// has gone), the row at the same position is selected.
+func (c *rowCursor) sync(v view.View, r stat.PGresult) {
+	// Columns differ when view has been switched, history of the previous view is useless.
+	if !equalStrings(c.cols, r.Cols) {
+		c.history = nil
+	}
+	c.cols = r.Cols
+
+	ukey := v.UniqueKey
+	if ukey >= r.Ncols {
+		ukey = 0
+	}
+
+	rows := filterRows(r, v.Filters, isFilterRequired(v.Filters))
+	c.keys = make([]string, len(rows))
+	pos := -1
+	for n, rownum := range rows {
+		if ukey < len(r.Values[rownum]) {
+			c.keys[n] = r.Values[rownum][ukey].String
+		}
+		if pos < 0 && c.keys[n] == c.key {
+			pos = n
+		}
+	}
+
+	if len(rows) == 0 {
+		c.row, c.values = 0, nil
+		return
+	}
+
+	if pos < 0 {
+		pos = min(max(c.row, 0), len(rows)-1)
+	}
+
+	c.selectRow(pos)
+	c.values = append([]sql.NullString(nil), r.Values[rows[pos]]...)
+}
+
+// record appends values of diffed columns of the selected row to its history.
+func (c *rowCursor) record(v view.View, ts time.Time) {
+	first, last := v.DiffIntvl[0], v.DiffIntvl[1]
+	if last == 0 || last >= len(c.values) {
+		return
+	}
+
+	values := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		values = append(values, c.values[i].String)
+	}
+
+	c.history = append(c.history, rowSample{ts: ts, values: values})
+	if len(c.history) > rowHistorySize {
+		c.history = c.history[len(c.history)-rowHistorySize:]
+	}
+}
+
+// move moves the cursor by specified number of rows.
+func (c *rowCursor) move(step int) {
+	if len(c.keys) == 0 {
+		return
+	}
+
+	c.selectRow(min(max(c.row+step, 0), len(c.keys)-1))
+}
+
+// selectRow selects the printed row at specified position. History is reset when another row is selected.
+func (c *rowCursor) selectRow(pos int) {
+	c.row = pos
+	if c.keys[pos] != c.key {
+		c.key = c.keys[pos]
+		c.history = nil
+	}
+}
+
+// value returns full value of the specified column of the selected row.
+func (c *rowCursor) value(name string) (string, bool) {
+	i := columnIndex(c.cols, name)
+	if i < 0 || i >= len(c.values) {
+		return "", false
+	}
+
+	return c.values[i].String, true
+}
+
+// toggleRowCursor shows or hides the row cursor.
+func toggleRowCursor(config *config) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		config.cursor.active = !config.cursor.active
+		if config.cursor.active {
+			printCmdline(g, "Row cursor: on, use Up/Down or j/k to move, Enter to show details")
+		} else {
+			printCmdline(g, "Row cursor: off")
+		}
+
+		return redrawDbstat(g, config)
+	}
+}
+
+// moveRowCursor moves the row cursor when it is shown, otherwise the key does its usual action.
+func moveRowCursor(config *config, step int, fallback func(g *gocui.Gui, v *gocui.View) error) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if !config.cursor.active {
+			return fallback(g, v)
+		}
+
+		config.cursor.move(step)
+		config.cursor.sync(config.view, config.last.Result)
+
+		return redrawDbstat(g, config)
+	}
+}
+
+// redrawDbstat prints the last received stats again, so the row cursor moves without waiting for the next refresh.
+func redrawDbstat(g *gocui.Gui, config *config) error {
+	if g == nil || (!config.last.Result.Valid && config.last.Error == nil) {
+		return nil
+	}
+
+	v, err := g.View("dbstat")
+	if err != nil {
+		return fmt.Errorf("set focus on dbstat view failed: %w", err)
+	}
+	v.Clear()
+
+	return printDbstat(v, config, config.last)
+}
+
+// columnIndex returns index of the column with specified name, or -1 if there is no such column.
+func columnIndex(cols []string, name string) int {
+	for i, col := range cols {
+		if col == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// equalStrings returns true if slices contain the same strings.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
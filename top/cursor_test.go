@@ -0,0 +1,117 @@
+package top
+
+import (
+	"database/sql"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// newCursorResult returns result with rows of backends, calls column is diffed.
+func newCursorResult(rows ...[]string) stat.PGresult {
+	r := stat.PGresult{Valid: true, Ncols: 3, Nrows: len(rows), Cols: []string{"pid", "calls", "query"}}
+	for _, row := range rows {
+		values := make([]sql.NullString, len(row))
+		for i, v := range row {
+			values[i] = sql.NullString{String: v, Valid: true}
+		}
+		r.Values = append(r.Values, values)
+	}
+	return r
+}
+
+func Test_rowCursor(t *testing.T) {
+	v := view.View{DiffIntvl: [2]int{1, 1}, Filters: map[int]*regexp.Regexp{}}
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := rowCursor{active: true}
+
+	// First row is selected by default.
+	c.sync(v, newCursorResult([]string{"10", "1", "SELECT 1"}, []string{"20", "2", "SELECT 2"}, []string{"30", "3", "SELECT 3"}))
+	c.record(v, ts)
+	assert.Equal(t, 0, c.row)
+	assert.Equal(t, "10", c.key)
+	assert.Equal(t, []string{"10", "20", "30"}, c.keys)
+
+	// Moving is clamped by number of rows, history is reset when another row is selected.
+	c.move(-1)
+	assert.Equal(t, 0, c.row)
+	assert.Len(t, c.history, 1)
+	c.move(5)
+	assert.Equal(t, 2, c.row)
+	assert.Equal(t, "30", c.key)
+	assert.Len(t, c.history, 0)
+
+	// Selected row is followed when rows are reordered.
+	c.sync(v, newCursorResult([]string{"30", "4", "SELECT 3"}, []string{"10", "1", "SELECT 1"}))
+	c.record(v, ts)
+	assert.Equal(t, 0, c.row)
+	value, ok := c.value("query")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT 3", value)
+
+	c.sync(v, newCursorResult([]string{"10", "1", "SELECT 1"}, []string{"30", "6", "SELECT 3"}))
+	c.record(v, ts.Add(time.Second))
+	assert.Equal(t, 1, c.row)
+	assert.Equal(t, []rowSample{{ts: ts, values: []string{"4"}}, {ts: ts.Add(time.Second), values: []string{"6"}}}, c.history)
+
+	// Row at the same position is selected when the row has gone.
+	c.sync(v, newCursorResult([]string{"10", "1", "SELECT 1"}, []string{"20", "2", "SELECT 2"}))
+	assert.Equal(t, 1, c.row)
+	assert.Equal(t, "20", c.key)
+	assert.Len(t, c.history, 0)
+
+	// Filtered rows are skipped.
+	v.Filters[2] = regexp.MustCompile("SELECT 2")
+	c.sync(v, newCursorResult([]string{"10", "1", "SELECT 1"}, []string{"20", "2", "SELECT 2"}))
+	assert.Equal(t, 0, c.row)
+	assert.Equal(t, []string{"20"}, c.keys)
+
+	// No rows.
+	c.sync(v, newCursorResult())
+	assert.Nil(t, c.values)
+	_, ok = c.value("pid")
+	assert.False(t, ok)
+
+	// History is limited.
+	c = rowCursor{}
+	for i := 0; i < rowHistorySize+5; i++ {
+		c.sync(v, newCursorResult([]string{"20", "2", "SELECT 2"}))
+		c.record(v, ts)
+	}
+	assert.Len(t, c.history, rowHistorySize)
+
+	// Views without diffed columns have no history.
+	c.reset()
+	c.sync(view.View{}, newCursorResult([]string{"20", "2", "SELECT 2"}))
+	c.record(view.View{}, ts)
+	assert.Len(t, c.history, 0)
+}
+
+func Test_moveRowCursor(t *testing.T) {
+	config := newConfig()
+	config.view = view.View{Filters: map[int]*regexp.Regexp{}}
+	config.last = stat.Stat{Pgstat: stat.Pgstat{Result: newCursorResult([]string{"10", "1", "q"}, []string{"20", "2", "q"})}}
+	config.cursor.sync(config.view, config.last.Result)
+
+	var fallback int
+	handler := moveRowCursor(config, 1, func(_ *gocui.Gui, _ *gocui.View) error {
+		fallback++
+		return nil
+	})
+
+	// Hidden cursor is not moved, the key does its usual action.
+	assert.NoError(t, handler(nil, nil))
+	assert.Equal(t, 1, fallback)
+	assert.Equal(t, 0, config.cursor.row)
+
+	assert.NoError(t, toggleRowCursor(config)(nil, nil))
+	assert.NoError(t, handler(nil, nil))
+	assert.Equal(t, 1, fallback)
+	assert.Equal(t, 1, config.cursor.row)
+	value, _ := config.cursor.value("pid")
+	assert.Equal(t, "20", value)
+}
@@ -0,0 +1,74 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"sort"
+	"unicode/utf8"
+)
+
+// customViewsKeys returns keys which switch to custom views. Hotkeys of custom views should not override keys
+// already used in the main view.
+func customViewsKeys(app *app, keys []key) ([]key, error) {
+	used := map[any]string{}
+	for _, k := range keys {
+		if k.viewname == "" || k.viewname == "sysstat" {
+			used[k.key] = "predefined action"
+		}
+	}
+
+	var custom []key
+	for _, name := range customViewsNames(app.config) {
+		hotkey := app.config.settings.CustomViews[name].Hotkey
+		if hotkey == "" {
+			continue
+		}
+
+		r, _ := utf8.DecodeRuneInString(hotkey)
+		if owner, ok := used[r]; ok {
+			return nil, fmt.Errorf("hotkey '%s' of custom view '%s' is already used by %s", hotkey, name, owner)
+		}
+		used[r] = fmt.Sprintf("custom view '%s'", name)
+
+		custom = append(custom, key{"sysstat", r, switchViewToCustom(app.config, name)})
+	}
+
+	return custom, nil
+}
+
+// customViewsMenuItems returns items of custom views menu. Views not supported by Postgres are not shown.
+func customViewsMenuItems(config *config) []string {
+	var items []string
+	for _, name := range customViewsNames(config) {
+		if _, ok := config.views[name]; ok && config.settings.CustomViews[name].Menu {
+			items = append(items, " "+name)
+		}
+	}
+
+	return items
+}
+
+// customViewsNames returns sorted names of custom views defined by user.
+func customViewsNames(config *config) []string {
+	names := make([]string, 0, len(config.settings.CustomViews))
+	for name := range config.settings.CustomViews {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// switchViewToCustom switches to custom view, if it is supported by Postgres.
+func switchViewToCustom(config *config, name string) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if _, ok := config.views[name]; !ok {
+			printCmdline(g, "NOTICE: custom view %s is not supported by current Postgres", name)
+			return nil
+		}
+
+		viewSwitchHandler(config, name)
+		printCmdline(g, "%s", config.view.Msg)
+		return nil
+	}
+}
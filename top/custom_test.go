@@ -0,0 +1,86 @@
+package top
+
+import (
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// newCustomConfig creates config with custom views added to views, as it is done at startup.
+func newCustomConfig(defs map[string]view.Custom, supported ...string) *config {
+	config := newConfig()
+	config.settings.CustomViews = defs
+	for _, name := range supported {
+		config.views[name] = defs[name].View(name)
+	}
+	config.view = config.views["activity"]
+	return config
+}
+
+func Test_customViewsKeys(t *testing.T) {
+	keys := []key{
+		{"", gocui.KeyCtrlC, nil},
+		{"sysstat", 'q', nil},
+		{"menu", 'e', nil},
+	}
+
+	app := &app{config: newCustomConfig(map[string]view.Custom{
+		"queue":      {Query: "SELECT 1", Hotkey: "e"},
+		"partitions": {Query: "SELECT 1", Hotkey: "y"},
+		"counters":   {Query: "SELECT 1"},
+	})}
+
+	got, err := customViewsKeys(app, keys)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, 'y', got[0].key) // sorted by view name
+	assert.Equal(t, 'e', got[1].key)
+	assert.Equal(t, "sysstat", got[0].viewname)
+
+	// Predefined keys are not overridden.
+	app.config.settings.CustomViews["counters"] = view.Custom{Query: "SELECT 1", Hotkey: "q"}
+	_, err = customViewsKeys(app, keys)
+	assert.Error(t, err)
+
+	// Hotkeys are unique.
+	app.config.settings.CustomViews["counters"] = view.Custom{Query: "SELECT 1", Hotkey: "e"}
+	_, err = customViewsKeys(app, keys)
+	assert.Error(t, err)
+}
+
+func Test_customViewsMenuItems(t *testing.T) {
+	config := newCustomConfig(map[string]view.Custom{
+		"queue":      {Query: "SELECT 1", Menu: true},
+		"partitions": {Query: "SELECT 1", Menu: true},
+		"counters":   {Query: "SELECT 1"},
+		"newest":     {Query: "SELECT 1", Menu: true, MinVersion: 990000},
+	}, "queue", "partitions", "counters")
+
+	assert.Equal(t, []string{" partitions", " queue"}, customViewsMenuItems(config))
+	assert.Nil(t, customViewsMenuItems(newConfig()))
+}
+
+func Test_switchViewToCustom(t *testing.T) {
+	config := newCustomConfig(map[string]view.Custom{
+		"queue":  {Query: "SELECT 1"},
+		"newest": {Query: "SELECT 1", MinVersion: 990000},
+	}, "queue")
+
+	// Not supported view is not switched.
+	assert.NoError(t, switchViewToCustom(config, "newest")(nil, nil))
+	assert.Equal(t, "activity", config.view.Name)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		v := <-config.viewCh
+		assert.Equal(t, "queue", v.Name)
+		wg.Done()
+	}()
+
+	assert.NoError(t, switchViewToCustom(config, "queue")(nil, nil))
+	wg.Wait()
+	assert.Equal(t, "queue", config.view.Name)
+}
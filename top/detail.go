@@ -0,0 +1,247 @@
+package top
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// showDetail opens fullscreen view with details of the row selected by the row cursor. The cursor is shown if
+// it is hidden.
+func showDetail(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		config := app.config
+
+		if !config.cursor.active {
+			config.cursor.active = true
+			err := redrawDbstat(g, config)
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.cursor.values == nil {
+			printCmdline(g, "Details: no row selected")
+			return nil
+		}
+
+		text, err := fetchQueryText(app.db, app.postgresProps.ExtPGSSSchema, config.cursor)
+		if err != nil {
+			printCmdline(g, "Details: get full query text failed: %s", err)
+		}
+		if config.redact {
+			text = query.Normalize(text)
+		}
+
+		maxX, maxY := g.Size()
+		v, err := g.SetView("detail", -1, -1, maxX-1, maxY-1)
+		if err != nil && err != gocui.ErrUnknownView {
+			return fmt.Errorf("set 'detail' view on layout failed: %w", err)
+		}
+
+		v.Frame = false
+		v.Wrap = true
+		v.Clear()
+
+		err = renderDetail(v, config.view.Name, config.view.DiffIntvl, config.cursor, text)
+		if err != nil {
+			return fmt.Errorf("print on 'detail' view failed: %w", err)
+		}
+
+		if _, err := g.SetCurrentView("detail"); err != nil {
+			return fmt.Errorf("set 'detail' view as current on layout failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// closeDetail closes 'detail' view and switches focus to 'sysstat' view.
+func closeDetail(g *gocui.Gui, v *gocui.View) error {
+	v.Clear()
+	err := g.DeleteView("detail")
+	if err != nil {
+		return fmt.Errorf("delete detail view failed: %w", err)
+	}
+
+	if _, err := g.SetCurrentView("sysstat"); err != nil {
+		return fmt.Errorf("set focus on sysstat view failed: %w", err)
+	}
+	return nil
+}
+
+// scrollDetail scrolls content of 'detail' view by specified number of lines.
+func scrollDetail(step int) func(_ *gocui.Gui, v *gocui.View) error {
+	return func(_ *gocui.Gui, v *gocui.View) error {
+		ox, oy := v.Origin()
+		if oy+step < 0 || oy+step >= len(v.BufferLines()) {
+			return nil
+		}
+
+		return v.SetOrigin(ox, oy+step)
+	}
+}
+
+// fetchQueryText returns full text of query of the selected row. Query texts of statements are truncated in stats,
+// hence full text is queried using the queryid of the statement or pid of the backend. The value from stats is
+// returned if the row has gone or there is nothing to query by. Returns empty string if the row has no query.
+func fetchQueryText(db *postgres.DB, pgssSchema string, c rowCursor) (string, error) {
+	text, ok := c.value("query")
+	if !ok {
+		return "", nil
+	}
+
+	var q string
+	var arg any
+
+	if queryid, ok := c.value("queryid"); ok && pgssSchema != "" {
+		var err error
+		q, err = query.Format(query.SelectStatementsQueryText, query.Options{PGSSSchema: pgssSchema})
+		if err != nil {
+			return text, err
+		}
+		arg = queryid
+	} else if pid, ok := c.value("pid"); ok {
+		n, err := strconv.Atoi(pid)
+		if err != nil {
+			return text, nil
+		}
+		q, arg = query.SelectActivityQueryText, n
+	} else {
+		return text, nil
+	}
+
+	var full sql.NullString
+	err := db.QueryRow(q, arg).Scan(&full)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !full.Valid) {
+		return text, nil
+	}
+	if err != nil {
+		return text, err
+	}
+
+	return full.String, nil
+}
+
+// renderDetail prints details of the selected row: all values of the row, the full query text, recent samples of
+// diffed columns and the actions which could be applied to the row.
+func renderDetail(w io.Writer, name string, diffIntvl [2]int, c rowCursor, text string) error {
+	_, err := fmt.Fprintf(w, "Details of the row selected in %s (Up/Down to scroll, 'q' or 'Esc' to continue)\n\n", name)
+	if err != nil {
+		return err
+	}
+
+	// All values of the row, one column per line.
+	var width int
+	for _, col := range c.cols {
+		width = max(width, len(col))
+	}
+
+	for i, col := range c.cols {
+		if i >= len(c.values) {
+			break
+		}
+
+		value := c.values[i].String
+		if col == "query" {
+			value = text
+		}
+
+		_, err = fmt.Fprintf(w, "%-*s  %s\n", width, col, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Recent samples of diffed columns.
+	if len(c.history) > 0 && diffIntvl[1] < len(c.cols) {
+		err = renderHistory(w, c.cols[diffIntvl[0]:diffIntvl[1]+1], c.history)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Actions applicable to the row.
+	actions := rowActions(name, c)
+	if len(actions) > 0 {
+		_, err = fmt.Fprintf(w, "\nactions:\n    %s\n", strings.Join(actions, "\n    "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderHistory prints recent samples of diffed columns as a table, the oldest sample first.
+func renderHistory(w io.Writer, cols []string, history []rowSample) error {
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = len(col)
+		for _, s := range history {
+			if i < len(s.values) {
+				widths[i] = max(widths[i], len(s.values[i]))
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nrecent samples of diffed columns:\n%-8s", "time")
+	if err != nil {
+		return err
+	}
+	for i, col := range cols {
+		_, err = fmt.Fprintf(w, "  %*s", widths[i], col)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range history {
+		_, err = fmt.Fprintf(w, "\n%-8s", s.ts.Format("15:04:05"))
+		if err != nil {
+			return err
+		}
+		for i := range cols {
+			var value string
+			if i < len(s.values) {
+				value = s.values[i]
+			}
+			_, err = fmt.Fprintf(w, "  %*s", widths[i], value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+// rowActions returns descriptions of actions which could be applied to the selected row using keys.
+func rowActions(name string, c rowCursor) []string {
+	var actions []string
+
+	if pid, ok := c.value("pid"); ok && pid != "" {
+		actions = append(actions,
+			fmt.Sprintf("'-' cancel query of the backend (pid %s)", pid),
+			fmt.Sprintf("'_' terminate the backend (pid %s)", pid),
+			fmt.Sprintf("'T' profile wait events of the backend (pid %s)", pid),
+		)
+	}
+
+	if queryid, ok := c.value("queryid"); ok && queryid != "" && strings.Contains(name, "statements") {
+		actions = append(actions, fmt.Sprintf("'G' show query report (queryid %s)", queryid))
+	}
+
+	if text, ok := c.value("query"); ok && text != "" {
+		actions = append(actions, "'e' explain the query")
+	}
+
+	return actions
+}
@@ -0,0 +1,85 @@
+package top
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_renderDetail(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := rowCursor{
+		cols: []string{"pid", "calls", "query"},
+		values: []sql.NullString{
+			{String: "123", Valid: true}, {String: "40", Valid: true}, {String: "SELECT ~", Valid: true},
+		},
+		history: []rowSample{
+			{ts: ts, values: []string{"4"}},
+			{ts: ts.Add(time.Second), values: []string{"40"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderDetail(&buf, "activity", [2]int{1, 1}, c, "SELECT * FROM t"))
+	assert.Equal(t, "Details of the row selected in activity (Up/Down to scroll, 'q' or 'Esc' to continue)\n\n"+
+		"pid    123\n"+
+		"calls  40\n"+
+		"query  SELECT * FROM t\n"+
+		"\nrecent samples of diffed columns:\n"+
+		"time      calls\n"+
+		"12:00:00      4\n"+
+		"12:00:01     40\n"+
+		"\nactions:\n"+
+		"    '-' cancel query of the backend (pid 123)\n"+
+		"    '_' terminate the backend (pid 123)\n"+
+		"    'T' profile wait events of the backend (pid 123)\n"+
+		"    'e' explain the query\n", buf.String())
+
+	// Row without history and actions.
+	c = rowCursor{cols: []string{"datname"}, values: []sql.NullString{{String: "postgres", Valid: true}}}
+	buf.Reset()
+	assert.NoError(t, renderDetail(&buf, "databases", [2]int{1, 5}, c, ""))
+	assert.Equal(t, "Details of the row selected in databases (Up/Down to scroll, 'q' or 'Esc' to continue)\n\n"+
+		"datname  postgres\n", buf.String())
+}
+
+func Test_rowActions(t *testing.T) {
+	c := rowCursor{cols: []string{"queryid", "query"}, values: []sql.NullString{{String: "abcdef0123", Valid: true}, {}}}
+	assert.Equal(t, []string{"'G' show query report (queryid abcdef0123)"}, rowActions("statements_timings", c))
+	assert.Nil(t, rowActions("queue", c))
+
+	c = rowCursor{cols: []string{"datname"}, values: []sql.NullString{{String: "postgres", Valid: true}}}
+	assert.Nil(t, rowActions("databases", c))
+}
+
+func Test_fetchQueryText(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	}
+	defer db.Close()
+
+	var pid string
+	assert.NoError(t, db.QueryRow("SELECT pg_backend_pid()::text").Scan(&pid))
+
+	// Row without query.
+	c := rowCursor{cols: []string{"datname"}, values: []sql.NullString{{String: "postgres", Valid: true}}}
+	got, err := fetchQueryText(db, "", c)
+	assert.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	// Text of query is queried by pid.
+	c = rowCursor{cols: []string{"pid", "query"}, values: []sql.NullString{{String: pid, Valid: true}, {String: "SELECT ~", Valid: true}}}
+	got, err = fetchQueryText(db, "", c)
+	assert.NoError(t, err)
+	assert.Contains(t, got, "pg_stat_activity")
+
+	// Value from stats is used when backend has gone.
+	c.values[0].String = "1"
+	got, err = fetchQueryText(db, "", c)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT ~", got)
+}
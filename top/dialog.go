@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/jroimartin/gocui"
 	"strings"
+	"time"
 )
 
 // dialogType defines type of dialog between pgcenter and user.
@@ -22,21 +23,29 @@ const (
 	dialogChangeAge
 	dialogQueryReport
 	dialogChangeRefresh
+	dialogAnnotate
+	dialogCancelSelected
+	dialogTerminateSelected
+	dialogExport
 )
 
 // dialogPrompts returns dialog prompt depending on user-requested actions.
 func dialogPrompts(t dialogType) string {
 	prompts := map[dialogType]string{
-		dialogPgReload:         "Reload configuration files (y/n): ",
-		dialogFilter:           "Set filter: ",
-		dialogCancelQuery:      "PID to cancel: ",
-		dialogTerminateBackend: "PID to terminate: ",
-		dialogCancelGroup:      "Cancel group of queries. Confirm [Enter - yes, Esc - no]",
-		dialogTerminateGroup:   "Terminate group of backends. Confirm [Enter - yes, Esc - no]",
-		dialogSetMask:          "Set state mask for group backends [a: active, i: idle, x: idle_xact, w: waiting, o: others]: ",
-		dialogChangeAge:        "Enter new min age, format: HH:MM:SS[.NN]: ",
-		dialogQueryReport:      "Enter the queryid: ",
-		dialogChangeRefresh:    "Change refresh (min 1, max 300) to ",
+		dialogPgReload:          "Reload configuration files (y/n): ",
+		dialogFilter:            "Set filter: ",
+		dialogCancelQuery:       "PID to cancel: ",
+		dialogTerminateBackend:  "PID to terminate: ",
+		dialogCancelGroup:       "Cancel group of queries. Confirm [Enter - yes, Esc - no]",
+		dialogTerminateGroup:    "Terminate group of backends. Confirm [Enter - yes, Esc - no]",
+		dialogSetMask:           "Set state mask for group backends [a: active, i: idle, x: idle_xact, w: waiting, o: others]: ",
+		dialogChangeAge:         "Enter new min age, format: HH:MM:SS[.NN]: ",
+		dialogQueryReport:       "Enter the queryid: ",
+		dialogChangeRefresh:     "Change refresh (min 1, max 300) to ",
+		dialogAnnotate:          "Annotation: ",
+		dialogCancelSelected:    "Cancel query of backend with pid %s. Confirm [Enter - yes, Esc - no]",
+		dialogTerminateSelected: "Terminate backend with pid %s. Confirm [Enter - yes, Esc - no]",
+		dialogExport:            "Export screen to file (.txt, .csv or .json): ",
 	}
 
 	return prompts[t]
@@ -47,6 +56,11 @@ func dialogOpen(app *app, d dialogType) func(g *gocui.Gui, _ *gocui.View) error
 	return func(g *gocui.Gui, _ *gocui.View) error {
 		prompt := dialogPrompts(d)
 
+		// Actions on the selected row show the value the action is applied to.
+		if d == dialogCancelSelected || d == dialogTerminateSelected {
+			prompt = fmt.Sprintf(prompt, app.config.selected)
+		}
+
 		// Cancel/terminate/mask dialogs are allowed in pg_stat_activity view only.
 		if (d == dialogCancelQuery || d == dialogTerminateBackend ||
 			d == dialogCancelGroup || d == dialogTerminateGroup ||
@@ -73,6 +87,11 @@ func dialogOpen(app *app, d dialogType) func(g *gocui.Gui, _ *gocui.View) error
 			return nil
 		}
 
+		if d == dialogAnnotate && app.annotationFile() == "" {
+			printCmdline(g, "No active recording to annotate, specify it using --annotate option or start recording using 'W'.")
+			return nil
+		}
+
 		maxX, _ := g.Size()
 
 		// Create one-line editable view, print a prompt and set cursor after it.
@@ -98,6 +117,19 @@ func dialogOpen(app *app, d dialogType) func(g *gocui.Gui, _ *gocui.View) error
 		v.Editable = true
 		v.Frame = false
 
+		// Suggest default name of the file, user can edit it.
+		if d == dialogExport {
+			filename := exportFilename(app.config.view.Name, time.Now())
+			_, err = fmt.Fprint(v, filename)
+			if err != nil {
+				return fmt.Errorf("print to dialog view failed: %w", err)
+			}
+			err = v.SetCursor(len(filename), 0)
+			if err != nil {
+				return fmt.Errorf("set cursor on dialog view failed: %w", err)
+			}
+		}
+
 		if _, err := g.SetCurrentView("dialog"); err != nil {
 			return fmt.Errorf("set dialog view as current on layout failed: %w", err)
 		}
@@ -138,13 +170,17 @@ func dialogFinish(app *app) func(g *gocui.Gui, v *gocui.View) error {
 		case dialogChangeAge:
 			message = changeQueryAge(answer, app.config)
 		case dialogQueryReport:
-			var r report
-			r, message = getQueryReport(answer, app.postgresProps.VersionNum, app.postgresProps.ExtPGSSSchema, app.db)
-			if message == "" {
-				message = printQueryReport(g, r, app.uiExit)
-			}
+			message = showQueryReport(g, app, answer)
 		case dialogChangeRefresh:
 			message = changeRefresh(answer, app.config)
+		case dialogAnnotate:
+			message = annotate(answer, app.annotationFile(), app.annotationKey())
+		case dialogCancelSelected:
+			message = killSingle(app.db, "cancel", app.config.selected)
+		case dialogTerminateSelected:
+			message = killSingle(app.db, "terminate", app.config.selected)
+		case dialogExport:
+			message = exportScreen(app, strings.TrimSpace(answer))
 		case dialogNone:
 			// do nothing
 		}
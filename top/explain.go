@@ -0,0 +1,116 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// explainStatementTimeout defines statement_timeout used for explaining queries, planning might be blocked by locks.
+const explainStatementTimeout = "5s"
+
+// explainSelected shows plan of the query selected by the row cursor in $PAGER program.
+func explainSelected(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if !app.config.cursor.active {
+			printCmdline(g, "Explain: no query selected, press 'c' to show the row cursor")
+			return nil
+		}
+
+		text, err := fetchQueryText(app.db, app.postgresProps.ExtPGSSSchema, app.config.cursor)
+		if err != nil {
+			printCmdline(g, "Explain: get full query text failed: %s", err)
+			return nil
+		}
+		if text == "" {
+			printCmdline(g, "Explain: no query in the selected row")
+			return nil
+		}
+
+		// Explain normalized query in redact mode, its plan doesn't contain literals.
+		if app.config.redact {
+			text = query.Normalize(text)
+		}
+
+		explain, err := query.Explain(app.postgresProps.VersionNum, text)
+		if err != nil {
+			printCmdline(g, "Explain: %s", err)
+			return nil
+		}
+
+		dbname := selectedDatabase(app.config.cursor)
+		plan, err := explainQuery(app.db, dbname, explain)
+		if err != nil {
+			printCmdline(g, "Explain: %s", err)
+			return nil
+		}
+
+		var pager string
+		if pager = os.Getenv("PAGER"); pager == "" {
+			pager = "less"
+		}
+
+		// Exit from UI and stats loop... will restore it after $PAGER is closed.
+		app.uiExit <- 1
+		g.Close()
+
+		cmd := exec.Command(pager) // #nosec G204,G702
+		cmd.Stdin = strings.NewReader(formatPlan(dbname, explain, plan))
+		cmd.Stdout = os.Stdout
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("run pager failed: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// selectedDatabase returns name of the database of the row selected by the row cursor.
+func selectedDatabase(c rowCursor) string {
+	if dbname, ok := c.value("datname"); ok {
+		return dbname
+	}
+
+	dbname, _ := c.value("database")
+	return dbname
+}
+
+// explainQuery executes EXPLAIN statement in the specified database, current database is used if not specified.
+// Temporary connection is always used, hence statement timeout is never set on the connection used for collecting
+// stats.
+func explainQuery(db *postgres.DB, dbname string, explain string) (string, error) {
+	if dbname == "" {
+		err := db.QueryRow("SELECT current_database()").Scan(&dbname)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	conn, err := postgres.ConnectDatabase(db, dbname)
+	if err != nil {
+		return "", fmt.Errorf("connect to database %s failed: %w", dbname, err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Exec(fmt.Sprintf("SET statement_timeout TO '%s'", explainStatementTimeout))
+	if err != nil {
+		return "", err
+	}
+
+	return stat.ExplainPlan(conn, explain)
+}
+
+// formatPlan returns explained statement and its plan prepared for printing.
+func formatPlan(dbname string, explain string, plan string) string {
+	if dbname == "" {
+		return fmt.Sprintf("%s\n\n%s\n", explain, plan)
+	}
+
+	return fmt.Sprintf("Database: %s\n%s\n\n%s\n", dbname, explain, plan)
+}
@@ -0,0 +1,53 @@
+package top
+
+import (
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_selectedDatabase(t *testing.T) {
+	c := rowCursor{cols: []string{"pid", "datname"}, values: []sql.NullString{{String: "123", Valid: true}, {String: "db1", Valid: true}}}
+	assert.Equal(t, "db1", selectedDatabase(c))
+
+	c = rowCursor{cols: []string{"user", "database"}, values: []sql.NullString{{String: "alice", Valid: true}, {String: "db2", Valid: true}}}
+	assert.Equal(t, "db2", selectedDatabase(c))
+
+	c = rowCursor{cols: []string{"relname"}, values: []sql.NullString{{String: "t1", Valid: true}}}
+	assert.Equal(t, "", selectedDatabase(c))
+}
+
+func Test_formatPlan(t *testing.T) {
+	assert.Equal(t, "Database: db1\nEXPLAIN SELECT 1\n\nResult  (cost=0.00..0.01 rows=1 width=4)\n",
+		formatPlan("db1", "EXPLAIN SELECT 1", "Result  (cost=0.00..0.01 rows=1 width=4)"))
+	assert.Equal(t, "EXPLAIN SELECT 1\n\nResult\n", formatPlan("", "EXPLAIN SELECT 1", "Result"))
+}
+
+func Test_explainQuery(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if err != nil {
+		t.Skipf("skip: test postgres not available: %v", err)
+	}
+	defer db.Close()
+
+	// Current database, statement timeout of current connection is not changed.
+	plan, err := explainQuery(db, "", "EXPLAIN SELECT * FROM pg_class")
+	assert.NoError(t, err)
+	assert.Contains(t, plan, "Seq Scan on pg_class")
+
+	var timeout string
+	assert.NoError(t, db.QueryRow("SHOW statement_timeout").Scan(&timeout))
+	assert.Equal(t, "0", timeout)
+
+	// Another database.
+	plan, err = explainQuery(db, "postgres", "EXPLAIN (GENERIC_PLAN) SELECT * FROM pg_class WHERE oid = $1")
+	assert.NoError(t, err)
+	assert.Contains(t, plan, "pg_class")
+
+	assert.NoError(t, db.QueryRow("SHOW statement_timeout").Scan(&timeout))
+	assert.Equal(t, "0", timeout)
+
+	_, err = explainQuery(db, "pgcenter_unknown_database", "EXPLAIN SELECT 1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package top
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// screen defines contents of the screen prepared for exporting into file.
+type screen struct {
+	View    string            `json:"view"`
+	Time    string            `json:"time"`
+	Summary []string          `json:"summary"`
+	Order   string            `json:"order"`
+	Filters map[string]string `json:"filters,omitempty"`
+	Columns []string          `json:"columns"`
+	Rows    [][]string        `json:"rows"`
+}
+
+// exportFilename returns default name of the file where the screen is exported.
+func exportFilename(name string, ts time.Time) string {
+	return fmt.Sprintf("./pgcenter-%s-%s.txt", name, ts.Format("20060102T150405"))
+}
+
+// exportScreen writes the displayed stats into file. Format of the file is defined by its extension.
+func exportScreen(app *app, filename string) string {
+	if filename == "" {
+		return "Export: do nothing"
+	}
+
+	s := app.config.last
+	if s.Error != nil || !s.Result.Valid {
+		return "Export: no stats to export"
+	}
+
+	// Time of the displayed stats, they might be received before when the display is paused.
+	ts := time.Now()
+	if app.config.history.paused {
+		ts = app.config.history.shown
+	}
+
+	var summary bytes.Buffer
+	err := renderSysstat(&summary, s, app.config.verbose, app.db.Local, app.postgresProps.DataDirectory, "")
+	if err != nil {
+		return fmt.Sprintf("Export: %s", err)
+	}
+	err = renderPgstat(&summary, s, app.postgresProps, app.db, app.config.verbose)
+	if err != nil {
+		return fmt.Sprintf("Export: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = writeScreen(&buf, filepath.Ext(filename), newScreen(app.config, s, summary.String(), ts))
+	if err != nil {
+		return fmt.Sprintf("Export: %s", err)
+	}
+
+	err = os.WriteFile(filepath.Clean(filename), buf.Bytes(), 0600)
+	if err != nil {
+		return fmt.Sprintf("Export: %s", err)
+	}
+
+	return fmt.Sprintf("Export: screen saved to %s", filename)
+}
+
+// newScreen returns contents of the screen: summary panels, and all rows of the stats which pass filters with full
+// values in current sort order. Columns are written in the order chosen by user, hidden columns are skipped.
+func newScreen(config *config, s stat.Stat, summary string, ts time.Time) screen {
+	sc := screen{
+		View:    config.view.Name,
+		Time:    ts.Format(time.RFC3339),
+		Summary: strings.Split(strings.TrimRight(ansiRE.ReplaceAllString(summary, ""), "\n"), "\n"),
+		Rows:    [][]string{},
+	}
+
+	cols := displayColumns(config, s.Result.Cols)
+	for _, idx := range cols {
+		sc.Columns = append(sc.Columns, s.Result.Cols[idx])
+	}
+
+	if config.view.OrderKey < len(s.Result.Cols) {
+		order := "asc"
+		if config.view.OrderDesc {
+			order = "desc"
+		}
+		sc.Order = s.Result.Cols[config.view.OrderKey] + " " + order
+	}
+
+	for i, re := range config.view.Filters {
+		if re != nil && re.String() != "" && i < len(s.Result.Cols) {
+			if sc.Filters == nil {
+				sc.Filters = map[string]string{}
+			}
+			sc.Filters[s.Result.Cols[i]] = re.String()
+		}
+	}
+
+	for _, rownum := range filterRows(s.Result, config.view.Filters, isFilterRequired(config.view.Filters)) {
+		row := make([]string, len(cols))
+		for i, idx := range cols {
+			row[i] = s.Result.Values[rownum][idx].String
+		}
+		sc.Rows = append(sc.Rows, row)
+	}
+
+	return sc
+}
+
+// writeScreen writes screen contents in format specified by file extension: .txt, .csv or .json.
+func writeScreen(w io.Writer, ext string, sc screen) error {
+	switch ext {
+	case ".txt":
+		return writeScreenText(w, sc)
+	case ".csv":
+		return writeScreenCSV(w, sc)
+	case ".json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sc)
+	default:
+		return fmt.Errorf("unknown format '%s', use .txt, .csv or .json", ext)
+	}
+}
+
+// screenHeader returns lines describing exported stats: view, time, sort order and filters.
+func screenHeader(sc screen) []string {
+	lines := []string{
+		fmt.Sprintf("view: %s, time: %s, order: %s", sc.View, sc.Time, sc.Order),
+	}
+
+	if len(sc.Filters) > 0 {
+		cols := make([]string, 0, len(sc.Filters))
+		for col := range sc.Filters {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+
+		filters := make([]string, len(cols))
+		for i, col := range cols {
+			filters[i] = fmt.Sprintf("%s ~ '%s'", col, sc.Filters[col])
+		}
+		lines = append(lines, "filters: "+strings.Join(filters, ", "))
+	}
+
+	return lines
+}
+
+// writeScreenText writes screen contents as plain text, values are aligned in columns.
+func writeScreenText(w io.Writer, sc screen) error {
+	lines := append(screenHeader(sc), "")
+	lines = append(lines, sc.Summary...)
+
+	_, err := fmt.Fprintf(w, "%s\n\n", strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+
+	widths := make([]int, len(sc.Columns))
+	for i, col := range sc.Columns {
+		widths[i] = len(col)
+		for _, row := range sc.Rows {
+			if i < len(row) {
+				widths[i] = max(widths[i], len(row[i]))
+			}
+		}
+	}
+
+	for _, row := range append([][]string{sc.Columns}, sc.Rows...) {
+		var line strings.Builder
+		for i, value := range row {
+			if i < len(widths) {
+				fmt.Fprintf(&line, "%-*s  ", widths[i], value)
+			}
+		}
+
+		_, err = fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeScreenCSV writes screen contents as CSV. Description of stats and summary panels are written as comment
+// lines started with '#'.
+func writeScreenCSV(w io.Writer, sc screen) error {
+	for _, line := range append(screenHeader(sc), sc.Summary...) {
+		_, err := fmt.Fprintf(w, "# %s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	err := cw.Write(sc.Columns)
+	if err != nil {
+		return err
+	}
+
+	err = cw.WriteAll(sc.Rows)
+	if err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
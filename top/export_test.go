@@ -0,0 +1,106 @@
+package top
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newExportScreen returns screen with filtered rows and summary.
+func newExportScreen() screen {
+	config := newConfig()
+	config.view = view.View{
+		Name:      "activity",
+		OrderKey:  1,
+		OrderDesc: true,
+		Filters:   map[int]*regexp.Regexp{0: regexp.MustCompile("r[13]")},
+	}
+
+	s := makeRenderResult(3, 4)
+	s.Result.Values[3][2].String = "long value which is not truncated"
+
+	summary := "\033[1mpgcenter: 12:00:00\033[0m, load average: 0.10\nactivity: 1/100 conns\n"
+	ts := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	return newScreen(config, s, summary, ts)
+}
+
+func Test_exportFilename(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 12, 30, 15, 0, time.UTC)
+	assert.Equal(t, "./pgcenter-activity-20261019T123015.txt", exportFilename("activity", ts))
+}
+
+func Test_newScreen(t *testing.T) {
+	sc := newExportScreen()
+
+	assert.Equal(t, "activity", sc.View)
+	assert.Equal(t, "2026-10-19T12:00:00Z", sc.Time)
+	assert.Equal(t, []string{"pgcenter: 12:00:00, load average: 0.10", "activity: 1/100 conns"}, sc.Summary)
+	assert.Equal(t, "col1 desc", sc.Order)
+	assert.Equal(t, map[string]string{"col0": "r[13]"}, sc.Filters)
+	assert.Equal(t, []string{"col0", "col1", "col2"}, sc.Columns)
+	assert.Equal(t, [][]string{
+		{"r1-c0", "r1-c1", "r1-c2"},
+		{"r3-c0", "r3-c1", "long value which is not truncated"},
+	}, sc.Rows)
+}
+
+func Test_writeScreen(t *testing.T) {
+	sc := newExportScreen()
+
+	// Plain text.
+	var buf bytes.Buffer
+	assert.NoError(t, writeScreen(&buf, ".txt", sc))
+	assert.Equal(t, strings.Join([]string{
+		"view: activity, time: 2026-10-19T12:00:00Z, order: col1 desc",
+		"filters: col0 ~ 'r[13]'",
+		"",
+		"pgcenter: 12:00:00, load average: 0.10",
+		"activity: 1/100 conns",
+		"",
+		"col0   col1   col2",
+		"r1-c0  r1-c1  r1-c2",
+		"r3-c0  r3-c1  long value which is not truncated",
+		"",
+	}, "\n"), buf.String())
+
+	// CSV.
+	buf.Reset()
+	assert.NoError(t, writeScreen(&buf, ".csv", sc))
+	assert.Equal(t, strings.Join([]string{
+		"# view: activity, time: 2026-10-19T12:00:00Z, order: col1 desc",
+		"# filters: col0 ~ 'r[13]'",
+		"# pgcenter: 12:00:00, load average: 0.10",
+		"# activity: 1/100 conns",
+		"col0,col1,col2",
+		"r1-c0,r1-c1,r1-c2",
+		"r3-c0,r3-c1,long value which is not truncated",
+		"",
+	}, "\n"), buf.String())
+
+	// JSON.
+	buf.Reset()
+	assert.NoError(t, writeScreen(&buf, ".json", sc))
+	var got screen
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, sc, got)
+
+	// Unknown format.
+	assert.Error(t, writeScreen(&buf, ".xml", sc))
+	assert.Error(t, writeScreen(&buf, "", sc))
+}
+
+func Test_newScreen_layout(t *testing.T) {
+	config := newConfig()
+	config.view = view.View{Name: "activity", Filters: map[int]*regexp.Regexp{}}
+	config.layouts["activity"] = columnLayout{order: []string{"col2"}, hidden: map[string]bool{"col1": true}}
+
+	sc := newScreen(config, makeRenderResult(3, 1), "", time.Now())
+	assert.Equal(t, []string{"col2", "col0"}, sc.Columns)
+	assert.Equal(t, [][]string{{"r0-c2", "r0-c0"}}, sc.Rows)
+}
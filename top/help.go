@@ -17,9 +17,15 @@ general actions:
     x,X               'x' pg_stat_statements switch, 'X' pg_stat_statements menu.
     p,P               'p' pg_stat_progress_* switch, 'P' pg_stat_progress_* menu.
     j,J               'j' pg_stat_io switch (operations/timings), 'J' pg_stat_io menu.
+    U                 'U' custom views menu, custom views are also switched by their hotkeys.
     S                 'S' per-process system stats (local mode only; Shift+S).
     Left,Right,<,/    'Left,Right' change column sort, '<' desc/asc sort toggle, '/' set filter.
     Up,Down           'Up' increase column width, 'Down' decrease column width.
+    V                 'V' columns menu: show/hide and reorder columns of the view.
+    c,Enter           'c' row cursor on/off (Up,Down or j,k move the cursor), 'Enter' show details of the row.
+    Space,{,}         'Space' pause/resume display, '{' show previous stats, '}' show next stats.
+    mouse             with --mouse: click column name to sort (again to switch order), click row to select it,
+                      wheel scrolls rows (columns over the header), click menu item to choose it.
     [,]               '[' scroll columns left, ']' scroll columns right.
     C,E,R       config: 'C' show config, 'E' edit configs, 'R' reload config.
     ~                 start psql session.
@@ -30,17 +36,23 @@ extra stats actions:
     v             'v' verbose mode for the summary panels on/off.
 
 activity actions:
-    -,_         '-' cancel backend by pid, '_' terminate backend by pid.
+    -,_         '-' cancel backend by pid, '_' terminate backend by pid (the selected backend when row cursor is on).
+    T           profile wait events of the backend selected by row cursor.
     n,m         'n' set new mask, 'm' show current mask.
     k,K         'k' cancel group of queries using mask, 'K' terminate group of backends using mask.
     I           show IDLE connections toggle.
     A           change activity age threshold.
-    G           get query report.
+    G           get query report (of the statement selected by row cursor when it is on).
+    e           explain query selected by row cursor.
 
 other actions:
     , Q         ',' show system tables on/off, 'Q' reset postgresql statistics counters
                       ('Q' does not reset shared stats: pg_stat_io, bgwriter, wal).
     z           'z' set refresh interval.
+    M           'M' write annotation into active recording.
+    W           'W' start/stop recording stats into file.
+    O           'O' export displayed screen to file (.txt, .csv or .json).
+    Z           'Z' save current settings into config file.
     h,F1        show this tab.
     q,Ctrl+Q    quit.
 
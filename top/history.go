@@ -0,0 +1,167 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"time"
+)
+
+// statHistorySize defines number of recent stats snapshots kept per view for rewinding the display.
+const statHistorySize = 30
+
+// statHistory keeps recent stats snapshots of views. Snapshots are used for showing the stats received before
+// when the display is paused.
+type statHistory struct {
+	paused    bool                      // Display is paused, received stats are kept but not printed.
+	shown     time.Time                 // Time of the snapshot shown while the display is paused.
+	back      int                       // Number of snapshots between the shown snapshot and the latest one.
+	snapshots map[string][]statSnapshot // Recent snapshots per view, the oldest first.
+}
+
+// statSnapshot defines stats received at the specified time.
+type statSnapshot struct {
+	ts   time.Time
+	stat stat.Stat
+}
+
+// add appends stats of the view to the history.
+func (h *statHistory) add(name string, s stat.Stat, ts time.Time) {
+	if h.snapshots == nil {
+		h.snapshots = map[string][]statSnapshot{}
+	}
+
+	snapshots := append(h.snapshots[name], statSnapshot{ts: ts, stat: s})
+	if len(snapshots) > statHistorySize {
+		snapshots = snapshots[len(snapshots)-statHistorySize:]
+	}
+	h.snapshots[name] = snapshots
+
+	if h.paused {
+		h.back = h.position(name)
+	}
+}
+
+// pause pauses the display on the latest snapshot of the view. Returns false if there are no snapshots.
+func (h *statHistory) pause(name string) (statSnapshot, bool) {
+	snapshots := h.snapshots[name]
+	if len(snapshots) == 0 {
+		return statSnapshot{}, false
+	}
+
+	latest := snapshots[len(snapshots)-1]
+	h.paused, h.shown, h.back = true, latest.ts, 0
+
+	return latest, true
+}
+
+// resume resumes the display and returns the latest snapshot of the view.
+func (h *statHistory) resume(name string) (statSnapshot, bool) {
+	h.paused, h.shown, h.back = false, time.Time{}, 0
+
+	snapshots := h.snapshots[name]
+	if len(snapshots) == 0 {
+		return statSnapshot{}, false
+	}
+
+	return snapshots[len(snapshots)-1], true
+}
+
+// step moves the display of paused view by the specified number of snapshots, negative step moves back in time.
+// Steps are clamped by the oldest and the latest snapshots.
+func (h *statHistory) step(name string, n int) (statSnapshot, bool) {
+	snapshots := h.snapshots[name]
+	if !h.paused || len(snapshots) == 0 {
+		return statSnapshot{}, false
+	}
+
+	// The shown snapshot could be evicted while the display is paused, continue from the oldest one then.
+	i := len(snapshots) - 1 - h.position(name) + n
+	i = min(max(i, 0), len(snapshots)-1)
+
+	h.shown = snapshots[i].ts
+	h.back = len(snapshots) - 1 - i
+
+	return snapshots[i], true
+}
+
+// position returns number of snapshots between the shown snapshot and the latest one.
+func (h *statHistory) position(name string) int {
+	snapshots := h.snapshots[name]
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].ts.After(h.shown) {
+			return len(snapshots) - 1 - i
+		}
+	}
+
+	return len(snapshots) - 1
+}
+
+// indicator returns string which tells the display is paused, and the time of shown stats.
+func (h *statHistory) indicator() string {
+	if !h.paused {
+		return ""
+	}
+
+	if h.back == 0 {
+		return fmt.Sprintf(", \033[33;1mpaused %s\033[0m", h.shown.Format("15:04:05"))
+	}
+
+	return fmt.Sprintf(", \033[33;1mpaused %s (%d back)\033[0m", h.shown.Format("15:04:05"), h.back)
+}
+
+// togglePause pauses or resumes the display. Stats are collected while the display is paused, resuming shows
+// the latest stats.
+func togglePause(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		name := app.config.view.Name
+
+		if app.config.history.paused {
+			printCmdline(g, "Display resumed")
+			snapshot, ok := app.config.history.resume(name)
+			if !ok {
+				return nil
+			}
+			return drawSnapshot(g, app, snapshot)
+		}
+
+		snapshot, ok := app.config.history.pause(name)
+		if !ok {
+			printCmdline(g, "Pause: no stats received yet")
+			return nil
+		}
+
+		printCmdline(g, "Display paused, use '{' and '}' to rewind, Space to resume")
+		return drawSnapshot(g, app, snapshot)
+	}
+}
+
+// rewindHistory shows snapshot received before or after the shown one. The display is paused if it is not paused.
+func rewindHistory(app *app, n int) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		name := app.config.view.Name
+
+		if !app.config.history.paused {
+			if _, ok := app.config.history.pause(name); !ok {
+				printCmdline(g, "Pause: no stats received yet")
+				return nil
+			}
+		}
+
+		snapshot, ok := app.config.history.step(name, n)
+		if !ok {
+			return nil
+		}
+
+		return drawSnapshot(g, app, snapshot)
+	}
+}
+
+// drawSnapshot prints stats snapshot from history.
+func drawSnapshot(g *gocui.Gui, app *app, snapshot statSnapshot) error {
+	if g == nil {
+		return nil
+	}
+
+	return drawStat(g, app, snapshot.stat, app.postgresProps, false)
+}
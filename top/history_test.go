@@ -0,0 +1,95 @@
+package top
+
+import (
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// newHistoryStat returns stats which could be told apart by load average.
+func newHistoryStat(n int) stat.Stat {
+	return stat.Stat{System: stat.System{LoadAvg: stat.LoadAvg{One: float64(n)}}}
+}
+
+func Test_statHistory(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	h := statHistory{}
+
+	// Nothing to show.
+	_, ok := h.pause("activity")
+	assert.False(t, ok)
+	_, ok = h.step("activity", -1)
+	assert.False(t, ok)
+
+	for i := 0; i < 5; i++ {
+		h.add("activity", newHistoryStat(i), ts.Add(time.Duration(i)*time.Second))
+	}
+	h.add("tables", newHistoryStat(100), ts)
+	assert.Equal(t, "", h.indicator())
+
+	// Pause shows the latest snapshot.
+	s, ok := h.pause("activity")
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, s.stat.LoadAvg.One)
+	assert.Equal(t, ", \033[33;1mpaused 12:00:04\033[0m", h.indicator())
+
+	// Step back and forth, steps are clamped.
+	s, _ = h.step("activity", -2)
+	assert.Equal(t, 2.0, s.stat.LoadAvg.One)
+	assert.Equal(t, ", \033[33;1mpaused 12:00:02 (2 back)\033[0m", h.indicator())
+	s, _ = h.step("activity", -10)
+	assert.Equal(t, 0.0, s.stat.LoadAvg.One)
+	s, _ = h.step("activity", 10)
+	assert.Equal(t, 4.0, s.stat.LoadAvg.One)
+	s, _ = h.step("activity", -1)
+	assert.Equal(t, 3.0, s.stat.LoadAvg.One)
+
+	// Stats received while paused don't change the shown snapshot.
+	h.add("activity", newHistoryStat(5), ts.Add(5*time.Second))
+	assert.Equal(t, 2, h.back)
+	s, _ = h.step("activity", 0)
+	assert.Equal(t, 3.0, s.stat.LoadAvg.One)
+
+	// Resume shows the latest snapshot.
+	s, ok = h.resume("activity")
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, s.stat.LoadAvg.One)
+	assert.Equal(t, "", h.indicator())
+	_, ok = h.step("activity", -1)
+	assert.False(t, ok)
+
+	// History is limited, evicted snapshot is replaced with the oldest one.
+	h.pause("activity")
+	h.step("activity", -5)
+	for i := 6; i < statHistorySize+10; i++ {
+		h.add("activity", newHistoryStat(i), ts.Add(time.Duration(i)*time.Second))
+	}
+	assert.Len(t, h.snapshots["activity"], statHistorySize)
+	assert.Len(t, h.snapshots["tables"], 1)
+	s, _ = h.step("activity", 0)
+	assert.Equal(t, h.snapshots["activity"][0], s)
+}
+
+func Test_togglePause(t *testing.T) {
+	app := &app{config: newConfig()}
+	app.config.view = app.config.views["activity"]
+
+	assert.NoError(t, togglePause(app)(nil, nil))
+	assert.False(t, app.config.history.paused)
+
+	app.config.history.add("activity", newHistoryStat(1), time.Now())
+	app.config.history.add("activity", newHistoryStat(2), time.Now().Add(time.Second))
+
+	assert.NoError(t, togglePause(app)(nil, nil))
+	assert.True(t, app.config.history.paused)
+	assert.NoError(t, togglePause(app)(nil, nil))
+	assert.False(t, app.config.history.paused)
+
+	// Rewinding pauses the display.
+	assert.NoError(t, rewindHistory(app, -1)(nil, nil))
+	assert.True(t, app.config.history.paused)
+	assert.Equal(t, 1, app.config.history.back)
+	assert.NoError(t, rewindHistory(app, 1)(nil, nil))
+	assert.Equal(t, 0, app.config.history.back)
+}
@@ -21,8 +21,8 @@ func keybindings(app *app) error {
 		{"sysstat", 'q', app.quit()},
 		{"sysstat", gocui.KeyArrowLeft, orderKeyLeft(app.config)},
 		{"sysstat", gocui.KeyArrowRight, orderKeyRight(app.config)},
-		{"sysstat", gocui.KeyArrowUp, increaseWidth(app.config)},
-		{"sysstat", gocui.KeyArrowDown, decreaseWidth(app.config)},
+		{"sysstat", gocui.KeyArrowUp, moveRowCursor(app.config, -1, increaseWidth(app.config))},
+		{"sysstat", gocui.KeyArrowDown, moveRowCursor(app.config, 1, decreaseWidth(app.config))},
 		{"sysstat", '[', scrollLeft(app.config)},
 		{"sysstat", ']', scrollRight(app.config)},
 		{"sysstat", '<', switchSortOrder(app.config)},
@@ -40,13 +40,15 @@ func keybindings(app *app) error {
 		{"sysstat", 'p', switchViewTo(app, "progress")},
 		{"sysstat", 'a', switchViewTo(app, "activity")},
 		{"sysstat", 'x', switchViewTo(app, "statements")},
-		{"sysstat", 'j', switchViewTo(app, "statio")},
+		{"sysstat", 'j', moveRowCursor(app.config, 1, switchViewTo(app, "statio"))},
 		{"sysstat", 'Q', resetStat(app.db, app.postgresProps.ExtPGSSSchema)},
 		{"sysstat", 'E', menuOpen(menuConf, app.config, "")},
 		{"sysstat", 'D', menuOpen(menuDatabases, app.config, "")},
 		{"sysstat", 'X', menuOpen(menuPgss, app.config, app.postgresProps.ExtPGSSSchema)},
 		{"sysstat", 'P', menuOpen(menuProgress, app.config, "")},
 		{"sysstat", 'J', menuOpen(menuStatIO, app.config, "")},
+		{"sysstat", 'U', menuOpen(menuCustom, app.config, "")},
+		{"sysstat", 'V', menuOpen(menuColumns, app.config, "")},
 		{"sysstat", 'l', showPgLog(app.db, app.postgresProps.VersionNum, app.uiExit)},
 		{"sysstat", 'C', showPgConfig(app.db, app.uiExit)},
 		{"sysstat", '~', runPsql(app.db, app.uiExit)},
@@ -58,27 +60,63 @@ func keybindings(app *app) error {
 		{"sysstat", 'S', switchViewToProcPidStat(app)},
 		{"sysstat", 'R', dialogOpen(app, dialogPgReload)},
 		{"sysstat", '/', dialogOpen(app, dialogFilter)},
-		{"sysstat", '-', dialogOpen(app, dialogCancelQuery)},
-		{"sysstat", '_', dialogOpen(app, dialogTerminateBackend)},
+		{"sysstat", '-', killSelected(app, dialogCancelQuery)},
+		{"sysstat", '_', killSelected(app, dialogTerminateBackend)},
+		{"sysstat", 'T', profileBackend(app)},
+		{"sysstat", 'e', explainSelected(app)},
 		{"sysstat", 'n', dialogOpen(app, dialogSetMask)},
 		{"sysstat", 'm', showProcMask(app.config)},
-		{"sysstat", 'k', dialogOpen(app, dialogCancelGroup)},
+		{"sysstat", 'k', moveRowCursor(app.config, -1, dialogOpen(app, dialogCancelGroup))},
 		{"sysstat", 'K', dialogOpen(app, dialogTerminateGroup)},
 		{"sysstat", 'A', dialogOpen(app, dialogChangeAge)},
-		{"sysstat", 'G', dialogOpen(app, dialogQueryReport)},
+		{"sysstat", 'G', queryReport(app)},
 		{"sysstat", 'z', dialogOpen(app, dialogChangeRefresh)},
+		{"sysstat", 'M', dialogOpen(app, dialogAnnotate)},
+		{"sysstat", 'W', toggleRecording(app)},
+		{"sysstat", 'Z', saveUserConfig(app)},
+		{"sysstat", 'c', toggleRowCursor(app.config)},
+		{"sysstat", gocui.KeySpace, togglePause(app)},
+		{"sysstat", 'O', dialogOpen(app, dialogExport)},
+		{"sysstat", '{', rewindHistory(app, -1)},
+		{"sysstat", '}', rewindHistory(app, 1)},
+		{"sysstat", gocui.KeyEnter, showDetail(app)},
 		{"dialog", gocui.KeyEsc, dialogCancel(app)},
 		{"dialog", gocui.KeyEnter, dialogFinish(app)},
 		{"menu", gocui.KeyEsc, menuClose},
 		{"menu", gocui.KeyArrowUp, moveCursor(moveUp, app.config)},
 		{"menu", gocui.KeyArrowDown, moveCursor(moveDown, app.config)},
 		{"menu", gocui.KeyEnter, menuSelect(app)},
+		{"menu", gocui.KeySpace, toggleColumnHandler(app.config)},
+		{"menu", 'u', moveColumnHandler(app.config, -1)},
+		{"menu", 'd', moveColumnHandler(app.config, 1)},
+		{"menu", gocui.MouseLeft, menuClick(app)},
+		{"menu", gocui.MouseWheelUp, moveCursor(moveUp, app.config)},
+		{"menu", gocui.MouseWheelDown, moveCursor(moveDown, app.config)},
+		{"dbstat", gocui.MouseLeft, mouseClick(app)},
+		{"dbstat", gocui.MouseWheelUp, mouseWheel(app, -1)},
+		{"dbstat", gocui.MouseWheelDown, mouseWheel(app, 1)},
 		{"sysstat", 'h', showHelp},
 		{"sysstat", gocui.KeyF1, showHelp},
 		{"help", gocui.KeyEsc, closeHelp},
 		{"help", 'q', closeHelp},
+		{"detail", gocui.KeyEsc, closeDetail},
+		{"detail", 'q', closeDetail},
+		{"detail", gocui.KeyArrowUp, scrollDetail(-1)},
+		{"detail", gocui.KeyArrowDown, scrollDetail(1)},
+		{"detail", '-', detailAction(killSelected(app, dialogCancelQuery))},
+		{"detail", '_', detailAction(killSelected(app, dialogTerminateBackend))},
+		{"detail", 'T', detailAction(profileBackend(app))},
+		{"detail", 'G', detailAction(queryReport(app))},
+		{"detail", 'e', detailAction(explainSelected(app))},
 	}
 
+	// Add keys switching to custom views.
+	custom, err := customViewsKeys(app, keys)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, custom...)
+
 	app.ui.InputEsc = true
 
 	for _, k := range keys {
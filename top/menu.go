@@ -3,6 +3,7 @@ package top
 import (
 	"fmt"
 	"github.com/jroimartin/gocui"
+	"strings"
 )
 
 // menuType defines a type of the used menu.
@@ -19,6 +20,8 @@ const (
 	menuProgress                  // menu with pg_stat_progress_* stats
 	menuConf                      // menu with configuration files
 	menuStatIO                    // menu with pg_stat_io stats
+	menuCustom                    // menu with custom views
+	menuColumns                   // menu with columns of the current view
 
 	// Directions allowed when working with menu.
 	moveUp   direction = iota // move up
@@ -93,6 +96,18 @@ func selectMenuStyle(t menuType) menuStyle {
 				" pg_stat_io timings",
 			},
 		}
+	case menuCustom:
+		// Items depend on user's configuration and are defined when menu is opened.
+		s = menuStyle{
+			menuType: menuCustom,
+			title:    " Choose custom view (Enter to choose, Esc to exit): ",
+		}
+	case menuColumns:
+		// Items depend on columns of the current view and are defined when menu is opened.
+		s = menuStyle{
+			menuType: menuColumns,
+			title:    " Choose columns (Space show/hide, u,d move up/down, Esc to exit): ",
+		}
 	default:
 		s = menuStyle{
 			menuType: menuNone,
@@ -113,7 +128,27 @@ func menuOpen(m menuType, config *config, pgssSchema string) func(g *gocui.Gui,
 			return nil
 		}
 
-		v, err := g.SetView("menu", 0, 5, 72, 6+len(s.items))
+		if s.menuType == menuCustom {
+			s.items = customViewsMenuItems(config)
+			if len(s.items) == 0 {
+				printCmdline(g, "NOTICE: no custom views in menu")
+				return nil
+			}
+		}
+
+		if s.menuType == menuColumns {
+			if len(config.view.Cols) == 0 {
+				printCmdline(g, "NOTICE: columns of the view are not known yet")
+				return nil
+			}
+			s.items = columnsMenuItems(config)
+		}
+
+		// Long menus don't fit the screen, they are scrolled when cursor is moved.
+		_, maxY := g.Size()
+		height := min(len(s.items), max(maxY-8, 1))
+
+		v, err := g.SetView("menu", 0, 5, 72, 6+height)
 		if err != nil {
 			if err != gocui.ErrUnknownView {
 				return err
@@ -124,7 +159,7 @@ func menuOpen(m menuType, config *config, pgssSchema string) func(g *gocui.Gui,
 			return err
 		}
 
-		err = menuDraw(v, s.items)
+		err = menuDraw(v, config.theme, s.items)
 		if err != nil {
 			return err
 		}
@@ -140,7 +175,7 @@ func menuOpen(m menuType, config *config, pgssSchema string) func(g *gocui.Gui,
 func menuSelect(app *app) func(g *gocui.Gui, v *gocui.View) error {
 	return func(g *gocui.Gui, v *gocui.View) error {
 		// 'cy' points to an index of the selected menu item, use it to switch to a context.
-		_, cy := v.Cursor()
+		cy := menuPosition(v)
 
 		switch app.config.menu.menuType {
 		case menuDatabases:
@@ -201,6 +236,11 @@ func menuSelect(app *app) func(g *gocui.Gui, v *gocui.View) error {
 				viewSwitchHandler(app.config, "stat_io")
 			}
 			printCmdline(app.ui, "%s", app.config.view.Msg)
+		case menuCustom:
+			if cy < len(app.config.menu.items) {
+				viewSwitchHandler(app.config, strings.TrimSpace(app.config.menu.items[cy]))
+				printCmdline(app.ui, "%s", app.config.view.Msg)
+			}
 		case menuConf:
 			switch cy {
 			case 0:
@@ -220,6 +260,8 @@ func menuSelect(app *app) func(g *gocui.Gui, v *gocui.View) error {
 					return err
 				}
 			}
+		case menuColumns:
+			/* columns are changed in place, just close the menu */
 		case menuNone:
 			/* do nothing */
 		}
@@ -242,14 +284,51 @@ func menuClose(g *gocui.Gui, _ *gocui.View) error {
 	return nil
 }
 
-// menuDraw draws passed items in the menu.
-func menuDraw(v *gocui.View, items []string) error {
+// menuPosition returns index of the menu item under cursor, taking into account scrolled menu.
+func menuPosition(v *gocui.View) int {
+	if v == nil {
+		return 0
+	}
+
+	_, oy := v.Origin()
 	_, cy := v.Cursor()
+	return oy + cy
+}
+
+// menuSetPosition moves cursor to the menu item with specified index, menu is scrolled if the item is out of view.
+func menuSetPosition(v *gocui.View, pos int) error {
+	if v == nil {
+		return nil
+	}
+
+	ox, oy := v.Origin()
+	cx, _ := v.Cursor()
+	_, height := v.Size()
+	height = max(height, 1)
+
+	switch {
+	case pos < oy:
+		oy = pos
+	case pos >= oy+height:
+		oy = pos - height + 1
+	}
+
+	err := v.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	return v.SetCursor(cx, pos-oy)
+}
+
+// menuDraw draws passed items in the menu using colors of the theme.
+func menuDraw(v *gocui.View, t theme, items []string) error {
+	cy := menuPosition(v)
 	v.Clear()
 	// print menu items
 	for i, item := range items {
 		if i == cy {
-			_, err := fmt.Fprintln(v, "\033[30;47m"+item+"\033[0m")
+			_, err := fmt.Fprintln(t.writer(v), "\033[30;47m"+item+"\033[0m")
 			if err != nil {
 				return err
 			}
@@ -273,7 +352,7 @@ func moveCursor(d direction, config *config) func(g *gocui.Gui, v *gocui.View) e
 
 		limit := len(config.menu.items)
 
-		cx, cy := v.Cursor()
+		cy := menuPosition(v)
 		switch d {
 		case moveDown:
 			// Set cursor position to next menu item, check if it's out of last menu item then set cursor to the first menu item.
@@ -282,11 +361,11 @@ func moveCursor(d direction, config *config) func(g *gocui.Gui, v *gocui.View) e
 				pos = 0
 			}
 
-			err := v.SetCursor(cx, pos)
+			err := menuSetPosition(v, pos)
 			if err != nil {
 				return err
 			}
-			err = menuDraw(v, config.menu.items)
+			err = menuDraw(v, config.theme, config.menu.items)
 			if err != nil {
 				return err
 			}
@@ -297,11 +376,11 @@ func moveCursor(d direction, config *config) func(g *gocui.Gui, v *gocui.View) e
 				pos = limit - 1
 			}
 
-			err := v.SetCursor(cx, pos)
+			err := menuSetPosition(v, pos)
 			if err != nil {
 				return err
 			}
-			err = menuDraw(v, config.menu.items)
+			err = menuDraw(v, config.theme, config.menu.items)
 			if err != nil {
 				return err
 			}
@@ -16,6 +16,7 @@ func Test_selectMenuStyle(t *testing.T) {
 		{menu: menuProgress, want: 6},
 		{menu: menuConf, want: 4},
 		{menu: menuStatIO, want: 2},
+		{menu: menuCustom, want: 0}, // items depend on configuration
 	}
 
 	for _, tc := range testcases {
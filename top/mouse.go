@@ -0,0 +1,152 @@
+package top
+
+import (
+	"github.com/jroimartin/gocui"
+)
+
+// clampRowOffset clamps the vertical scroll position by the number of printed rows and the number of lines available
+// for them. When the row cursor is shown, the stats table is scrolled to keep the selected row on the screen.
+func clampRowOffset(config *config, nrows int, lines int) {
+	lines = max(lines, 1)
+
+	if config.cursor.active {
+		if config.cursor.row < config.rowOffset {
+			config.rowOffset = config.cursor.row
+		}
+		if config.cursor.row >= config.rowOffset+lines {
+			config.rowOffset = config.cursor.row - lines + 1
+		}
+	}
+
+	config.rowOffset = min(max(config.rowOffset, 0), max(nrows-lines, 0))
+}
+
+// columnAt returns index of the column printed at the specified position of the header line. Returns false if there
+// is no column at the position, e.g. position points to an edge marker.
+func columnAt(config *config, x int) (int, bool) {
+	// Nothing has been printed yet.
+	win := config.window
+	if len(win.cols) == 0 {
+		return 0, false
+	}
+
+	// Frozen column.
+	width := config.view.ColsWidth[win.column(0)] + 2
+	if x < width {
+		return win.column(0), x >= 0
+	}
+	x -= width
+
+	if win.hiddenLeft {
+		if x < markerWidth {
+			return 0, false
+		}
+		x -= markerWidth
+	}
+
+	for pos := win.first; pos <= win.last; pos++ {
+		width = config.view.ColsWidth[win.column(pos)] + 2
+		if x < width {
+			return win.column(pos), true
+		}
+		x -= width
+	}
+
+	return 0, false
+}
+
+// mouseClick handles clicks on the stats table.
+func mouseClick(app *app) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		// Ignore clicks when menu, dialog or another view is opened.
+		if g.CurrentView() == nil || g.CurrentView().Name() != "sysstat" {
+			return nil
+		}
+
+		cx, cy := v.Cursor()
+		if msg := clickStat(app.config, cx, cy); msg != "" {
+			printCmdline(g, "%s", msg)
+		}
+
+		return redrawDbstat(g, app.config)
+	}
+}
+
+// clickStat handles click at the specified position of the stats table. Click on the header sorts stats by the
+// column, click on the ordered column switches sort order. Click on the row selects the row, the row cursor is
+// shown if it is hidden. Returns message for the cmdline.
+func clickStat(config *config, x int, y int) string {
+	if y == 0 {
+		idx, ok := columnAt(config, x)
+		if !ok {
+			return ""
+		}
+
+		var msg string
+		if idx == config.view.OrderKey {
+			config.view.OrderDesc = !config.view.OrderDesc
+			msg = "Switch sort order"
+		} else {
+			config.view.OrderKey = idx
+		}
+
+		config.viewCh <- config.view
+		return msg
+	}
+
+	// Keys of printed rows are known when the cursor is shown.
+	config.cursor.active = true
+	config.cursor.sync(config.view, config.last.Result)
+
+	pos := config.rowOffset + y - 1
+	if pos < len(config.cursor.keys) {
+		config.cursor.selectRow(pos)
+		config.cursor.sync(config.view, config.last.Result)
+	}
+
+	return ""
+}
+
+// mouseWheel handles wheel scrolling over the stats table.
+func mouseWheel(app *app, step int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if g.CurrentView() == nil || g.CurrentView().Name() != "sysstat" {
+			return nil
+		}
+
+		_, cy := v.Cursor()
+		wheelStat(app.config, cy, step)
+
+		return redrawDbstat(g, app.config)
+	}
+}
+
+// wheelStat scrolls the stats table by the specified number of steps. Wheel over the header scrolls columns, wheel
+// over rows moves the row cursor when it is shown, otherwise it scrolls rows. Offsets are clamped at render time.
+func wheelStat(config *config, y int, step int) {
+	switch {
+	case y == 0:
+		config.scrollOffset = max(config.scrollOffset+step, 0)
+	case config.cursor.active:
+		config.cursor.move(step)
+		config.cursor.sync(config.view, config.last.Result)
+	default:
+		config.rowOffset = max(config.rowOffset+step, 0)
+	}
+}
+
+// menuClick handles clicks on menu items. Click on the item of columns menu shows or hides the column, click on the
+// item of other menus chooses the item.
+func menuClick(app *app) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if menuPosition(v) >= len(app.config.menu.items) {
+			return nil
+		}
+
+		if app.config.menu.menuType == menuColumns {
+			return toggleColumnHandler(app.config)(g, v)
+		}
+
+		return menuSelect(app)(g, v)
+	}
+}
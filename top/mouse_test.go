@@ -0,0 +1,150 @@
+package top
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_clampRowOffset(t *testing.T) {
+	testcases := []struct {
+		offset int
+		active bool
+		row    int
+		want   int
+	}{
+		{offset: 0, want: 0},
+		{offset: 5, want: 5},
+		{offset: 20, want: 6}, // 10 rows, 4 lines
+		{offset: -1, want: 0}, // negative offset
+		{offset: 0, active: true, row: 7, want: 4},
+		{offset: 5, active: true, row: 2, want: 2},
+		{offset: 3, active: true, row: 4, want: 3},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			config := newConfig()
+			config.rowOffset = tc.offset
+			config.cursor.active, config.cursor.row = tc.active, tc.row
+
+			clampRowOffset(config, 10, 4)
+			assert.Equal(t, tc.want, config.rowOffset)
+		})
+	}
+}
+
+func Test_columnAt(t *testing.T) {
+	cfg := makeRenderConfig(4, 5)
+
+	// Nothing printed yet.
+	_, ok := columnAt(cfg, 0)
+	assert.False(t, ok)
+
+	// Columns are 7 cells wide: col0 [0,7), col1 [7,14), col2 [14,21), col3 [21,28).
+	var buf bytes.Buffer
+	assert.NoError(t, renderDbstat(&buf, cfg, makeRenderResult(4, 1), 200))
+
+	testcases := []struct {
+		x      int
+		want   int
+		wantOk bool
+	}{
+		{x: 0, want: 0, wantOk: true},
+		{x: 6, want: 0, wantOk: true},
+		{x: 7, want: 1, wantOk: true},
+		{x: 27, want: 3, wantOk: true},
+		{x: 28, wantOk: false},
+		{x: -1, wantOk: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			got, ok := columnAt(cfg, tc.x)
+			assert.Equal(t, tc.wantOk, ok)
+			if tc.wantOk {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+
+	// Reordered columns and the left edge marker.
+	cfg.layouts = map[string]columnLayout{"": {order: []string{"col3"}}}
+	cfg.scrollOffset = 1
+	buf.Reset()
+	assert.NoError(t, renderDbstat(&buf, cfg, makeRenderResult(4, 1), 20))
+
+	got, ok := columnAt(cfg, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 3, got)
+	_, ok = columnAt(cfg, 7) // left marker
+	assert.False(t, ok)
+	got, ok = columnAt(cfg, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 1, got)
+}
+
+func Test_clickStat(t *testing.T) {
+	cfg := makeRenderConfig(4, 5)
+	cfg.viewCh = make(chan view.View, 1)
+	cfg.last = makeRenderResult(4, 5)
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderDbstat(&buf, cfg, cfg.last, 200))
+
+	// Click on the header sorts by the column.
+	assert.Equal(t, "", clickStat(cfg, 8, 0))
+	assert.Equal(t, 1, (<-cfg.viewCh).OrderKey)
+	assert.False(t, cfg.view.OrderDesc)
+
+	// Click on the ordered column switches sort order.
+	assert.Equal(t, "Switch sort order", clickStat(cfg, 8, 0))
+	assert.True(t, (<-cfg.viewCh).OrderDesc)
+
+	// Click on the row selects it.
+	cfg.rowOffset = 1
+	assert.Equal(t, "", clickStat(cfg, 0, 2))
+	assert.True(t, cfg.cursor.active)
+	assert.Equal(t, 2, cfg.cursor.row)
+	v, _ := cfg.cursor.value("col0")
+	assert.Equal(t, "r2-c0", v)
+
+	// Click below rows keeps the selection.
+	assert.Equal(t, "", clickStat(cfg, 0, 20))
+	assert.Equal(t, 2, cfg.cursor.row)
+}
+
+func Test_wheelStat(t *testing.T) {
+	cfg := makeRenderConfig(4, 5)
+	cfg.last = makeRenderResult(4, 5)
+
+	// Wheel over the header scrolls columns.
+	wheelStat(cfg, 0, 1)
+	assert.Equal(t, 1, cfg.scrollOffset)
+	wheelStat(cfg, 0, -2)
+	assert.Equal(t, 0, cfg.scrollOffset)
+
+	// Wheel over rows scrolls rows.
+	wheelStat(cfg, 1, 1)
+	assert.Equal(t, 1, cfg.rowOffset)
+	wheelStat(cfg, 1, -2)
+	assert.Equal(t, 0, cfg.rowOffset)
+
+	var buf bytes.Buffer
+	cfg.rowOffset = 3
+	assert.NoError(t, renderDbstat(&buf, cfg, cfg.last, 200))
+	lines := strings.Split(strings.TrimSpace(ansiRE.ReplaceAllString(buf.String(), "")), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "r3-c0")
+
+	// Wheel over rows moves the row cursor when it is shown.
+	cfg.rowOffset = 0
+	cfg.cursor.active = true
+	cfg.cursor.sync(cfg.view, cfg.last.Result)
+	wheelStat(cfg, 1, 1)
+	assert.Equal(t, 1, cfg.cursor.row)
+	assert.Equal(t, 0, cfg.rowOffset)
+}
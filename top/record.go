@@ -0,0 +1,140 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/record"
+	"sync"
+	"time"
+)
+
+// recording defines background recording of stats started from 'pgcenter top'.
+type recording struct {
+	mu       sync.Mutex
+	recorder *record.Background
+	stopping sync.WaitGroup // recordings being stopped in background
+}
+
+// toggleRecording starts background recording of stats or stops it if recording is already running. Stopping
+// waits until the last snapshot is written, hence its result is printed when it is done.
+func toggleRecording(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		stopped := func(msg string) {
+			g.Update(func(g *gocui.Gui) error {
+				printCmdline(g, "%s", msg)
+				return nil
+			})
+		}
+
+		printCmdline(g, "%s", app.recording.toggle(app.db.Config, recordingConfig(app.config, time.Now()), stopped))
+		return nil
+	}
+}
+
+// recordingConfig returns settings of recording started from 'pgcenter top'. Stats are recorded with the current
+// refresh interval and query texts are limited to the same length as in 'top', hence the results of the displayed
+// stats could be recorded without querying them again. Changing refresh interval doesn't affect running recording.
+func recordingConfig(config *config, ts time.Time) record.Config {
+	return record.Config{
+		Interval:    config.refresh,
+		OutputFile:  recordingFilename(ts),
+		StringLimit: config.queryOptions.PgSSQueryLen,
+		Redact:      config.redact,
+		ConfigFile:  config.configFile,
+	}
+}
+
+// toggle starts or stops recording and returns message for user. Recording is stopped in background, the message
+// about its result is passed to stopped function.
+func (r *recording) toggle(dbConfig postgres.Config, config record.Config, stopped func(string)) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorder != nil {
+		rec := r.recorder
+		r.recorder = nil
+
+		r.stopping.Add(1)
+		go func() {
+			defer r.stopping.Done()
+			stopped(stopMessage(rec.Filename(), rec.Stop()))
+		}()
+
+		return "Recording: stopping..."
+	}
+
+	rec, err := record.StartBackground(dbConfig, config)
+	if err != nil {
+		return fmt.Sprintf("Recording: failed, %s", err.Error())
+	}
+
+	r.recorder = rec
+
+	return fmt.Sprintf("Recording: started, stats are saved to %s", config.OutputFile)
+}
+
+// stopMessage returns message about the result of stopped recording.
+func stopMessage(filename string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Recording: stopped, %s", err.Error())
+	}
+
+	return fmt.Sprintf("Recording: stopped, stats saved to %s", filename)
+}
+
+// stop stops recording if it is running and waits until recordings being stopped in background are stopped.
+func (r *recording) stop() {
+	r.mu.Lock()
+	if r.recorder != nil {
+		_ = r.recorder.Stop()
+		r.recorder = nil
+	}
+	r.mu.Unlock()
+
+	r.stopping.Wait()
+}
+
+// share passes stats collected for displaying to recorder, hence they are not collected twice.
+func (r *recording) share(s stat.Stat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorder != nil && s.Error == nil {
+		r.recorder.Share(s.Pgstat.Query, s.Pgstat.Raw, s.Pgstat.Collected)
+	}
+}
+
+// filename returns name of the file where stats are recorded, empty string is returned if recording is not running.
+func (r *recording) filename() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorder == nil {
+		return ""
+	}
+
+	return r.recorder.Filename()
+}
+
+// indicator returns recording indicator displayed in sysstat panel.
+func (r *recording) indicator() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorder == nil {
+		return ""
+	}
+
+	if r.recorder.Err() != nil {
+		return ", \033[31;1mrecording failed\033[0m"
+	}
+
+	return ", \033[31;1mrecording\033[0m"
+}
+
+// recordingFilename returns name of the file where stats recorded from 'pgcenter top' are saved.
+func recordingFilename(ts time.Time) string {
+	return fmt.Sprintf("pgcenter.stat.%s.tar", ts.Format("20060102T150405"))
+}
@@ -0,0 +1,50 @@
+package top
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recordingFilename(t *testing.T) {
+	ts := time.Date(2026, 5, 19, 10, 15, 30, 0, time.UTC)
+	assert.Equal(t, "pgcenter.stat.20260519T101530.tar", recordingFilename(ts))
+}
+
+func Test_recordingConfig(t *testing.T) {
+	ts := time.Date(2026, 5, 19, 10, 15, 30, 0, time.UTC)
+	config := newConfig()
+	config.refresh = 5 * time.Second
+	config.queryOptions.PgSSQueryLen = 256
+	config.redact = true
+
+	got := recordingConfig(config, ts)
+	assert.Equal(t, 5*time.Second, got.Interval)
+	assert.Equal(t, "pgcenter.stat.20260519T101530.tar", got.OutputFile)
+	assert.Equal(t, 256, got.StringLimit)
+	assert.True(t, got.Redact)
+}
+
+func Test_stopMessage(t *testing.T) {
+	assert.Equal(t, "Recording: stopped, stats saved to /tmp/stats.tar", stopMessage("/tmp/stats.tar", nil))
+	assert.Equal(t, "Recording: stopped, write failed", stopMessage("/tmp/stats.tar", fmt.Errorf("write failed")))
+}
+
+func Test_recording_inactive(t *testing.T) {
+	var r recording
+
+	// Nothing is shown and shared when recording is not running.
+	r.share(stat.Stat{})
+	r.stop()
+	assert.Equal(t, "", r.indicator())
+	assert.Equal(t, "", r.filename())
+
+	app := &app{config: newConfig()}
+	assert.Equal(t, "", app.annotationFile())
+
+	app.config.annotateFile = "/tmp/stats.tar"
+	assert.Equal(t, "/tmp/stats.tar", app.annotationFile())
+}
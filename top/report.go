@@ -125,6 +125,20 @@ func getQueryReport(answer string, version int, pgssSchema string, db *postgres.
 	return r, ""
 }
 
+// showQueryReport gets report about query with specified queryid and prints it in $PAGER program.
+func showQueryReport(g *gocui.Gui, app *app, queryid string) string {
+	r, message := getQueryReport(queryid, app.postgresProps.VersionNum, app.postgresProps.ExtPGSSSchema, app.db)
+	if message != "" {
+		return message
+	}
+
+	if app.config.redact {
+		r.Query = query.Normalize(r.Query)
+	}
+
+	return printQueryReport(g, r, app.uiExit)
+}
+
 // printQueryReport prints report in $PAGER program.
 func printQueryReport(g *gocui.Gui, r report, uiExit chan int) string {
 	t, err := template.New("query").Parse(reportTemplate)
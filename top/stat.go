@@ -167,96 +167,126 @@ func printStat(app *app, s stat.Stat, props stat.PostgresProperties) {
 	}
 
 	app.ui.Update(func(g *gocui.Gui) error {
-		v, err := g.View("sysstat")
-		if err != nil {
-			return fmt.Errorf("set focus on sysstat view failed: %w", err)
-		}
-		v.Clear()
-		err = printSysstat(v, s, app.config.verbose, app.db.Local, props.DataDirectory)
-		if err != nil {
-			return fmt.Errorf("print sysstat failed: %w", err)
+		// Keep stats for rewinding. Stats are collected but not printed while the display is paused.
+		app.config.history.add(app.config.view.Name, s, time.Now())
+		if app.config.history.paused {
+			return nil
 		}
 
-		v, err = g.View("pgstat")
-		if err != nil {
-			return fmt.Errorf("set focus on pgstat view failed: %w", err)
-		}
-		v.Clear()
-		err = printPgstat(v, s, props, app.db, app.config.verbose)
-		if err != nil {
-			return fmt.Errorf("print summary postgres stat failed: %w", err)
-		}
+		return drawStat(g, app, s, props, true)
+	})
+}
 
-		v, err = g.View("dbstat")
-		if err != nil {
-			return fmt.Errorf("set focus on dbstat view failed: %w", err)
+// drawStat prints stats in all views. Live stats are followed by the row cursor, stats from history are only printed.
+func drawStat(g *gocui.Gui, app *app, s stat.Stat, props stat.PostgresProperties, live bool) error {
+	v, err := g.View("sysstat")
+	if err != nil {
+		return fmt.Errorf("set focus on sysstat view failed: %w", err)
+	}
+	v.Clear()
+	err = printSysstat(v, app.config.theme, s, app.config.verbose, app.db.Local, props.DataDirectory, app.recording.indicator()+app.config.history.indicator())
+	if err != nil {
+		return fmt.Errorf("print sysstat failed: %w", err)
+	}
+
+	v, err = g.View("pgstat")
+	if err != nil {
+		return fmt.Errorf("set focus on pgstat view failed: %w", err)
+	}
+	v.Clear()
+	err = printPgstat(v, app.config.theme, s, props, app.db, app.config.verbose)
+	if err != nil {
+		return fmt.Errorf("print summary postgres stat failed: %w", err)
+	}
+
+	v, err = g.View("dbstat")
+	if err != nil {
+		return fmt.Errorf("set focus on dbstat view failed: %w", err)
+	}
+	v.Clear()
+
+	// Remember stats for redrawing and follow the row selected by the row cursor.
+	app.config.last = s
+	if s.Error == nil {
+		app.config.cursor.sync(app.config.view, s.Result)
+		if live {
+			app.config.cursor.record(app.config.view, time.Now())
 		}
-		v.Clear()
+	}
+
+	err = printDbstat(v, app.config, s)
+	if err != nil {
+		return fmt.Errorf("print main postgres stat failed: %w", err)
+	}
 
-		err = printDbstat(v, app.config, s)
+	// Apply settings of the view from config file when its columns become known.
+	changed, err := applyViewSettings(app.config, s)
+	if err != nil {
+		printCmdline(g, "Config: %s", err)
+	}
+	if changed {
+		app.config.viewCh <- app.config.view
+	}
+
+	if app.config.view.ShowExtra > stat.CollectNone {
+		v, err := g.View("extra")
 		if err != nil {
-			return fmt.Errorf("print main postgres stat failed: %w", err)
+			return fmt.Errorf("set focus on extra view failed: %w", err)
 		}
 
-		if app.config.view.ShowExtra > stat.CollectNone {
-			v, err := g.View("extra")
+		switch app.config.view.ShowExtra {
+		case stat.CollectDiskstats:
+			v.Clear()
+			err := printIostat(app.config.theme.writer(v), s.Diskstats, app.config.thresholds)
+			if err != nil {
+				return err
+			}
+		case stat.CollectNetdev:
+			v.Clear()
+			err := printNetdev(app.config.theme.writer(v), s.Netdevs, app.config.thresholds)
+			if err != nil {
+				return err
+			}
+		case stat.CollectFsstats:
+			v.Clear()
+			err := printFsstats(app.config.theme.writer(v), s.Fsstats, app.config.thresholds)
+			if err != nil {
+				return err
+			}
+		case stat.CollectLogtail:
+			size, buf, err := readLogfileRecent(v, app.config.logtail)
 			if err != nil {
-				return fmt.Errorf("set focus on extra view failed: %w", err)
+				printCmdline(g, "Tail Postgres log failed: %s", err)
+				return err
 			}
 
-			switch app.config.view.ShowExtra {
-			case stat.CollectDiskstats:
-				v.Clear()
-				err := printIostat(v, s.Diskstats)
-				if err != nil {
-					return err
-				}
-			case stat.CollectNetdev:
-				v.Clear()
-				err := printNetdev(v, s.Netdevs)
-				if err != nil {
-					return err
-				}
-			case stat.CollectFsstats:
+			if size < app.config.logtail.Size {
 				v.Clear()
-				err := printFsstats(v, s.Fsstats)
-				if err != nil {
-					return err
-				}
-			case stat.CollectLogtail:
-				size, buf, err := readLogfileRecent(v, app.config.logtail)
+				err := app.config.logtail.Reopen(app.db, app.postgresProps.VersionNum)
 				if err != nil {
 					printCmdline(g, "Tail Postgres log failed: %s", err)
 					return err
 				}
+			}
 
-				if size < app.config.logtail.Size {
-					v.Clear()
-					err := app.config.logtail.Reopen(app.db, app.postgresProps.VersionNum)
-					if err != nil {
-						printCmdline(g, "Tail Postgres log failed: %s", err)
-						return err
-					}
-				}
-
-				// Update info about logfile size.
-				app.config.logtail.Size = size
+			// Update info about logfile size.
+			app.config.logtail.Size = size
 
-				err = printLogtail(v, app.config.logtail.Path, buf)
-				if err != nil {
-					return err
-				}
+			err = printLogtail(v, app.config.theme, app.config.logtail.Path, buf)
+			if err != nil {
+				return err
 			}
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 // printSysstat prints system stats on UI. It is a thin wrapper that delegates to the
 // writer-based renderSysstat (*gocui.View implements io.Writer), so the render core can be
-// unit-tested without a live terminal — mirroring the printDbstat → renderDbstat precedent.
-func printSysstat(v *gocui.View, s stat.Stat, verbose bool, local bool, dataDir string) error {
-	return renderSysstat(v, s, verbose, local, dataDir)
+// unit-tested without a live terminal — mirroring the printDbstat → renderDbstat precedent. Colors of the theme
+// are applied when printing to the view.
+func printSysstat(v *gocui.View, t theme, s stat.Stat, verbose bool, local bool, dataDir string, recording string) error {
+	return renderSysstat(t.writer(v), s, verbose, local, dataDir, recording)
 }
 
 // renderSysstat is the writer-based core of printSysstat: it prints the system stats to w.
@@ -264,14 +294,14 @@ func printSysstat(v *gocui.View, s stat.Stat, verbose bool, local bool, dataDir
 // full B/N/F side panels: the iostat/nicstat rows select the max-%util device reusing the struct
 // math already computed by count*Usage (never recomputed), and filesyst shows the data_directory's
 // filesystem. local/dataDir drive the filesyst mount-prefix match (data_directory symlinks are
-// resolved only when local).
-func renderSysstat(w io.Writer, s stat.Stat, verbose bool, local bool, dataDir string) error {
+// resolved only when local). The recording indicator is appended to the first line.
+func renderSysstat(w io.Writer, s stat.Stat, verbose bool, local bool, dataDir string, recording string) error {
 	var err error
 
 	/* line1: current time and load average */
-	_, err = fmt.Fprintf(w, "pgcenter: %s, load average: %.2f, %.2f, %.2f\n",
+	_, err = fmt.Fprintf(w, "pgcenter: %s, load average: %.2f, %.2f, %.2f%s\n",
 		time.Now().Format("2006-01-02 15:04:05"),
-		s.LoadAvg.One, s.LoadAvg.Five, s.LoadAvg.Fifteen)
+		s.LoadAvg.One, s.LoadAvg.Five, s.LoadAvg.Fifteen, recording)
 	if err != nil {
 		return err
 	}
@@ -452,9 +482,10 @@ func truncate(s string, n int) string {
 
 // printPgstat prints summary Postgres stats on UI. It is a thin wrapper that delegates to the
 // writer-based renderPgstat (*gocui.View implements io.Writer), so the render core can be
-// unit-tested without a live terminal — mirroring the printDbstat → renderDbstat precedent.
-func printPgstat(v *gocui.View, s stat.Stat, props stat.PostgresProperties, db *postgres.DB, verbose bool) error {
-	return renderPgstat(v, s, props, db, verbose)
+// unit-tested without a live terminal — mirroring the printDbstat → renderDbstat precedent. Colors of the theme
+// are applied when printing to the view.
+func printPgstat(v *gocui.View, t theme, s stat.Stat, props stat.PostgresProperties, db *postgres.DB, verbose bool) error {
+	return renderPgstat(t.writer(v), s, props, db, verbose)
 }
 
 // renderPgstat is the writer-based core of printPgstat: it prints the summary Postgres stats to w.
@@ -659,9 +690,13 @@ func printDbstat(v *gocui.View, config *config, s stat.Stat) error {
 
 	// Terminal width drives the visible-column window. dbstat is created with
 	// Frame=false, so Size() returns the true drawing width.
-	termWidth, _ := v.Size()
+	termWidth, height := v.Size()
 
-	return renderDbstat(v, config, s, termWidth)
+	// Rows scrolled by mouse wheel or by moving the row cursor, one line is taken by header.
+	nrows := len(filterRows(s.Result, config.view.Filters, isFilterRequired(config.view.Filters)))
+	clampRowOffset(config, nrows, height-1)
+
+	return renderDbstat(config.theme.writer(v), config, s, termWidth)
 }
 
 // renderDbstat is the writer-based core of printDbstat: it clamps the scroll offset,
@@ -673,7 +708,20 @@ func renderDbstat(w io.Writer, config *config, s stat.Stat, termWidth int) error
 	// the single source of truth for the render: it avoids re-running visibleColumns three
 	// times per frame (which risked the header and data disagreeing on the window) and it
 	// guarantees both rows reserve the SAME space for the edge markers (alignment invariant).
-	win := visibleColumns(s.Result.Ncols, config.view.ColsWidth, termWidth, config.scrollOffset)
+	//
+	// The window is computed over positions of printed columns: columns hidden by user are skipped and the rest
+	// follow in the order chosen by user (see columnLayout). Widths are passed by position accordingly.
+	cols := displayColumns(config, s.Result.Cols)
+	widths := make(map[int]int, len(cols))
+	for pos, idx := range cols {
+		widths[pos] = config.view.ColsWidth[idx]
+	}
+
+	win := visibleColumns(len(cols), widths, termWidth, config.scrollOffset)
+	win.cols = cols
+
+	// Remember the window, it is used for finding the column under mouse pointer.
+	config.window = win
 
 	// Re-clamp the scroll offset on every render and write it back into config. config
 	// is shared by pointer, so this persists across renders — the fix for runaway offset:
@@ -722,9 +770,21 @@ const markerWidth = 1
 // corresponding side; they double as "print this side's marker" flags. The header prints the
 // marker rune; the data rows print markerWidth spaces on the same side, so both rows keep the
 // same visible width and the columns stay aligned beneath their names.
+//
+// first, last and clamped are positions of printed columns; cols maps positions to absolute column indexes when
+// columns are reordered or hidden by user (nil means positions are the absolute indexes).
 type columnWindow struct {
 	first, last, clamped    int
 	hiddenLeft, hiddenRight bool
+	cols                    []int
+}
+
+// column returns absolute index of the column printed at the specified position.
+func (win columnWindow) column(pos int) int {
+	if win.cols == nil {
+		return pos
+	}
+	return win.cols[pos]
 }
 
 // visibleColumns computes the visible window of scrollable columns for horizontal
@@ -858,7 +918,7 @@ func visibleColumns(ncols int, colsWidth map[int]int, termWidth, offset int) col
 // budget so the header stays aligned with the data rows.
 func printStatHeader(w io.Writer, s stat.Stat, config *config, win columnWindow) error {
 	// Frozen column 0 is always printed first, independent of offset.
-	if err := printHeaderCell(w, s, config, 0); err != nil {
+	if err := printHeaderCell(w, s, config, win.column(0), true); err != nil {
 		return err
 	}
 
@@ -870,8 +930,8 @@ func printStatHeader(w io.Writer, s stat.Stat, config *config, win columnWindow)
 	}
 
 	// Scrollable columns inside the visible window.
-	for i := win.first; i <= win.last; i++ {
-		if err := printHeaderCell(w, s, config, i); err != nil {
+	for pos := win.first; pos <= win.last; pos++ {
+		if err := printHeaderCell(w, s, config, win.column(pos), false); err != nil {
 			return err
 		}
 	}
@@ -892,9 +952,9 @@ func printStatHeader(w io.Writer, s stat.Stat, config *config, win columnWindow)
 
 // printHeaderCell prints a single header cell for column i, applying the filter prefix,
 // the ordered-column highlight, and the frozen-column bold. The sort highlight has
-// priority over frozen-bold on column 0 (Decision 4): when column 0 is the ordered
-// column, only the sort highlight is applied.
-func printHeaderCell(w io.Writer, s stat.Stat, config *config, i int) error {
+// priority over frozen-bold on the frozen column (Decision 4): when the frozen column is
+// the ordered column, only the sort highlight is applied.
+func printHeaderCell(w io.Writer, s stat.Stat, config *config, i int, frozen bool) error {
 	name := s.Result.Cols[i]
 
 	// mark filtered column
@@ -910,7 +970,7 @@ func printHeaderCell(w io.Writer, s stat.Stat, config *config, i int) error {
 		// ordered column highlight (also wins over frozen-bold on column 0, Decision 4)
 		_, err := fmt.Fprintf(w, "\033[%d;%dm%-*s\033[0m", 47, 1, width, pname)
 		return err
-	case i == 0:
+	case frozen:
 		// frozen column name in bold (when not the ordered column)
 		_, err := fmt.Fprintf(w, "\033[%d;%d;%dm%-*s\033[0m", 30, 47, 1, width, pname)
 		return err
@@ -939,30 +999,22 @@ func printStatData(w io.Writer, s stat.Stat, config *config, filter bool, win co
 		rightMarker = strings.Repeat(" ", markerWidth)
 	}
 
-	var doPrint bool
-	for rownum := 0; rownum < s.Result.Nrows; rownum++ {
-		// be optimistic, we want to print the row.
-		doPrint = true
-
-		// apply filters using regexp
-		if filter {
-			for i := 0; i < s.Result.Ncols; i++ {
-				if config.view.Filters[i] != nil {
-					if config.view.Filters[i].MatchString(s.Result.Values[rownum][i].String) {
-						doPrint = true
-						break
-					}
-					doPrint = false
-				}
-			}
+	for n, rownum := range filterRows(s.Result, config.view.Filters, filter) {
+		// Skip rows scrolled out of the screen.
+		if n < config.rowOffset {
+			continue
 		}
 
-		if !doPrint {
-			continue
+		// Highlight the row selected by the row cursor.
+		selected := config.cursor.active && n == config.cursor.row
+		if selected {
+			if _, err := fmt.Fprint(w, "\033[7m"); err != nil {
+				return err
+			}
 		}
 
 		// print frozen column 0 value first, then the windowed columns.
-		if err := printDataCell(w, s, config, rownum, 0); err != nil {
+		if err := printDataCell(w, s, config, rownum, win.column(0), selected); err != nil {
 			return err
 		}
 
@@ -973,8 +1025,8 @@ func printStatData(w io.Writer, s stat.Stat, config *config, filter bool, win co
 			}
 		}
 
-		for i := win.first; i <= win.last; i++ {
-			if err := printDataCell(w, s, config, rownum, i); err != nil {
+		for pos := win.first; pos <= win.last; pos++ {
+			if err := printDataCell(w, s, config, rownum, win.column(pos), selected); err != nil {
 				return err
 			}
 		}
@@ -986,6 +1038,12 @@ func printStatData(w io.Writer, s stat.Stat, config *config, filter bool, win co
 			}
 		}
 
+		if selected {
+			if _, err := fmt.Fprint(w, "\033[0m"); err != nil {
+				return err
+			}
+		}
+
 		if _, err := fmt.Fprintf(w, "\n"); err != nil {
 			return err
 		}
@@ -994,31 +1052,74 @@ func printStatData(w io.Writer, s stat.Stat, config *config, filter bool, win co
 	return nil
 }
 
+// filterRows returns numbers of rows which should be printed: all rows, or rows matched by at least one
+// of the filters when filtering is required.
+func filterRows(r stat.PGresult, filters map[int]*regexp.Regexp, filter bool) []int {
+	rows := make([]int, 0, r.Nrows)
+	for rownum := 0; rownum < r.Nrows; rownum++ {
+		// be optimistic, we want to print the row.
+		doPrint := true
+
+		// apply filters using regexp
+		if filter {
+			for i := 0; i < r.Ncols; i++ {
+				if filters[i] != nil {
+					if filters[i].MatchString(r.Values[rownum][i].String) {
+						doPrint = true
+						break
+					}
+					doPrint = false
+				}
+			}
+		}
+
+		if doPrint {
+			rows = append(rows, rownum)
+		}
+	}
+
+	return rows
+}
+
 // printDataCell prints the value of column i for the given row, truncating values longer
 // than the column width (replacing the last character with '~') and padding to the column
-// width plus the +2 gap. Returns an error for a zero or negative column width.
-func printDataCell(w io.Writer, s stat.Stat, config *config, rownum, i int) error {
+// width plus the +2 gap. Returns an error for a zero or negative column width. The value in the result is
+// not modified, full values are kept for the row details. Values which exceed thresholds of the column are
+// colored, the full value is checked. Highlighting of the selected row is restored after the colored value.
+func printDataCell(w io.Writer, s stat.Stat, config *config, rownum, i int, selected bool) error {
+	value := s.Result.Values[rownum][i].String
+	color := config.thresholds.cellColor(config.view.Name, s.Result, rownum, i, config.blockSize)
+
 	// truncate values that are longer than column width
-	valuelen := len(s.Result.Values[rownum][i].String)
-	if valuelen > config.view.ColsWidth[i] {
+	if len(value) > config.view.ColsWidth[i] {
 		width := config.view.ColsWidth[i]
 		if width <= 0 {
 			return fmt.Errorf("zero or negative width, skip")
 		}
 
 		// truncate value up to column width and replace last character with '~' symbol
-		s.Result.Values[rownum][i].String = s.Result.Values[rownum][i].String[:width-1] + "~"
+		value = value[:width-1] + "~"
+	}
+
+	cell := paint(fmt.Sprintf("%-*s", config.view.ColsWidth[i]+2, value), color)
+	if color != "" && selected {
+		cell = strings.Replace(cell, "\033[0m", "\033[0;7m", 1)
 	}
 
 	// print value
-	_, err := fmt.Fprintf(w, "%-*s", config.view.ColsWidth[i]+2, s.Result.Values[rownum][i].String)
+	_, err := fmt.Fprint(w, cell)
 	return err
 }
 
+// iostatColumns defines names of 'iostat' columns, used for checking thresholds.
+var iostatColumns = []string{
+	"rrqm/s", "wrqm/s", "r/s", "w/s", "rMB/s", "wMB/s", "avgrq-sz", "avgqu-sz", "await", "r_await", "w_await", "%util",
+}
+
 // printIostat prints extra 'iostat' - block IO devices stats.
-func printIostat(v *gocui.View, s stat.Diskstats) error {
+func printIostat(w io.Writer, s stat.Diskstats, th thresholds) error {
 	// print header
-	_, err := fmt.Fprintf(v, "\033[30;47m             Device:     rrqm/s     wrqm/s        r/s        w/s      rMB/s      wMB/s   avgrq-sz   avgqu-sz      await    r_await    w_await      %%util\033[0m\n")
+	_, err := fmt.Fprintf(w, "\033[30;47m             Device:     rrqm/s     wrqm/s        r/s        w/s      rMB/s      wMB/s   avgrq-sz   avgqu-sz      await    r_await    w_await      %%util\033[0m\n")
 	if err != nil {
 		return err
 	}
@@ -1029,13 +1130,18 @@ func printIostat(v *gocui.View, s stat.Diskstats) error {
 			continue
 		}
 
-		// print stats
-		_, err := fmt.Fprintf(v, "%20s\t%10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f\n",
-			s[i].Device,
+		// print stats, values which exceed thresholds are colored
+		values := []float64{
 			s[i].Rmerged, s[i].Wmerged, s[i].Rcompleted, s[i].Wcompleted,
 			s[i].Rsectors, s[i].Wsectors, s[i].Arqsz, s[i].Tweighted,
 			s[i].Await, s[i].Rawait, s[i].Wawait, s[i].Util,
-		)
+		}
+		cells := make([]string, len(values))
+		for j, v := range values {
+			cells[j] = paintValue(th, extraIostat, iostatColumns[j], fmt.Sprintf("%10.2f", v))
+		}
+
+		_, err := fmt.Fprintf(w, "%20s\t%s\n", s[i].Device, strings.Join(cells, " "))
 		if err != nil {
 			return err
 		}
@@ -1043,10 +1149,15 @@ func printIostat(v *gocui.View, s stat.Diskstats) error {
 	return nil
 }
 
+// nicstatColumns defines names of 'nicstat' columns, used for checking thresholds.
+var nicstatColumns = []string{
+	"rMbps", "wMbps", "rPk/s", "wPk/s", "rAvs", "wAvs", "IErr", "OErr", "Coll", "Sat", "%rUtil", "%wUtil", "%Util",
+}
+
 // printNetdev prints 'nicstat' stats - network interfaces stats.
-func printNetdev(v *gocui.View, s stat.Netdevs) error {
+func printNetdev(w io.Writer, s stat.Netdevs, th thresholds) error {
 	// print header
-	_, err := fmt.Fprintf(v, "\033[30;47m          Interface:   rMbps   wMbps    rPk/s    wPk/s     rAvs     wAvs     IErr     OErr     Coll      Sat   %%rUtil   %%wUtil    %%Util\033[0m\n")
+	_, err := fmt.Fprintf(w, "\033[30;47m          Interface:   rMbps   wMbps    rPk/s    wPk/s     rAvs     wAvs     IErr     OErr     Coll      Sat   %%rUtil   %%wUtil    %%Util\033[0m\n")
 	if err != nil {
 		return err
 	}
@@ -1057,14 +1168,23 @@ func printNetdev(v *gocui.View, s stat.Netdevs) error {
 			continue
 		}
 
-		// print stats
-		_, err := fmt.Fprintf(v, "%20s%8.2f%8.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f%9.2f\n",
-			s[i].Ifname,
-			s[i].Rbytes/1024/128, s[i].Tbytes/1024/128, // conversion to Mbps
+		// print stats, values which exceed thresholds are colored
+		values := []float64{
+			s[i].Rbytes / 1024 / 128, s[i].Tbytes / 1024 / 128, // conversion to Mbps
 			s[i].Rpackets, s[i].Tpackets, s[i].Raverage, s[i].Taverage,
 			s[i].Rerrs, s[i].Terrs, s[i].Tcolls,
 			s[i].Saturation, s[i].Rutil, s[i].Tutil, s[i].Utilization,
-		)
+		}
+		var b strings.Builder
+		for j, v := range values {
+			width := 9
+			if j < 2 {
+				width = 8
+			}
+			b.WriteString(paintValue(th, extraNicstat, nicstatColumns[j], fmt.Sprintf("%*.2f", width, v)))
+		}
+
+		_, err := fmt.Fprintf(w, "%20s%s\n", s[i].Ifname, b.String())
 		if err != nil {
 			return err
 		}
@@ -1073,19 +1193,21 @@ func printNetdev(v *gocui.View, s stat.Netdevs) error {
 }
 
 // printFsstats prints stats similar to 'df -h', 'df -i' - mounted filesystems stats.
-func printFsstats(v *gocui.View, s stat.Fsstats) error {
+func printFsstats(w io.Writer, s stat.Fsstats, th thresholds) error {
 	// print header
-	_, err := fmt.Fprintf(v, "\033[30;47m             Filesystem:       size       used      avail   reserved     use%%      inodes       iused       ifree    iuse%%   fstype  mounted on\033[0m\n")
+	_, err := fmt.Fprintf(w, "\033[30;47m             Filesystem:       size       used      avail   reserved     use%%      inodes       iused       ifree    iuse%%   fstype  mounted on\033[0m\n")
 	if err != nil {
 		return err
 	}
 
 	for i := 0; i < len(s); i++ {
-		// print stats
-		_, err := fmt.Fprintf(v, "%24s%11s%11s%11s%11s%8.0f%%%12.0f%12.0f%12.0f%8.0f%%%9s  %-24s\n",
+		// print stats, usage which exceeds thresholds is colored
+		_, err := fmt.Fprintf(w, "%24s%11s%11s%11s%11s%s%12.0f%12.0f%12.0f%s%9s  %-24s\n",
 			s[i].Mount.Device,
-			pretty.Size(s[i].Size), pretty.Size(s[i].Used), pretty.Size(s[i].Avail), pretty.Size(s[i].Reserved), s[i].Pused,
-			s[i].Files, s[i].Filesused, s[i].Filesfree, s[i].Filespused,
+			pretty.Size(s[i].Size), pretty.Size(s[i].Used), pretty.Size(s[i].Avail), pretty.Size(s[i].Reserved),
+			paintValue(th, extraFsstat, "use%", fmt.Sprintf("%8.0f%%", s[i].Pused)),
+			s[i].Files, s[i].Filesused, s[i].Filesfree,
+			paintValue(th, extraFsstat, "iuse%", fmt.Sprintf("%8.0f%%", s[i].Filespused)),
 			s[i].Mount.Fstype, s[i].Mount.Mountpoint,
 		)
 		if err != nil {
@@ -1095,6 +1217,11 @@ func printFsstats(v *gocui.View, s stat.Fsstats) error {
 	return nil
 }
 
+// paintValue colors the formatted value of the column of the extra stats panel if it exceeds thresholds.
+func paintValue(th thresholds, panel string, column string, value string) string {
+	return paint(value, th.color(panel, column, value))
+}
+
 // readLogfileRecent reads necessary number of recent lines in logfile and return them.
 func readLogfileRecent(v *gocui.View, logfile stat.Logfile) (int64, []byte, error) {
 	// Calculate necessary number of lines and buffer size depending on size available screen.
@@ -1123,12 +1250,12 @@ func readLogfileRecent(v *gocui.View, logfile stat.Logfile) (int64, []byte, erro
 }
 
 // printLogtail prints 'logtail' - last lines of Postgres log.
-func printLogtail(v *gocui.View, path string, buf []byte) error {
+func printLogtail(v *gocui.View, t theme, path string, buf []byte) error {
 	if len(string(buf)) > 0 {
 		// clear view's content and read the log
 		v.Clear()
 
-		_, err := fmt.Fprintf(v, "\033[30;47m%s:\033[0m\n", path)
+		_, err := fmt.Fprintf(t.writer(v), "\033[30;47m%s:\033[0m\n", path)
 		if err != nil {
 			return err
 		}
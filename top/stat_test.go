@@ -56,7 +56,7 @@ func Test_renderSysstat_compact(t *testing.T) {
 	}}
 
 	var buf bytes.Buffer
-	err := renderSysstat(&buf, s, false, true, "")
+	err := renderSysstat(&buf, s, false, true, "", "")
 	assert.NoError(t, err)
 
 	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
@@ -78,6 +78,14 @@ func Test_renderSysstat_compact(t *testing.T) {
 	}
 }
 
+func Test_renderSysstat_recording(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, renderSysstat(&buf, stat.Stat{}, false, true, "", ", recording"))
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.Regexp(t, `^pgcenter: .*, load average: 0\.00, 0\.00, 0\.00, recording$`, lines[0])
+}
+
 // Test_renderPgstat_compact is the writer-based golden test for the summary Postgres-stats
 // panel. renderPgstat is the io.Writer core extracted from printPgstat (task 03 refactor);
 // its compact output must stay byte-identical. Line 1 is formatInfoString output; lines 2..4
@@ -147,7 +155,7 @@ func Test_formatError(t *testing.T) {
 func verboseSysstatLines(t *testing.T, s stat.Stat, local bool, dataDir string) []string {
 	t.Helper()
 	var buf bytes.Buffer
-	assert.NoError(t, renderSysstat(&buf, s, true, local, dataDir))
+	assert.NoError(t, renderSysstat(&buf, s, true, local, dataDir, ""))
 	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
 }
 
@@ -281,8 +289,8 @@ func Test_renderSysstat_compactUnchanged(t *testing.T) {
 	}}
 
 	var compact, verbose bytes.Buffer
-	assert.NoError(t, renderSysstat(&compact, s, false, true, "/"))
-	assert.NoError(t, renderSysstat(&verbose, s, true, true, "/"))
+	assert.NoError(t, renderSysstat(&compact, s, false, true, "/", ""))
+	assert.NoError(t, renderSysstat(&verbose, s, true, true, "/", ""))
 
 	compactLines := strings.Split(strings.TrimRight(compact.String(), "\n"), "\n")
 	verboseLines := strings.Split(strings.TrimRight(verbose.String(), "\n"), "\n")
@@ -1091,3 +1099,33 @@ func Test_firstTickCollectingHint(t *testing.T) {
 	assert.True(t, show, "hint must reappear on a re-armed first tick (OFF->ON re-enable)")
 	assert.Equal(t, "collecting...", msg)
 }
+
+func Test_printStatData_cursor(t *testing.T) {
+	cfg := makeRenderConfig(3, 5)
+	s := makeRenderResult(3, 3)
+	s.Result.Values[1][2] = sql.NullString{String: "abcdefghij", Valid: true}
+	win := visibleColumns(s.Result.Ncols, cfg.view.ColsWidth, 40, cfg.scrollOffset)
+
+	// Hidden cursor doesn't highlight rows.
+	var buf bytes.Buffer
+	assert.NoError(t, printStatData(&buf, s, cfg, false, win))
+	assert.NotContains(t, buf.String(), "\033[7m")
+
+	// Selected row is highlighted, values in the result are not truncated.
+	cfg.cursor = rowCursor{active: true, row: 1}
+	buf.Reset()
+	assert.NoError(t, printStatData(&buf, s, cfg, false, win))
+	lines := strings.Split(buf.String(), "\n")
+	assert.True(t, strings.HasPrefix(lines[1], "\033[7mr1-c0"))
+	assert.True(t, strings.HasSuffix(lines[1], "\033[0m"))
+	assert.Contains(t, lines[1], "abcd~")
+	assert.Equal(t, "abcdefghij", s.Result.Values[1][2].String)
+}
+
+func Test_filterRows(t *testing.T) {
+	s := makeRenderResult(3, 4)
+	assert.Equal(t, []int{0, 1, 2, 3}, filterRows(s.Result, map[int]*regexp.Regexp{}, false))
+
+	filters := map[int]*regexp.Regexp{0: regexp.MustCompile("r[13]")}
+	assert.Equal(t, []int{1, 3}, filterRows(s.Result, filters, true))
+}
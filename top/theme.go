@@ -0,0 +1,131 @@
+package top
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansiRE defines escape sequences used for coloring text in UI, parameters of the sequence are captured.
+var ansiRE = regexp.MustCompile("\033\\[([0-9;]*)m")
+
+const (
+	themeDefault    = "default"
+	themeLight      = "light"
+	themeMonochrome = "monochrome"
+)
+
+// theme defines colors of UI. Text is colored using escape sequences of the default theme, other themes replace
+// parameters of these sequences when text is printed on the screen.
+type theme struct {
+	name   string
+	colors map[string]string // Replacements of parameters of the default theme's sequences.
+	mono   bool              // Remove colors which have no replacement, keep text attributes only.
+}
+
+// themes defines themes available in UI.
+var themes = map[string]theme{
+	themeDefault: {name: themeDefault},
+	themeLight: {
+		name: themeLight,
+		colors: map[string]string{
+			"37;1":    "30;1",    // summary values
+			"30;47":   "37;44",   // header, selected menu item
+			"30;47;1": "37;44;1", // frozen column in header
+			"47;1":    "30;46;1", // ordered column in header
+			"33;1":    "30;43",   // warnings, pause indicator
+		},
+	},
+	themeMonochrome: {
+		name: themeMonochrome,
+		colors: map[string]string{
+			"30;47":   "7",
+			"30;47;1": "7;1",
+			"47;1":    "7;1;4",
+			"31;1":    "1;4", // critical values, recording indicator
+		},
+		mono: true,
+	},
+}
+
+// themeNames returns names of available themes.
+func themeNames() string {
+	return strings.Join([]string{themeDefault, themeLight, themeMonochrome}, ", ")
+}
+
+// selectTheme returns theme with the specified name. When name is not specified, monochrome theme is used if
+// NO_COLOR environment variable is set (see https://no-color.org), and default theme otherwise.
+func selectTheme(name string, noColor string) (theme, error) {
+	if name == "" {
+		if noColor != "" {
+			return themes[themeMonochrome], nil
+		}
+		return themes[themeDefault], nil
+	}
+
+	t, ok := themes[name]
+	if !ok {
+		return theme{}, fmt.Errorf("unknown theme '%s', available: %s", name, themeNames())
+	}
+
+	return t, nil
+}
+
+// writer returns writer which prints text using colors of the theme.
+func (t theme) writer(w io.Writer) io.Writer {
+	if t.colors == nil && !t.mono {
+		return w
+	}
+	return themeWriter{w: w, theme: t}
+}
+
+// replace returns escape sequence with parameters replaced according to the theme.
+func (t theme) replace(seq string) string {
+	params := ansiRE.FindStringSubmatch(seq)[1]
+
+	p, ok := t.colors[params]
+	if !ok {
+		p = params
+		if t.mono {
+			p = stripColors(params)
+		}
+	}
+
+	// Sequence consisted of colors only is not needed.
+	if p == "" && params != "" {
+		return ""
+	}
+
+	return "\033[" + p + "m"
+}
+
+// stripColors removes foreground and background colors from parameters of escape sequence.
+func stripColors(params string) string {
+	var kept []string
+	for _, p := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(p)
+		if err == nil && ((n >= 30 && n <= 49) || (n >= 90 && n <= 107)) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	return strings.Join(kept, ";")
+}
+
+// themeWriter replaces escape sequences in printed text according to the theme.
+type themeWriter struct {
+	w     io.Writer
+	theme theme
+}
+
+// Write implements io.Writer. Escape sequences are expected to be written entirely within a single call.
+func (tw themeWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(tw.w, ansiRE.ReplaceAllStringFunc(string(p), tw.theme.replace))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
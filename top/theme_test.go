@@ -0,0 +1,73 @@
+package top
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_selectTheme(t *testing.T) {
+	testcases := []struct {
+		name    string
+		noColor string
+		want    string
+		valid   bool
+	}{
+		{name: "", want: themeDefault, valid: true},
+		{name: "", noColor: "1", want: themeMonochrome, valid: true},
+		{name: "light", noColor: "1", want: themeLight, valid: true}, // config overrides NO_COLOR
+		{name: "monochrome", want: themeMonochrome, valid: true},
+		{name: "dark", valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			got, err := selectTheme(tc.name, tc.noColor)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got.name)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_theme_writer(t *testing.T) {
+	text := "\033[30;47mheader\033[0m \033[37;1mvalue\033[0m \033[31;1mcritical\033[0m \033[32mok\033[0m \033[7mrow\033[0m"
+
+	testcases := []struct {
+		name string
+		want string
+	}{
+		{name: themeDefault, want: text},
+		{
+			name: themeLight,
+			want: "\033[37;44mheader\033[0m \033[30;1mvalue\033[0m \033[31;1mcritical\033[0m \033[32mok\033[0m \033[7mrow\033[0m",
+		},
+		{
+			name: themeMonochrome,
+			want: "\033[7mheader\033[0m \033[1mvalue\033[0m \033[1;4mcritical\033[0m ok\033[0m \033[7mrow\033[0m",
+		},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := fmt.Fprint(themes[tc.name].writer(&buf), text)
+			assert.NoError(t, err)
+			assert.Equal(t, len(text), n)
+			assert.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func Test_renderDbstat_theme(t *testing.T) {
+	cfg := makeRenderConfig(2, 5)
+	cfg.theme = themes[themeMonochrome]
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderDbstat(cfg.theme.writer(&buf), cfg, makeRenderResult(2, 1), 200))
+	assert.Equal(t, "\033[7;1;4mcol0   \033[0m\033[7mcol1   \033[0m\nr0-c0  r0-c1  \n", buf.String())
+}
@@ -0,0 +1,362 @@
+package top
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	warningColor  = "33;1" // Color of values which exceed warning threshold.
+	criticalColor = "31;1" // Color of values which exceed critical threshold.
+)
+
+// Names of extra stats panels, used for defining thresholds of their columns.
+const (
+	extraIostat  = "iostat"
+	extraNicstat = "nicstat"
+	extraFsstat  = "fsstat"
+)
+
+// threshold defines conditions of highlighting values of the column, stored in configuration file. Condition
+// consists of operator and value, e.g. "> 5m", "< 95", "= lost" or "~ ^idle". Empty condition is not checked.
+type threshold struct {
+	Warning  string `toml:"warning,omitempty"`  // Condition of highlighting the value in yellow
+	Critical string `toml:"critical,omitempty"` // Condition of highlighting the value in red
+}
+
+// defaultThresholds defines built-in thresholds: key is the view (or extra stats panel) name, then column name.
+var defaultThresholds = map[string]map[string]threshold{
+	"activity":          {"xact_age": {Warning: "> 1m", Critical: "> 5m"}},
+	"databases_general": {"hit,%": {Warning: "< 95", Critical: "< 90"}},
+	"replslots":         {"wal_status": {Warning: "= unreserved", Critical: "= lost"}},
+	extraIostat:         {"%util": {Warning: "> 60", Critical: "> 80"}},
+	extraNicstat:        {"%Util": {Warning: "> 60", Critical: "> 80"}},
+	extraFsstat: {
+		"use%":  {Warning: "> 80", Critical: "> 90"},
+		"iuse%": {Warning: "> 80", Critical: "> 90"},
+	},
+}
+
+// derivedColumn defines value which is not returned by the view's query, but calculated from other columns of the
+// row. Thresholds could be defined for the derived value, the value of the specified column is highlighted.
+type derivedColumn struct {
+	column string                                                          // Column which value is highlighted
+	value  func(cols []string, row []sql.NullString, blockSize int) string // Calculates the value, empty if not known
+}
+
+// derivedColumns defines derived values: key is the view name, then name of the derived value.
+var derivedColumns = map[string]map[string]derivedColumn{
+	"databases_general": {"hit,%": {column: "hits", value: hitRatio}},
+}
+
+// hitRatio returns percentage of blocks found in buffer cache over the refresh interval, calculated from diffed
+// 'hits' (in blocks) and 'read,KiB' values of databases_general view. Empty string returned if no blocks accessed.
+func hitRatio(cols []string, row []sql.NullString, blockSize int) string {
+	var hits, read float64
+	var found int
+	for i, col := range cols {
+		if i >= len(row) || (col != "hits" && col != "read,KiB") {
+			continue
+		}
+		v, err := strconv.ParseFloat(row[i].String, 64)
+		if err != nil {
+			return ""
+		}
+		if col == "hits" {
+			hits = v
+		} else {
+			read = v * 1024 / float64(blockSize)
+		}
+		found++
+	}
+
+	if found != 2 || blockSize <= 0 || hits+read <= 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(100*hits/(hits+read), 'f', 2, 64)
+}
+
+// thresholds defines parsed thresholds: key is the view (or extra stats panel) name, then column name.
+type thresholds map[string]map[string]columnThreshold
+
+// newThresholds returns built-in thresholds overridden by thresholds specified by user. User's threshold replaces
+// the built-in one of the same column, empty threshold disables it.
+func newThresholds(user map[string]map[string]threshold) (thresholds, error) {
+	merged := map[string]map[string]threshold{}
+	for _, m := range []map[string]map[string]threshold{defaultThresholds, user} {
+		for name, columns := range m {
+			if merged[name] == nil {
+				merged[name] = map[string]threshold{}
+			}
+			for col, th := range columns {
+				merged[name][col] = th
+			}
+		}
+	}
+
+	t := thresholds{}
+	for name, columns := range merged {
+		for col, th := range columns {
+			warning, err := parseCondition(th.Warning)
+			if err != nil {
+				return nil, fmt.Errorf("invalid warning threshold of '%s' column in '%s' view: %w", col, name, err)
+			}
+			critical, err := parseCondition(th.Critical)
+			if err != nil {
+				return nil, fmt.Errorf("invalid critical threshold of '%s' column in '%s' view: %w", col, name, err)
+			}
+
+			if warning == nil && critical == nil {
+				continue
+			}
+			if t[name] == nil {
+				t[name] = map[string]columnThreshold{}
+			}
+			t[name][col] = columnThreshold{warning: warning, critical: critical}
+		}
+	}
+
+	return t, nil
+}
+
+// color returns color of the value of the column in the view, empty string is returned if no thresholds exceeded.
+func (t thresholds) color(view string, column string, value string) string {
+	th, ok := t[view][column]
+	if !ok {
+		return ""
+	}
+
+	if th.critical != nil && th.critical.match(value) {
+		return criticalColor
+	}
+	if th.warning != nil && th.warning.match(value) {
+		return warningColor
+	}
+
+	return ""
+}
+
+// cellColor returns color of the value in the row of stats, thresholds of derived values highlight the column which
+// the derived value refers to.
+func (t thresholds) cellColor(view string, res stat.PGresult, rownum int, i int, blockSize int) string {
+	row := res.Values[rownum]
+	if color := t.color(view, res.Cols[i], row[i].String); color != "" {
+		return color
+	}
+
+	for name, d := range derivedColumns[view] {
+		if d.column != res.Cols[i] {
+			continue
+		}
+		if _, ok := t[view][name]; !ok {
+			continue
+		}
+		if value := d.value(res.Cols, row, blockSize); value != "" {
+			if color := t.color(view, name, value); color != "" {
+				return color
+			}
+		}
+	}
+
+	return ""
+}
+
+// paint colors the value of the cell, padding of the cell is not colored.
+func paint(cell string, color string) string {
+	value := strings.TrimSpace(cell)
+	if color == "" || value == "" {
+		return cell
+	}
+
+	i := strings.Index(cell, value)
+	return cell[:i] + "\033[" + color + "m" + value + "\033[0m" + cell[i+len(value):]
+}
+
+// columnThreshold defines parsed conditions of highlighting values of the column.
+type columnThreshold struct {
+	warning  *condition
+	critical *condition
+}
+
+// condition defines parsed condition of the threshold. Value of the condition is a number, a duration
+// (e.g. 5m or 1h30m), a string or a regular expression (with '~' operator).
+type condition struct {
+	op       string
+	number   float64
+	duration bool
+	text     string
+	re       *regexp.Regexp
+}
+
+// operators defines supported operators of conditions. Two-character operators should go first.
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "~"}
+
+// parseCondition parses condition of threshold. Empty condition is not an error, nil is returned.
+func parseCondition(s string) (*condition, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	c := &condition{}
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			c.op = op
+			break
+		}
+	}
+	if c.op == "" {
+		return nil, fmt.Errorf("'%s': operator should be one of %s", s, strings.Join(operators, " "))
+	}
+
+	value := strings.TrimSpace(strings.TrimPrefix(s, c.op))
+	if value == "" {
+		return nil, fmt.Errorf("'%s': value is not specified", s)
+	}
+
+	if c.op == "~" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", s, err)
+		}
+		c.re = re
+		return c, nil
+	}
+
+	if n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64); err == nil {
+		c.number = n
+		return c, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		c.number, c.duration = float64(d), true
+		return c, nil
+	}
+
+	if c.op != "=" && c.op != "!=" {
+		return nil, fmt.Errorf("'%s': value should be a number or a duration", s)
+	}
+	c.text = value
+
+	return c, nil
+}
+
+// match returns true if the value satisfies the condition. Values which can't be compared, e.g. empty values
+// compared with numbers, never match.
+func (c *condition) match(value string) bool {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case c.re != nil:
+		return c.re.MatchString(value)
+	case c.duration:
+		d, ok := parseInterval(value)
+		return ok && compare(float64(d), c.number, c.op)
+	case c.text != "":
+		return (value == c.text) == (c.op == "=")
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	return err == nil && compare(n, c.number, c.op)
+}
+
+// compare compares two numbers using the operator.
+func compare(a, b float64, op string) bool {
+	switch op {
+	case ">=":
+		return a >= b
+	case "<=":
+		return a <= b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case "<":
+		return a < b
+	case "=":
+		return a == b
+	}
+	return false
+}
+
+// parseInterval parses interval printed by Postgres, e.g. "00:05:12", "2 days 01:02:03.5" or "-00:00:01".
+// Months and years are counted as 30 and 365 days.
+func parseInterval(s string) (time.Duration, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	var d time.Duration
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			t, ok := parseClock(fields[i])
+			if !ok {
+				return 0, false
+			}
+			d += t
+			continue
+		}
+
+		// Number should be followed by unit.
+		if i+1 == len(fields) {
+			return 0, false
+		}
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return 0, false
+		}
+		i++
+
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "day":
+			d += time.Duration(n) * 24 * time.Hour
+		case "mon":
+			d += time.Duration(n) * 30 * 24 * time.Hour
+		case "year":
+			d += time.Duration(n) * 365 * 24 * time.Hour
+		default:
+			return 0, false
+		}
+	}
+
+	return d, true
+}
+
+// parseClock parses time part of interval in [-]hh:mm[:ss[.ffffff]] format.
+func parseClock(s string) (time.Duration, bool) {
+	neg := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimPrefix(s, "-"), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	var sec float64
+	if len(parts) == 3 {
+		sec, err = strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return 0, false
+		}
+	}
+
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second))
+	if neg {
+		d = -d
+	}
+
+	return d, true
+}
@@ -0,0 +1,193 @@
+package top
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_newThresholds(t *testing.T) {
+	// Built-in thresholds are valid.
+	th, err := newThresholds(nil)
+	assert.NoError(t, err)
+	for name, columns := range defaultThresholds {
+		assert.Len(t, th[name], len(columns))
+	}
+
+	// User's thresholds replace and disable built-in ones.
+	th, err = newThresholds(map[string]map[string]threshold{
+		"activity":  {"xact_age": {Critical: "> 1h"}},
+		"replslots": {"wal_status": {}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, columnThreshold{critical: &condition{op: ">", number: float64(time.Hour), duration: true}}, th["activity"]["xact_age"])
+	assert.NotContains(t, th, "replslots")
+	assert.Contains(t, th["databases_general"], "hit,%")
+
+	// Invalid conditions.
+	_, err = newThresholds(map[string]map[string]threshold{"activity": {"xact_age": {Warning: "5m"}}})
+	assert.Error(t, err)
+	_, err = newThresholds(map[string]map[string]threshold{"activity": {"state": {Critical: "~ ("}}})
+	assert.Error(t, err)
+}
+
+func Test_condition(t *testing.T) {
+	testcases := []struct {
+		cond  string
+		value string
+		want  bool
+	}{
+		{cond: "> 5m", value: "00:05:01", want: true},
+		{cond: "> 5m", value: "00:05:00", want: false},
+		{cond: "> 5m", value: "1 day 00:00:00", want: true},
+		{cond: "> 5m", value: "", want: false},
+		{cond: "<= 1h30m", value: "01:30:00", want: true},
+		{cond: "< 95", value: "94.99", want: true},
+		{cond: "< 95", value: "95.00", want: false},
+		{cond: "< 95%", value: "42%", want: true},
+		{cond: "< 95", value: "", want: false},
+		{cond: ">= 80", value: "80", want: true},
+		{cond: "!= 0", value: "1", want: true},
+		{cond: "= 0", value: "0.00", want: true},
+		{cond: "= lost", value: "lost", want: true},
+		{cond: "= lost", value: "reserved", want: false},
+		{cond: "!= streaming", value: "catchup", want: true},
+		{cond: "~ ^idle in", value: "idle in transaction", want: true},
+		{cond: "~ ^idle in", value: "active", want: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			c, err := parseCondition(tc.cond)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, c.match(tc.value))
+		})
+	}
+
+	// Empty and invalid conditions.
+	c, err := parseCondition(" ")
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+	for _, s := range []string{"5m", ">", "> lost", "~ ("} {
+		_, err = parseCondition(s)
+		assert.Error(t, err)
+	}
+}
+
+func Test_parseInterval(t *testing.T) {
+	testcases := []struct {
+		value string
+		want  time.Duration
+		valid bool
+	}{
+		{value: "00:05:12", want: 5*time.Minute + 12*time.Second, valid: true},
+		{value: "-00:00:01", want: -time.Second, valid: true},
+		{value: "00:00:01.5", want: 1500 * time.Millisecond, valid: true},
+		{value: "2 days 01:00:00", want: 49 * time.Hour, valid: true},
+		{value: "1 mon 1 day", want: 31 * 24 * time.Hour, valid: true},
+		{value: "1 year", want: 365 * 24 * time.Hour, valid: true},
+		{value: "", valid: false},
+		{value: "5", valid: false},
+		{value: "5 weeks", valid: false},
+		{value: "aa:00:00", valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			got, ok := parseInterval(tc.value)
+			assert.Equal(t, tc.valid, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_hitRatio(t *testing.T) {
+	cols := []string{"datname", "read,KiB", "hits"}
+	row := func(read, hits string) []sql.NullString {
+		return []sql.NullString{{String: "db", Valid: true}, {String: read, Valid: true}, {String: hits, Valid: true}}
+	}
+
+	assert.Equal(t, "90.00", hitRatio(cols, row("80", "90"), 8192)) // 80 KiB = 10 blocks
+	assert.Equal(t, "100.00", hitRatio(cols, row("0", "90"), 8192))
+	assert.Equal(t, "", hitRatio(cols, row("0", "0"), 8192))
+	assert.Equal(t, "", hitRatio(cols, row("", "90"), 8192))
+	assert.Equal(t, "", hitRatio([]string{"datname", "hits"}, row("80", "90"), 8192))
+}
+
+func Test_thresholds_cellColor(t *testing.T) {
+	th, _ := newThresholds(nil)
+	res := stat.PGresult{
+		Cols: []string{"datname", "read,KiB", "hits"},
+		Values: [][]sql.NullString{
+			{{String: "db1", Valid: true}, {String: "8", Valid: true}, {String: "999", Valid: true}},
+			{{String: "db2", Valid: true}, {String: "48", Valid: true}, {String: "94", Valid: true}},
+			{{String: "db3", Valid: true}, {String: "160", Valid: true}, {String: "80", Valid: true}},
+			{{String: "db4", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, "", th.cellColor("databases_general", res, 0, 2, 8192))
+	assert.Equal(t, warningColor, th.cellColor("databases_general", res, 1, 2, 8192))
+	assert.Equal(t, criticalColor, th.cellColor("databases_general", res, 2, 2, 8192))
+	assert.Equal(t, "", th.cellColor("databases_general", res, 2, 1, 8192)) // only 'hits' is highlighted
+	assert.Equal(t, "", th.cellColor("databases_general", res, 3, 2, 8192))
+
+	// Disabled threshold.
+	th, _ = newThresholds(map[string]map[string]threshold{"databases_general": {"hit,%": {}}})
+	assert.Equal(t, "", th.cellColor("databases_general", res, 2, 2, 8192))
+}
+
+func Test_paint(t *testing.T) {
+	assert.Equal(t, "value  ", paint("value  ", ""))
+	assert.Equal(t, "\033[31;1mvalue\033[0m  ", paint("value  ", criticalColor))
+	assert.Equal(t, "  \033[33;1m42.00\033[0m", paint("  42.00", warningColor))
+	assert.Equal(t, "   ", paint("   ", warningColor))
+}
+
+func Test_renderDbstat_thresholds(t *testing.T) {
+	cfg := makeRenderConfig(2, 8)
+	cfg.view.Name = "activity"
+	cfg.thresholds, _ = newThresholds(nil)
+
+	s := makeRenderResult(2, 3)
+	s.Result.Cols[1] = "xact_age"
+	s.Result.Values[0][1].String = "00:00:10"
+	s.Result.Values[1][1].String = "00:02:00"
+	s.Result.Values[2][1].String = "01:00:00"
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderDbstat(&buf, cfg, s, 200))
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "r0-c0     00:00:10  ", lines[1])
+	assert.Equal(t, "r1-c0     \033[33;1m00:02:00\033[0m  ", lines[2])
+	assert.Equal(t, "r2-c0     \033[31;1m01:00:00\033[0m  ", lines[3])
+
+	// Highlighting of the selected row is restored after the colored value.
+	cfg.cursor.active, cfg.cursor.row = true, 2
+	buf.Reset()
+	assert.NoError(t, renderDbstat(&buf, cfg, s, 200))
+	lines = strings.Split(buf.String(), "\n")
+	assert.Equal(t, "\033[7mr2-c0     \033[31;1m01:00:00\033[0;7m  \033[0m", lines[3])
+}
+
+func Test_printIostat_thresholds(t *testing.T) {
+	th, _ := newThresholds(nil)
+	s := stat.Diskstats{
+		{Device: "sda", Completed: 1, Util: 85},
+		{Device: "sdb", Completed: 1, Util: 10},
+		{Device: "sdc"}, // never did IOs
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printIostat(&buf, s, th))
+	lines := strings.Split(buf.String(), "\n")
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasSuffix(lines[1], "      \033[31;1m85.00\033[0m"))
+	assert.Equal(t, len(lines[2]), len(ansiRE.ReplaceAllString(lines[1], "")))
+	assert.NotContains(t, lines[2], "\033")
+}
@@ -2,14 +2,41 @@ package top
 
 import (
 	"context"
+	"fmt"
 	"github.com/jroimartin/gocui"
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/lesovsky/pgcenter/record"
+	"os"
 )
 
+// Config defines user-defined settings of 'pgcenter top'.
+type Config struct {
+	AnnotateFile string          // Stats file of an active recording where annotations are written
+	AnnotateKey  stat.ArchiveKey // Key of encrypted stats file where annotations are written
+	Redact       bool            // Replace literals in query texts with placeholders
+	ConfigFile   string          // Configuration file with user's settings
+	Mouse        bool            // Enable mouse support
+}
+
 // RunMain is the main entry point for 'pgcenter top' command
-func RunMain(dbConfig postgres.Config) error {
+func RunMain(dbConfig postgres.Config, c Config) error {
+	// Read user's settings.
+	settings, err := readUserConfig(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	// Check annotations could be written into the specified file, error at 'M' press is too late.
+	if c.AnnotateFile != "" {
+		err = record.CheckAnnotationKey(c.AnnotateFile, c.AnnotateKey)
+		if err != nil {
+			return fmt.Errorf("annotate: %w", err)
+		}
+	}
+
 	// Connect to Postgres.
 	db, err := postgres.Connect(dbConfig)
 	if err != nil {
@@ -19,6 +46,17 @@ func RunMain(dbConfig postgres.Config) error {
 
 	// Create application instance.
 	app := newApp(db, newConfig())
+	app.config.annotateFile = c.AnnotateFile
+	app.config.annotateKey = c.AnnotateKey
+	app.config.redact = c.Redact
+	app.config.configFile = c.ConfigFile
+	app.config.mouse = c.Mouse
+	app.config.settings = settings
+
+	app.config.theme, err = selectTheme(settings.Theme, os.Getenv("NO_COLOR"))
+	if err != nil {
+		return err
+	}
 
 	// Setup application.
 	err = app.setup()
@@ -38,6 +76,7 @@ type app struct {
 	uiError       error                   // hold error occurred during executing UI.
 	db            *postgres.DB            // connection to Postgres.
 	postgresProps stat.PostgresProperties // properties of Postgres to which connected to.
+	recording     recording               // background recording of stats.
 }
 
 // newApp creates new application instance.
@@ -58,6 +97,18 @@ func (app *app) setup() error {
 
 	// Create query options needed for formatting necessary queries.
 	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, 256, props.ExtPGSSSchema)
+	app.config.settings.applyOptions(&opts)
+
+	// Add custom views defined by user, views not supported by running Postgres are skipped.
+	custom, err := view.NewCustomViews(app.config.settings.CustomViews)
+	if err != nil {
+		return err
+	}
+	for name, v := range custom {
+		if v.VersionOK(props.VersionNum) {
+			app.config.views[name] = v
+		}
+	}
 
 	// Create and configure stats views adjusting them depending on running Postgres.
 	err = app.config.views.Configure(opts)
@@ -65,9 +116,26 @@ func (app *app) setup() error {
 		return err
 	}
 
-	// Set default view.
+	// Check queries of custom views.
+	err = stat.ConfigureCustomViews(app.db, app.config.views)
+	if err != nil {
+		return err
+	}
+
+	// Set default view and apply user's settings.
 	app.config.view = app.config.views["activity"]
 
+	err = app.config.settings.apply(app.config)
+	if err != nil {
+		return err
+	}
+
+	// Block size is used for calculating cache hit ratio, read blocks are reported in KiB.
+	err = app.db.QueryRow("SELECT current_setting('block_size')::int").Scan(&app.config.blockSize)
+	if err != nil {
+		return err
+	}
+
 	app.config.queryOptions = opts
 	app.postgresProps = props
 	app.uiExit = make(chan int)
@@ -79,6 +147,7 @@ func (app *app) setup() error {
 func (app *app) quit() func(g *gocui.Gui, _ *gocui.View) error {
 	return func(g *gocui.Gui, _ *gocui.View) error {
 		close(app.uiExit)
+		app.recording.stop()
 		g.Close()
 		app.db.Close()
 		return gocui.ErrQuit
@@ -22,6 +22,7 @@ func mainLoop(ctx context.Context, app *app) error {
 		}
 
 		app.ui = g
+		app.ui.Mouse = app.config.mouse
 
 		// Setup UI layout.
 		app.ui.SetManagerFunc(layout(app))
@@ -79,7 +80,7 @@ func doWork(ctx context.Context, app *app) {
 	}()
 
 	// Send default view and default refresh interval to stats collector goroutine.
-	app.config.view.Refresh = time.Second
+	app.config.view.Refresh = app.config.refresh
 	app.config.viewCh <- app.config.view
 
 	// Reset refresh interval, it should not be saved as per-view setting.
@@ -91,6 +92,10 @@ func doWork(ctx context.Context, app *app) {
 			// used for exit from UI (not the program) in case when need to open $PAGER or $EDITOR programs.
 			return
 		case s := <-statCh:
+			app.recording.share(s)
+			if app.config.redact {
+				s.Pgstat.Result = stat.RedactQueries(s.Pgstat.Result)
+			}
 			printStat(app, s, app.postgresProps)
 		case <-ctx.Done():
 			wg.Wait()
@@ -0,0 +1,425 @@
+package top
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/math"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// userConfig defines settings of 'pgcenter top' stored in configuration file. Columns are referenced by names,
+// because their positions depend on Postgres version.
+type userConfig struct {
+	View        string                  `toml:"view,omitempty"`         // View shown at start
+	Refresh     int                     `toml:"refresh,omitempty"`      // Refresh interval, in seconds
+	ShowIdle    *bool                   `toml:"show_idle,omitempty"`    // Show idle connections in activity views
+	QueryAge    string                  `toml:"query_age,omitempty"`    // Show queries and transactions older than this age
+	Verbose     bool                    `toml:"verbose,omitempty"`      // Verbose mode of summary panels
+	Theme       string                  `toml:"theme,omitempty"`        // Colors of UI
+	Views       map[string]viewSettings `toml:"views,omitempty"`        // Per-view settings
+	CustomViews map[string]view.Custom  `toml:"custom_views,omitempty"` // User-defined views, also used by 'record' and 'report'
+	// Thresholds of highlighting values: key is the view (or extra stats panel) name, then column name.
+	Thresholds map[string]map[string]threshold `toml:"thresholds,omitempty"`
+}
+
+// viewSettings defines per-view settings stored in configuration file.
+type viewSettings struct {
+	OrderColumn string            `toml:"order_column,omitempty"` // Name of column used for ordering
+	OrderDesc   *bool             `toml:"order_desc,omitempty"`   // Ordering direction
+	Filters     map[string]string `toml:"filters,omitempty"`      // Filter patterns: key is the column name
+	Widths      map[string]int    `toml:"widths,omitempty"`       // Width of columns: key is the column name
+	Columns     []string          `toml:"columns,omitempty"`      // Order of printed columns
+	Hidden      []string          `toml:"hidden,omitempty"`       // Columns which are not printed
+}
+
+// readUserConfig reads configuration file. Missing file is not an error, empty config is returned in this case.
+func readUserConfig(filename string) (userConfig, error) {
+	var uc userConfig
+
+	if filename == "" {
+		return uc, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uc, nil
+		}
+		return uc, err
+	}
+
+	md, err := toml.Decode(string(data), &uc)
+	if err != nil {
+		return uc, fmt.Errorf("read config %s failed: %w", filename, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return uc, fmt.Errorf("read config %s failed: unknown setting '%s'", filename, undecoded[0])
+	}
+
+	err = uc.validate()
+	if err != nil {
+		return uc, fmt.Errorf("read config %s failed: %w", filename, err)
+	}
+
+	return uc, nil
+}
+
+// validate checks settings which don't depend on connected Postgres.
+func (uc userConfig) validate() error {
+	if uc.Refresh != 0 && (uc.Refresh < 1 || uc.Refresh > 300) {
+		return fmt.Errorf("refresh should be between 1 and 300")
+	}
+
+	if uc.QueryAge != "" {
+		err := parseHumanTimeString(uc.QueryAge)
+		if err != nil {
+			return fmt.Errorf("invalid query_age '%s': %w", uc.QueryAge, err)
+		}
+	}
+
+	for name, s := range uc.Views {
+		for col, pattern := range s.Filters {
+			_, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid filter of '%s' column in '%s' view: %w", col, name, err)
+			}
+		}
+		for col, width := range s.Widths {
+			if width < 1 || width > colsWidthMax {
+				return fmt.Errorf("width of '%s' column in '%s' view should be between 1 and %d", col, name, colsWidthMax)
+			}
+		}
+	}
+
+	if uc.Theme != "" {
+		_, err := selectTheme(uc.Theme, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	custom, err := view.NewCustomViews(uc.CustomViews)
+	if err != nil {
+		return err
+	}
+
+	// Columns depend on Postgres version, only names of views are checked.
+	views := view.New()
+	for name := range uc.Thresholds {
+		_, builtin := views[name]
+		_, ok := custom[name]
+		if !builtin && !ok && name != extraIostat && name != extraNicstat && name != extraFsstat {
+			return fmt.Errorf("unknown view '%s' in thresholds", name)
+		}
+	}
+
+	_, err = newThresholds(uc.Thresholds)
+	return err
+}
+
+// writeUserConfig writes configuration file. The file is replaced atomically, directory is created if necessary.
+// Whole file is rewritten, hence comments and order of settings are not kept. Mode of existing file is kept, new
+// file is readable by owner only. When the file is a symlink, e.g. into dotfiles repository, its target is replaced
+// and the symlink is kept.
+func writeUserConfig(filename string, uc userConfig) error {
+	var buf bytes.Buffer
+	err := toml.NewEncoder(&buf).Encode(uc)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return err
+	}
+
+	// Temporary file is created next to the real file, renaming across filesystems is not possible.
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if st, err := os.Stat(filename); err == nil {
+		mode = st.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	err = tmp.Chmod(mode)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	_, err = tmp.Write(buf.Bytes())
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// applyOptions applies global settings to queries' options. Should be called before formatting queries.
+func (uc userConfig) applyOptions(opts *query.Options) {
+	if uc.ShowIdle != nil {
+		opts.ShowNoIdle = !*uc.ShowIdle
+	}
+	if uc.QueryAge != "" {
+		opts.QueryAgeThresh = uc.QueryAge
+	}
+}
+
+// apply applies global settings and per-view settings which don't depend on columns. Settings which refer
+// columns are applied when columns of the view become known (see applyViewSettings).
+func (uc userConfig) apply(config *config) error {
+	if uc.View != "" {
+		v, ok := config.views[uc.View]
+		if !ok {
+			return fmt.Errorf("unknown view '%s' in config", uc.View)
+		}
+		config.view = v
+	}
+
+	if uc.Refresh > 0 {
+		config.refresh = time.Duration(uc.Refresh) * time.Second
+	}
+
+	if len(uc.Thresholds) > 0 {
+		th, err := newThresholds(uc.Thresholds)
+		if err != nil {
+			return err
+		}
+		config.thresholds = th
+	}
+
+	// Verbose mode is mirrored into every view, as toggleVerbose does.
+	if uc.Verbose {
+		for k, v := range config.views {
+			v.Verbose = true
+			config.views[k] = v
+		}
+		config.view.Verbose = true
+		config.verbose = true
+	}
+
+	for name, s := range uc.Views {
+		v, ok := config.views[name]
+		if !ok {
+			continue
+		}
+
+		if s.OrderDesc != nil {
+			v.OrderDesc = *s.OrderDesc
+			config.views[name] = v
+			if config.view.Name == name {
+				config.view.OrderDesc = v.OrderDesc
+			}
+		}
+
+		if s.OrderColumn != "" || len(s.Filters) > 0 || len(s.Widths) > 0 || len(s.Columns) > 0 || len(s.Hidden) > 0 {
+			config.pending[name] = s
+		}
+	}
+
+	return nil
+}
+
+// applyViewSettings applies settings which refer columns of the current view, when its columns become known.
+// Returns true if order of the view has been changed and collector should be notified.
+func applyViewSettings(config *config, s stat.Stat) (bool, error) {
+	settings, ok := config.pending[config.view.Name]
+	// Skip results which don't belong to the current view, e.g. the first result after switching views.
+	if !ok || s.Error != nil || s.Pgstat.Query != config.view.Query || len(config.view.Cols) != s.Pgstat.Result.Ncols {
+		return false, nil
+	}
+	delete(config.pending, config.view.Name)
+
+	column := func(name string) (int, error) {
+		for i, c := range config.view.Cols {
+			if c == name {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("unknown column '%s' in '%s' view", name, config.view.Name)
+	}
+
+	var changed bool
+	if settings.OrderColumn != "" {
+		idx, err := column(settings.OrderColumn)
+		if err != nil {
+			return false, err
+		}
+		changed = config.view.OrderKey != idx
+		config.view.OrderKey = idx
+	}
+
+	for name, pattern := range settings.Filters {
+		idx, err := column(name)
+		if err != nil {
+			return changed, err
+		}
+		config.view.Filters[idx] = regexp.MustCompile(pattern) // patterns are validated when config is read
+	}
+
+	for name, width := range settings.Widths {
+		idx, err := column(name)
+		if err != nil {
+			return changed, err
+		}
+		config.view.ColsWidth[idx] = math.Max(width, len(name))
+		setWidth(config, config.view.Name, name, config.view.ColsWidth[idx])
+	}
+
+	if len(settings.Columns) > 0 || len(settings.Hidden) > 0 {
+		layout := columnLayout{order: settings.Columns, hidden: map[string]bool{}}
+		for _, name := range settings.Columns {
+			if _, err := column(name); err != nil {
+				return changed, err
+			}
+		}
+		for _, name := range settings.Hidden {
+			if _, err := column(name); err != nil {
+				return changed, err
+			}
+			layout.hidden[name] = true
+		}
+		setLayout(config, layout)
+	}
+
+	return changed, nil
+}
+
+// setWidth remembers width of the column changed by user.
+func setWidth(config *config, view string, column string, width int) {
+	if config.widths[view] == nil {
+		config.widths[view] = map[string]int{}
+	}
+	config.widths[view][column] = width
+}
+
+// currentUserConfig returns settings of the current session. Settings of views which haven't been shown yet
+// are taken from the configuration file.
+func currentUserConfig(config *config) userConfig {
+	uc := userConfig{
+		View:     config.view.Name,
+		Refresh:  int(config.refresh / time.Second),
+		QueryAge: config.queryOptions.QueryAgeThresh,
+		Verbose:  config.verbose,
+		Theme:    config.settings.Theme,
+		Views:    map[string]viewSettings{},
+		// Custom views and thresholds are not changed by user in runtime.
+		CustomViews: config.settings.CustomViews,
+		Thresholds:  config.settings.Thresholds,
+	}
+
+	showIdle := !config.queryOptions.ShowNoIdle
+	uc.ShowIdle = &showIdle
+
+	for name, s := range config.settings.Views {
+		uc.Views[name] = s
+	}
+
+	names := make([]string, 0, len(config.views))
+	for name := range config.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := config.views[name]
+		if name == config.view.Name {
+			v = config.view
+		}
+
+		// Columns of views which haven't been shown are unknown.
+		if len(v.Cols) == 0 {
+			continue
+		}
+
+		// Settings of views which haven't been applied yet are kept as is.
+		if _, ok := config.pending[name]; ok {
+			continue
+		}
+
+		desc := v.OrderDesc
+		s := viewSettings{OrderDesc: &desc}
+		if v.OrderKey < len(v.Cols) {
+			s.OrderColumn = v.Cols[v.OrderKey]
+		}
+
+		for idx, re := range v.Filters {
+			if idx < len(v.Cols) {
+				if s.Filters == nil {
+					s.Filters = map[string]string{}
+				}
+				s.Filters[v.Cols[idx]] = re.String()
+			}
+		}
+
+		if len(config.widths[name]) > 0 {
+			s.Widths = map[string]int{}
+			for col, width := range config.widths[name] {
+				s.Widths[col] = width
+			}
+		}
+
+		if layout, ok := config.layouts[name]; ok {
+			for _, idx := range layout.columns(v.Cols) {
+				s.Columns = append(s.Columns, v.Cols[idx])
+			}
+			s.Hidden = layout.hiddenNames()
+		}
+
+		uc.Views[name] = s
+	}
+
+	if len(uc.Views) == 0 {
+		uc.Views = nil
+	}
+
+	return uc
+}
+
+// saveUserConfig saves settings of the current session into configuration file.
+func saveUserConfig(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if app.config.configFile == "" {
+			printCmdline(g, "Save config: do nothing, config file is not specified")
+			return nil
+		}
+
+		uc := currentUserConfig(app.config)
+
+		err := writeUserConfig(app.config.configFile, uc)
+		if err != nil {
+			printCmdline(g, "Save config: %s", err)
+			return nil
+		}
+
+		app.config.settings = uc
+		printCmdline(g, "Save config: saved to %s", app.config.configFile)
+		return nil
+	}
+}
@@ -0,0 +1,325 @@
+package top
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readUserConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// Missing file is not an error.
+	uc, err := readUserConfig(filepath.Join(dir, "missing.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, userConfig{}, uc)
+
+	uc, err = readUserConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, userConfig{}, uc)
+
+	showIdle, desc := false, false
+	testcases := []struct {
+		data  string
+		want  userConfig
+		valid bool
+	}{
+		{
+			data: `view = "tables"
+refresh = 5
+show_idle = false
+query_age = "00:00:10"
+verbose = true
+
+[views.tables]
+order_column = "seq_scan"
+order_desc = false
+filters = { relname = "^pgbench" }
+widths = { relname = 30 }
+`,
+			want: userConfig{
+				View: "tables", Refresh: 5, ShowIdle: &showIdle, QueryAge: "00:00:10", Verbose: true,
+				Views: map[string]viewSettings{
+					"tables": {
+						OrderColumn: "seq_scan", OrderDesc: &desc,
+						Filters: map[string]string{"relname": "^pgbench"}, Widths: map[string]int{"relname": 30},
+					},
+				},
+			},
+			valid: true,
+		},
+		{data: `refresh = 0`, want: userConfig{}, valid: true},
+		{data: `refresh = 500`, valid: false},
+		{data: `query_age = "invalid"`, valid: false},
+		{data: `unknown = true`, valid: false},
+		{data: `view = `, valid: false},
+		{data: "[views.tables]\nfilters = { relname = \"(\" }", valid: false},
+		{data: "[views.tables]\nwidths = { relname = 0 }", valid: false},
+		{
+			data: "[custom_views.queue]\nquery = \"SELECT 1\"\nhotkey = \"e\"",
+			want: userConfig{CustomViews: map[string]view.Custom{"queue": {Query: "SELECT 1", Hotkey: "e"}}}, valid: true,
+		},
+		{data: "[custom_views.tables]\nquery = \"SELECT 1\"", valid: false},
+		{data: "[custom_views.queue]\nquery = \"SELECT 1\"\nunknown = 1", valid: false},
+		{data: `theme = "light"`, want: userConfig{Theme: "light"}, valid: true},
+		{data: `theme = "dark"`, valid: false},
+		{
+			data:  "[thresholds.activity.xact_age]\nwarning = \"> 30s\"\ncritical = \"\"",
+			want:  userConfig{Thresholds: map[string]map[string]threshold{"activity": {"xact_age": {Warning: "> 30s"}}}},
+			valid: true,
+		},
+		{
+			data:  "[thresholds.iostat.\"%util\"]\ncritical = \"> 95\"",
+			want:  userConfig{Thresholds: map[string]map[string]threshold{"iostat": {"%util": {Critical: "> 95"}}}},
+			valid: true,
+		},
+		{data: "[thresholds.unknown.xact_age]\nwarning = \"> 30s\"", valid: false},
+		{data: "[thresholds.activity.xact_age]\nwarning = \"30s\"", valid: false},
+		{data: "[thresholds.activity.state]\nwarning = \"> idle\"", valid: false},
+		{data: "[thresholds.activity.xact_age]\nunknown = \"> 30s\"", valid: false},
+	}
+
+	for i, tc := range testcases {
+		t.Run(fmt.Sprintln(i), func(t *testing.T) {
+			filename := filepath.Join(dir, "config.toml")
+			assert.NoError(t, os.WriteFile(filename, []byte(tc.data), 0600))
+
+			got, err := readUserConfig(filename)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_writeUserConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "pgcenter", "config.toml")
+
+	showIdle, desc := true, true
+	uc := userConfig{
+		View: "activity", Refresh: 2, ShowIdle: &showIdle, QueryAge: "00:00:00.0",
+		Views: map[string]viewSettings{
+			"activity": {OrderColumn: "pid", OrderDesc: &desc, Filters: map[string]string{"state": "active"}},
+		},
+	}
+
+	assert.NoError(t, writeUserConfig(filename, uc))
+
+	got, err := readUserConfig(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, uc, got)
+
+	// No temporary files are left.
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// New file is readable by owner only, mode of existing file is kept.
+	st, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	assert.NoError(t, os.Chmod(filename, 0644))
+	assert.NoError(t, writeUserConfig(filename, uc))
+	st, err = os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), st.Mode().Perm())
+
+	// Symlink is kept, its target is rewritten.
+	link := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.Symlink(filename, link))
+	uc.View = "tables"
+	assert.NoError(t, writeUserConfig(link, uc))
+
+	st, err = os.Lstat(link)
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, st.Mode().Type())
+
+	got, err = readUserConfig(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, uc, got)
+
+	entries, err = os.ReadDir(filepath.Dir(link))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func Test_userConfig_apply(t *testing.T) {
+	showIdle, desc := true, false
+	uc := userConfig{
+		View: "tables", Refresh: 10, ShowIdle: &showIdle, QueryAge: "00:00:05", Verbose: true,
+		Views: map[string]viewSettings{
+			"tables":  {OrderColumn: "seq_scan", OrderDesc: &desc},
+			"indexes": {OrderDesc: &desc},
+			"unknown": {OrderColumn: "name"},
+		},
+	}
+
+	opts := query.Options{ShowNoIdle: true}
+	uc.applyOptions(&opts)
+	assert.False(t, opts.ShowNoIdle)
+	assert.Equal(t, "00:00:05", opts.QueryAgeThresh)
+
+	config := newConfig()
+	config.view = config.views["activity"]
+	assert.NoError(t, uc.apply(config))
+
+	assert.Equal(t, "tables", config.view.Name)
+	assert.False(t, config.view.OrderDesc)
+	assert.False(t, config.views["indexes"].OrderDesc)
+	assert.Equal(t, 10*time.Second, config.refresh)
+	assert.True(t, config.verbose)
+	assert.True(t, config.view.Verbose)
+	assert.True(t, config.views["activity"].Verbose)
+	assert.Equal(t, map[string]viewSettings{"tables": uc.Views["tables"]}, config.pending)
+
+	// Thresholds replace built-in thresholds of the same column.
+	config = newConfig()
+	assert.Equal(t, criticalColor, config.thresholds.color("activity", "xact_age", "00:10:00"))
+	uc = userConfig{Thresholds: map[string]map[string]threshold{
+		"activity": {"xact_age": {Warning: "> 1h"}, "state": {Critical: "= idle in transaction"}},
+	}}
+	assert.NoError(t, uc.apply(config))
+	assert.Equal(t, "", config.thresholds.color("activity", "xact_age", "00:10:00"))
+	assert.Equal(t, warningColor, config.thresholds.color("activity", "xact_age", "1 day 00:10:00"))
+	assert.Equal(t, criticalColor, config.thresholds.color("activity", "state", "idle in transaction"))
+	assert.Equal(t, criticalColor, config.thresholds.color("replslots", "wal_status", "lost"))
+
+	// Unknown view.
+	config = newConfig()
+	assert.Error(t, userConfig{View: "unknown"}.apply(config))
+}
+
+func Test_applyViewSettings(t *testing.T) {
+	newStat := func(q string) stat.Stat {
+		return stat.Stat{Pgstat: stat.Pgstat{
+			Query: q,
+			Result: stat.PGresult{
+				Valid: true, Ncols: 3, Nrows: 1, Cols: []string{"relname", "seq_scan", "idx_scan"},
+				Values: [][]sql.NullString{{{String: "t1", Valid: true}, {String: "1", Valid: true}, {String: "2", Valid: true}}},
+			},
+		}}
+	}
+
+	config := newConfig()
+	config.view = config.views["tables"]
+	config.view.Query = "SELECT tables"
+	config.pending["tables"] = viewSettings{
+		OrderColumn: "seq_scan",
+		Filters:     map[string]string{"relname": "^t"},
+		Widths:      map[string]int{"relname": 2, "idx_scan": 20},
+		Columns:     []string{"idx_scan"},
+		Hidden:      []string{"seq_scan"},
+	}
+
+	// Result of another view is skipped.
+	alignViewToResult(config, newStat("SELECT indexes").Result)
+	changed, err := applyViewSettings(config, newStat("SELECT indexes"))
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Contains(t, config.pending, "tables")
+
+	changed, err = applyViewSettings(config, newStat("SELECT tables"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, config.pending, "tables")
+	assert.Equal(t, 1, config.view.OrderKey)
+	assert.Equal(t, "^t", config.view.Filters[0].String())
+	assert.Equal(t, 7, config.view.ColsWidth[0]) // not less than column's name
+	assert.Equal(t, 20, config.view.ColsWidth[2])
+	assert.Equal(t, map[string]int{"relname": 7, "idx_scan": 20}, config.widths["tables"])
+	assert.Equal(t, []int{2, 0}, displayColumns(config, config.view.Cols))
+
+	// Settings are applied once.
+	changed, err = applyViewSettings(config, newStat("SELECT tables"))
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// Unknown column.
+	config.pending["tables"] = viewSettings{OrderColumn: "unknown"}
+	_, err = applyViewSettings(config, newStat("SELECT tables"))
+	assert.Error(t, err)
+
+	config.pending["tables"] = viewSettings{Hidden: []string{"unknown"}}
+	_, err = applyViewSettings(config, newStat("SELECT tables"))
+	assert.Error(t, err)
+}
+
+func Test_currentUserConfig(t *testing.T) {
+	desc, asc := true, false
+	config := newConfig()
+	config.settings = userConfig{
+		Theme: "monochrome",
+		Views: map[string]viewSettings{
+			"indexes":   {OrderColumn: "idx_scan"},
+			"functions": {OrderColumn: "calls"},
+		},
+		Thresholds: map[string]map[string]threshold{"activity": {"xact_age": {Warning: "> 1h"}}},
+	}
+	config.pending["functions"] = config.settings.Views["functions"]
+	config.refresh = 3 * time.Second
+	config.queryOptions = query.Options{ShowNoIdle: true, QueryAgeThresh: "00:00:01"}
+
+	// Current view with known columns, sort order, filter and changed width.
+	config.view = config.views["tables"]
+	config.view.Cols = []string{"relname", "seq_scan", "idx_scan"}
+	config.view.OrderKey = 2
+	config.view.OrderDesc = false
+	config.view.Filters = map[int]*regexp.Regexp{0: regexp.MustCompile("^t")}
+	setWidth(config, "tables", "relname", 30)
+	config.layouts["tables"] = columnLayout{order: []string{"idx_scan"}, hidden: map[string]bool{"seq_scan": true}}
+
+	// Shown view, without changes.
+	v := config.views["indexes"]
+	v.Cols = []string{"relname", "indexrelname", "idx_scan"}
+	v.OrderKey = 0
+	config.views["indexes"] = v
+
+	uc := currentUserConfig(config)
+
+	showIdle := false
+	assert.Equal(t, userConfig{
+		View: "tables", Refresh: 3, ShowIdle: &showIdle, QueryAge: "00:00:01", Theme: "monochrome",
+		Thresholds: config.settings.Thresholds,
+		Views: map[string]viewSettings{
+			"tables": {
+				OrderColumn: "idx_scan", OrderDesc: &asc,
+				Filters: map[string]string{"relname": "^t"}, Widths: map[string]int{"relname": 30},
+				Columns: []string{"idx_scan", "relname", "seq_scan"}, Hidden: []string{"seq_scan"},
+			},
+			"indexes":   {OrderColumn: "relname", OrderDesc: &desc},
+			"functions": {OrderColumn: "calls"},
+		},
+	}, uc)
+}
+
+func Test_increaseWidth_remembered(t *testing.T) {
+	config := newConfig()
+	config.view = config.views["activity"]
+	config.view.Cols = []string{"pid"}
+	config.view.ColsWidth = map[int]int{0: 10}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		<-config.viewCh
+		wg.Done()
+	}()
+
+	assert.NoError(t, increaseWidth(config)(nil, nil))
+	wg.Wait()
+	assert.Equal(t, map[string]int{"pid": 14}, config.widths["activity"])
+}